@@ -15,6 +15,8 @@ require (
 	github.com/spf13/viper v1.6.3
 	github.com/tidwall/gjson v1.6.0
 	github.com/zyedidia/highlight v0.0.0-20200217010119-291680feaca1
+	golang.org/x/crypto v0.0.0-20190308221718-c2843e01d9a2
 	golang.org/x/sys v0.0.0-20200509044756-6aff5f38e54f // indirect
 	gopkg.in/square/go-jose.v2 v2.5.0
+	gopkg.in/yaml.v2 v2.2.4
 )
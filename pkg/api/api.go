@@ -2,16 +2,32 @@ package api
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
 
+	"github.com/snapmaster-io/snap/pkg/definition"
+	"github.com/snapmaster-io/snap/pkg/secrets"
 	"github.com/snapmaster-io/snap/pkg/utils"
 	"github.com/spf13/viper"
 )
 
+// ErrDryRun is returned instead of a response for requests that change data when dry runs are enabled
+var ErrDryRun = errors.New("dry run: request not sent")
+
+// whether requests that change data are printed instead of sent
+var dryRun bool
+
+// SetDryRun enables or disables dry runs.  During a dry run, requests other than GET are
+// printed out (with their payload) instead of being sent, and return ErrDryRun.
+func SetDryRun(enabled bool) {
+	dryRun = enabled
+}
+
 // Get calls the API at the relative path, and returns the data retrieved or an error
 func Get(path string) ([]byte, error) {
 	return call(path, "GET", nil)
@@ -19,31 +35,38 @@ func Get(path string) ([]byte, error) {
 
 // Post calls the API at the relative path with the payload, and returns the data retrieved or an error
 func Post(path string, payload []byte) ([]byte, error) {
-	r := bytes.NewReader(payload)
-	return call(path, "POST", r)
+	return call(path, "POST", payload)
 }
 
-func call(path string, verb string, payload interface{ io.Reader }) ([]byte, error) {
+// Request calls the API at the relative path with the verb and payload (which may be nil).
+// Unlike Get and Post, it returns an *Error instead of exiting when the user isn't logged
+// in, the request can't be executed, or the token has expired.
+func Request(verb string, path string, payload []byte) ([]byte, error) {
 	// retrieve access token
 	accessToken := viper.GetString("AccessToken")
 	if len(accessToken) < 1 {
-		utils.PrintError("login required before executing this command")
-		os.Exit(1)
+		return nil, &Error{Message: "login required before executing this command"}
 	}
 
 	// retrieve API URL
 	apiURL := viper.GetString("APIURL")
 	if len(apiURL) < 1 {
-		utils.PrintError("API URL required but not found")
-		os.Exit(1)
+		return nil, &Error{Message: "API URL required but not found"}
 	}
 
 	// construct the URL and request
 	url := apiURL + path
-	req, err := http.NewRequest(verb, url, payload)
+	if dryRun && verb != "GET" {
+		printDryRun(verb, url, payload)
+		return nil, ErrDryRun
+	}
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequest(verb, url, body)
 	if err != nil {
-		utils.PrintErrorMessage(fmt.Sprintf("could not create request with URL %s", url), err)
-		os.Exit(1)
+		return nil, &Error{Message: fmt.Sprintf("could not create request with URL %s", url), Err: err}
 	}
 
 	// add headers and execute the request
@@ -51,28 +74,128 @@ func call(path string, verb string, payload interface{ io.Reader }) ([]byte, err
 	req.Header.Add("authorization", fmt.Sprintf("Bearer %s", accessToken))
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
-		utils.PrintErrorMessage(fmt.Sprintf("could not execute HTTP request with URL %s", url), err)
-		os.Exit(1)
+		return nil, &Error{Message: fmt.Sprintf("could not execute HTTP request with URL %s", url), Err: err}
 	}
 
 	// check for Unauthorized
 	if res.StatusCode == 401 {
-		utils.PrintError("token expired; please log in again")
-		os.Exit(1)
+		return nil, &Error{Message: "token expired; please log in again"}
 	}
 
 	// process the response
 	defer res.Body.Close()
 	contents, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		utils.PrintErrorMessage(fmt.Sprintf("error reading HTTP response from HTTP request for %s", url), err)
-		os.Exit(1)
+		return nil, &Error{Message: fmt.Sprintf("error reading HTTP response from HTTP request for %s", url), Err: err}
 	}
 
 	// check for an HTML response which would indicate an error
-	html := string(contents[0:15])
-	if html == "<!doctype html>" {
-		utils.PrintError("token expired; please log in again")
+	if bytes.HasPrefix(contents, []byte("<!doctype html>")) {
+		return nil, &Error{Message: "token expired; please log in again"}
+	}
+
+	return contents, nil
+}
+
+// Error describes a call to the API that failed before a response could be returned
+type Error struct {
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+// CheckStatus returns an error with the response message if the status of the response isn't "success"
+func CheckStatus(response []byte) error {
+	var status struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(response, &status); err != nil {
+		return fmt.Errorf("could not parse response: %s", err)
+	}
+
+	if status.Status != "success" {
+		if status.Message == "" {
+			return fmt.Errorf("operation status: %s", status.Status)
+		}
+		return errors.New(status.Message)
+	}
+
+	return nil
+}
+
+// printDryRun prints a request that isn't sent because of a dry run, in a single write so
+// that concurrent requests don't interleave.  Credential values and secret parameter
+// values are redacted.
+func printDryRun(verb string, url string, payload []byte) {
+	payload = redactCredentials(payload)
+	var indented bytes.Buffer
+	if json.Indent(&indented, payload, "", "  ") != nil {
+		indented.Reset()
+		indented.Write(payload)
+	}
+	fmt.Printf("dry run: %s %s\n%s\n", verb, url, indented.String())
+}
+
+// redactCredentials replaces the values of the connectionInfo entries in the payload,
+// other than the credential set name, and the values of secret activation parameters
+// (other than secret references), so that secrets aren't printed
+func redactCredentials(payload []byte) []byte {
+	var data map[string]interface{}
+	if json.Unmarshal(payload, &data) != nil {
+		return payload
+	}
+	entries, hasCredentials := data["connectionInfo"].([]interface{})
+	params, hasParams := data["params"].([]interface{})
+	if !hasCredentials && !hasParams {
+		return payload
+	}
+
+	for _, entry := range entries {
+		if param, ok := entry.(map[string]interface{}); ok && param["type"] != "name" && param["value"] != nil {
+			param["value"] = "<redacted>"
+		}
+	}
+	for _, entry := range params {
+		param, ok := entry.(map[string]interface{})
+		if !ok || param["value"] == nil {
+			continue
+		}
+		name, _ := param["name"].(string)
+		paramType, _ := param["type"].(string)
+		value, _ := param["value"].(string)
+		if definition.IsSecret(name, paramType) && !secrets.IsReference(value) {
+			param["value"] = "<redacted>"
+		}
+	}
+	var redacted bytes.Buffer
+	encoder := json.NewEncoder(&redacted)
+	encoder.SetEscapeHTML(false)
+	if encoder.Encode(data) != nil {
+		return payload
+	}
+
+	return bytes.TrimSpace(redacted.Bytes())
+}
+
+// call executes the request, and prints the error and exits if it failed (other than for a dry run)
+func call(path string, verb string, payload []byte) ([]byte, error) {
+	contents, err := Request(verb, path, payload)
+	if err == ErrDryRun {
+		return nil, err
+	}
+	if err != nil {
+		if e, ok := err.(*Error); ok && e.Err != nil {
+			utils.PrintErrorMessage(e.Message, e.Err)
+		} else {
+			utils.PrintError(err.Error())
+		}
 		os.Exit(1)
 	}
 
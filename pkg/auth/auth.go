@@ -21,6 +21,14 @@ import (
 	"gopkg.in/square/go-jose.v2/jwt"
 )
 
+// TokenURL is the endpoint that authorization codes are traded at for access tokens, and
+// AuthorizeURL, when it isn't empty, replaces the authorization endpoint of the auth domain.
+// They are variables only so that tests can point the login at a fake server.
+var (
+	TokenURL     = "https://snapmaster-dev.auth0.com/oauth/token"
+	AuthorizeURL = ""
+)
+
 // AuthorizeUser implements the PKCE OAuth2 flow.
 func AuthorizeUser(clientID string, authDomain string, redirectURL string) {
 	// initialize the code verifier
@@ -30,13 +38,17 @@ func AuthorizeUser(clientID string, authDomain string, redirectURL string) {
 	codeChallenge := CodeVerifier.CodeChallengeS256()
 
 	// construct the authorization URL (with Auth0 as the authorization provider)
+	authorizeURL := AuthorizeURL
+	if authorizeURL == "" {
+		authorizeURL = fmt.Sprintf("https://%s/authorize", authDomain)
+	}
 	authorizationURL := fmt.Sprintf(
-		"https://%s/authorize?audience=https://api.snapmaster.io"+
+		"%s?audience=https://api.snapmaster.io"+
 			"&scope=openid+profile+email"+
 			"&response_type=code&client_id=%s"+
 			"&code_challenge=%s"+
 			"&code_challenge_method=S256&redirect_uri=%s",
-		authDomain, clientID, codeChallenge, redirectURL)
+		authorizeURL, clientID, codeChallenge, redirectURL)
 
 	// start a web server to listen on a callback URL
 	server := &http.Server{Addr: redirectURL}
@@ -248,7 +260,7 @@ experience ;)
 // getAccessToken trades the authorization code retrieved from the first OAuth2 leg for an access token
 func getAccessToken(clientID string, codeVerifier string, authorizationCode string, callbackURL string) (map[string]interface{}, error) {
 	// set the url and form-encoded data for the POST to the access token endpoint
-	url := "https://snapmaster-dev.auth0.com/oauth/token"
+	url := TokenURL
 	data := fmt.Sprintf(
 		"grant_type=authorization_code&client_id=%s"+
 			"&code_verifier=%s"+
@@ -0,0 +1,291 @@
+// Package bundle reads and writes export bundles: gzipped tar archives of an account's snap
+// definitions, active snaps with their parameter values, connections, and profile, for
+// backing up an account or migrating it to another environment.  Secret parameter values
+// are redacted, or sealed with a key derived from a passphrase.
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Version is the version of the bundle format
+const Version = 1
+
+// Redacted is the value of a secret parameter that was redacted
+const Redacted = "<redacted>"
+
+// sealedPrefix starts the value of a secret parameter that was sealed
+const sealedPrefix = "sealed:"
+
+// ErrPassphrase is returned when a sealed value can't be opened with the passphrase
+var ErrPassphrase = errors.New("wrong passphrase, or the bundle is corrupt")
+
+// Manifest describes a bundle
+type Manifest struct {
+	Version int    `json:"version"`
+	Created int64  `json:"created"`
+	APIURL  string `json:"apiUrl"`
+	Account string `json:"account"`
+	// Salt is the salt of the key that secret values are sealed with; it is empty if they
+	// are redacted
+	Salt string `json:"salt,omitempty"`
+}
+
+// Snap is a snap definition in a bundle
+type Snap struct {
+	SnapID  string `json:"snapId"`
+	Private bool   `json:"private"`
+	Text    string `json:"-"`
+}
+
+// Param is a parameter value of an active snap in a bundle
+type Param struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	// Secret is set for values that are redacted or sealed
+	Secret bool `json:"secret,omitempty"`
+}
+
+// ActiveSnap is an active snap in a bundle
+type ActiveSnap struct {
+	ActiveSnapID string  `json:"activeSnapId"`
+	SnapID       string  `json:"snapId"`
+	State        string  `json:"state"`
+	Params       []Param `json:"params"`
+}
+
+// Connection is a connected tool in a bundle, with the names of its credential sets (but
+// not their values)
+type Connection struct {
+	Provider       string   `json:"provider"`
+	CredentialSets []string `json:"credentialSets,omitempty"`
+}
+
+// Bundle is the contents of an export bundle
+type Bundle struct {
+	Manifest    Manifest
+	Snaps       []Snap
+	ActiveSnaps []ActiveSnap
+	Connections []Connection
+	Profile     map[string]interface{}
+}
+
+// New returns an empty bundle of the account at the API URL
+func New(apiURL string, account string) *Bundle {
+	return &Bundle{
+		Manifest: Manifest{
+			Version: Version,
+			Created: time.Now().UnixNano() / int64(time.Millisecond),
+			APIURL:  apiURL,
+			Account: account,
+		},
+		Snaps:       []Snap{},
+		ActiveSnaps: []ActiveSnap{},
+		Connections: []Connection{},
+		Profile:     map[string]interface{}{},
+	}
+}
+
+// Name returns the name of a snap: the part of its ID after the account
+func Name(snapID string) string {
+	return snapID[strings.LastIndex(snapID, "/")+1:]
+}
+
+// Write writes the bundle as a gzipped tar archive, with the JSON files manifest.json,
+// snaps.json, activesnaps.json, connections.json, and profile.json, and the definition of
+// each snap in snaps/<name>.yaml
+func (b *Bundle) Write(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	files := []struct {
+		name  string
+		value interface{}
+	}{
+		{"manifest.json", b.Manifest},
+		{"snaps.json", b.Snaps},
+		{"activesnaps.json", b.ActiveSnaps},
+		{"connections.json", b.Connections},
+		{"profile.json", b.Profile},
+	}
+	for _, f := range files {
+		contents, err := json.MarshalIndent(f.value, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeFile(tw, f.name, contents); err != nil {
+			return err
+		}
+	}
+	for _, s := range b.Snaps {
+		if err := writeFile(tw, path.Join("snaps", Name(s.SnapID)+".yaml"), []byte(s.Text)); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// writeFile writes a file into the tar archive
+func writeFile(tw *tar.Writer, name string, contents []byte) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(contents)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+
+	_, err := tw.Write(contents)
+	return err
+}
+
+// Read reads a bundle written by Write
+func Read(r io.Reader) (*Bundle, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a bundle: %s", err)
+	}
+	tr := tar.NewReader(gz)
+
+	files := make(map[string][]byte)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("not a bundle: %s", err)
+		}
+		contents, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files[header.Name] = contents
+	}
+
+	b := &Bundle{}
+	for name, value := range map[string]interface{}{
+		"manifest.json":    &b.Manifest,
+		"snaps.json":       &b.Snaps,
+		"activesnaps.json": &b.ActiveSnaps,
+		"connections.json": &b.Connections,
+		"profile.json":     &b.Profile,
+	} {
+		contents, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("not a bundle: %s is missing", name)
+		}
+		if err := json.Unmarshal(contents, value); err != nil {
+			return nil, fmt.Errorf("could not parse %s: %s", name, err)
+		}
+	}
+	if b.Manifest.Version > Version {
+		return nil, fmt.Errorf("the bundle is version %d, and this version of snap reads up to version %d", b.Manifest.Version, Version)
+	}
+
+	for i, s := range b.Snaps {
+		text, ok := files[path.Join("snaps", Name(s.SnapID)+".yaml")]
+		if !ok {
+			return nil, fmt.Errorf("the definition of snap %s is missing", s.SnapID)
+		}
+		b.Snaps[i].Text = string(text)
+	}
+
+	return b, nil
+}
+
+// Sealed returns whether the bundle's secret values are sealed, rather than redacted
+func (b *Bundle) Sealed() bool {
+	return b.Manifest.Salt != ""
+}
+
+// Key derives the key that the bundle's secret values are sealed with from a passphrase,
+// choosing a new salt if the bundle doesn't have one yet
+func (b *Bundle) Key(passphrase string) ([]byte, error) {
+	if b.Manifest.Salt == "" {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		b.Manifest.Salt = base64.StdEncoding.EncodeToString(salt)
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(b.Manifest.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt in the manifest: %s", err)
+	}
+	return scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+}
+
+// Seal encrypts a secret value with the key, using AES-256-GCM
+func Seal(key []byte, value string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(value), nil)
+	return sealedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// IsSealed returns whether a value was sealed with Seal
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, sealedPrefix)
+}
+
+// Open decrypts a value sealed with Seal
+func Open(key []byte, value string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, sealedPrefix))
+	if err != nil {
+		return "", ErrPassphrase
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", ErrPassphrase
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", ErrPassphrase
+	}
+	return string(plaintext), nil
+}
+
+// newGCM returns an AES-GCM cipher with the key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,130 @@
+package bundle
+
+import (
+	"bytes"
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testKey derives the key of a bundle with a fixed salt from the passphrase
+func testKey(t *testing.T, passphrase string) []byte {
+	t.Helper()
+
+	b := New("https://www.snapmaster.io", "snaptest")
+	b.Manifest.Salt = base64.StdEncoding.EncodeToString([]byte("0123456789abcdef"))
+	key, err := b.Key(passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+func TestSealOpen(t *testing.T) {
+	key := testKey(t, "correct horse")
+
+	for _, value := range []string{"s3cret", "", "a longer value with unicode: ключ 🔑"} {
+		sealed, err := Seal(key, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !IsSealed(sealed) || (value != "" && strings.Contains(sealed, value)) {
+			t.Errorf("sealed %q as %q", value, sealed)
+		}
+		opened, err := Open(key, sealed)
+		if err != nil || opened != value {
+			t.Errorf("opened %q as %q, %v", value, opened, err)
+		}
+	}
+
+	// each seal uses a new nonce
+	first, _ := Seal(key, "s3cret")
+	second, _ := Seal(key, "s3cret")
+	if first == second {
+		t.Errorf("sealed the same value twice as %q", first)
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	key := testKey(t, "correct horse")
+	sealed, err := Seal(key, "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(sealed, sealedPrefix))
+	encode := func(data []byte) string {
+		return sealedPrefix + base64.StdEncoding.EncodeToString(data)
+	}
+	tampered := append([]byte{}, decoded...)
+	tampered[len(tampered)-1] ^= 0x01
+
+	tests := []struct {
+		name  string
+		key   []byte
+		value string
+	}{
+		{"wrong passphrase", testKey(t, "wrong horse"), sealed},
+		{"tampered ciphertext", key, encode(tampered)},
+		{"truncated ciphertext", key, encode(decoded[:len(decoded)-4])},
+		{"shorter than the nonce", key, encode(decoded[:8])},
+		{"empty", key, sealedPrefix},
+		{"not base64", key, sealedPrefix + "not base64!"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if opened, err := Open(test.key, test.value); err != ErrPassphrase {
+				t.Errorf("opened %q as %q, %v; want ErrPassphrase", test.value, opened, err)
+			}
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	// a new bundle gets a random salt, which the key is derived with
+	b := New("https://www.snapmaster.io", "snaptest")
+	key, err := b.Key("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.Sealed() || len(key) != 32 {
+		t.Fatalf("salt %q, key of %d bytes", b.Manifest.Salt, len(key))
+	}
+	again, _ := b.Key("correct horse")
+	if !bytes.Equal(key, again) {
+		t.Error("derived a different key from the same passphrase and salt")
+	}
+	if other, _ := New("", "").Key("correct horse"); bytes.Equal(key, other) {
+		t.Error("derived the same key with a different salt")
+	}
+
+	b.Manifest.Salt = "not base64!"
+	if _, err := b.Key("correct horse"); err == nil {
+		t.Error("derived a key from an invalid salt")
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	b := New("https://www.snapmaster.io", "snaptest")
+	b.Snaps = []Snap{{SnapID: "snaptest/deploy", Private: true, Text: "name: deploy\n"}}
+	b.ActiveSnaps = []ActiveSnap{{ActiveSnapID: "1", SnapID: "snaptest/deploy", State: "active", Params: []Param{{Name: "token", Value: Redacted, Secret: true}}}}
+	b.Connections = []Connection{{Provider: "docker", CredentialSets: []string{"ci"}}}
+	b.Profile = map[string]interface{}{"account": "snaptest"}
+
+	var buf bytes.Buffer
+	if err := b.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, b) {
+		t.Errorf("read %+v, want %+v", read, b)
+	}
+
+	if _, err := Read(strings.NewReader("not a bundle")); err == nil || !strings.Contains(err.Error(), "not a bundle") {
+		t.Errorf("read a file that isn't a bundle: %v", err)
+	}
+}
@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"bufio"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -21,7 +20,8 @@ var activateCmd = &cobra.Command{
 	If only the snap ID is passed in, the command will prompt for parameters.
 	
 	If the parameter file was provided, those parameter values will be used to activate the snap.`,
-	Args: cobra.MinimumNArgs(1),
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeSnapIDs,
 	Run: func(cmd *cobra.Command, args []string) {
 		snapID := args[0]
 		paramsFile, err := cmd.Flags().GetString("params-file")
@@ -48,6 +48,8 @@ var activateCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(activateCmd)
 	activateCmd.Flags().StringP("params-file", "p", "", "a yaml file that defines snap parameter values")
+
+	pickable(activateCmd, false, snapIDPicker)
 }
 
 func getSnapParameters(snapID string, path string, jsonPath string) []map[string]string {
@@ -58,26 +60,15 @@ func getSnapParameters(snapID string, path string, jsonPath string) []map[string
 }
 
 func processActivateCommand(snapID string, action string, params []map[string]string) {
-	path := "/activesnaps"
-
-	// set up the data map
-	data := make(map[string]interface{})
-	data["action"] = action
-	data["snapId"] = snapID
-	data["params"] = params
-
-	payload, err := json.Marshal(data)
-	if err != nil {
-		utils.PrintError(fmt.Sprintf("could not serialize payload into JSON\nerror: %s\n", err))
-		os.Exit(1)
+	response, err := postActivation(snapID, action, params)
+	if err == api.ErrDryRun {
+		return
 	}
-
-	// execute the API call
-	response, err := api.Post(path, payload)
 	if err != nil {
 		utils.PrintError(fmt.Sprintf("could not retrieve data\nerror: %s\n", err))
 		os.Exit(1)
 	}
+	response = journalActivation(response)
 
 	format, err := rootCmd.PersistentFlags().GetString("format")
 	if format == "json" {
@@ -88,20 +79,36 @@ func processActivateCommand(snapID string, action string, params []map[string]st
 	print.ActiveSnapStatusTable(response)
 }
 
-func obtainSnapParameters(snapID string, path string, jsonPath string) []map[string]string {
-	params := getSnapParameters(snapID, path, jsonPath)
+// postActivation posts an activate or edit request with the parameter values to the API and returns the response
+func postActivation(snapID string, action string, params []map[string]string) ([]byte, error) {
+	path := "/activesnaps"
 
-	// create a new reader from stdin
-	reader := bufio.NewReader(os.Stdin)
+	// resolve secret references in the parameter values
+	params, err := resolveSecrets(params)
+	if err != nil {
+		return nil, err
+	}
 
-	// get values for each parameter and store them in the same map
-	for i, param := range params {
-		fmt.Printf("%s (%s): ", param["name"], param["description"])
-		text, _ := reader.ReadString('\n')
+	// set up the data map
+	data := make(map[string]interface{})
+	data["action"] = action
+	data["snapId"] = snapID
+	data["params"] = params
 
-		// store the parameter value without the last character (\n)
-		params[i]["value"] = text[:len(text)-1]
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
 	}
 
+	// execute the API call
+	return api.Post(path, payload)
+}
+
+func obtainSnapParameters(snapID string, path string, jsonPath string) []map[string]string {
+	params := getSnapParameters(snapID, path, jsonPath)
+
+	// get values for each parameter and store them in the same map
+	inputParameters(params)
+
 	return params
 }
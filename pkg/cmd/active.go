@@ -26,18 +26,19 @@ var activeSnapsCmd = &cobra.Command{
 
 // deactivateSnapCmd represents the deactivate snap subcommand
 var deactivateSnapCmd = &cobra.Command{
-	Use:   "deactivate [active snap ID]",
-	Short: "Deactivate a snap",
-	Long: `Deactivate a snap.
+	Use:   "deactivate [active snap ID]...",
+	Short: "Deactivate one or more active snaps",
+	Long: `Deactivate one or more active snaps.
 	
 	Note that once an active snap is deactivated, ALL LOGS ARE DELETED.
 	
-	If you want to stop the active snap from triggering, use the pause subcommand.`,
-	Args: cobra.MinimumNArgs(1),
+	If you want to stop the active snap from triggering, use the pause subcommand.
+	
+	Active snaps can be passed as arguments, or selected with --all, --snap, --state, and --trigger.`,
+	Args:              idsOrSelectors,
+	ValidArgsFunction: completeActiveSnapIDs,
 	Run: func(cmd *cobra.Command, args []string) {
-		// retrieve activeSnapID as the first argument
-		activeSnapID := args[0]
-		processActiveCommand(activeSnapID, "deactivate")
+		processBulkActiveCommand(cmd, args, "deactivate")
 	},
 }
 
@@ -50,7 +51,8 @@ var editActiveSnapCmd = &cobra.Command{
 	If only active snap ID is passed in, the command will prompt for parameters.
 	
 	If the parameter file was provided with the -f flag, those parameter values will be used to activate the snap.`,
-	Args: cobra.MinimumNArgs(1),
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeActiveSnapIDs,
 	Run: func(cmd *cobra.Command, args []string) {
 		activeSnapID := args[0]
 		paramsFile, err := cmd.Flags().GetString("params-file")
@@ -75,10 +77,11 @@ var editActiveSnapCmd = &cobra.Command{
 
 // getActiveSnapCmd represents the get active snap subcommand
 var getActiveSnapCmd = &cobra.Command{
-	Use:   "get [active snap ID]",
-	Short: "Get the state of an active snap",
-	Long:  `Get the state of an active snap.`,
-	Args:  cobra.MinimumNArgs(1),
+	Use:               "get [active snap ID]",
+	Short:             "Get the state of an active snap",
+	Long:              `Get the state of an active snap.`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeActiveSnapIDs,
 	Run: func(cmd *cobra.Command, args []string) {
 		// retrieve activeSnapID as the first argument
 		activeSnapID := args[0]
@@ -95,6 +98,7 @@ var getActiveSnapCmd = &cobra.Command{
 			utils.PrintErrorMessage("could not retrieve data", err)
 			os.Exit(1)
 		}
+		response = addSnapRevisions(response)
 
 		format, err := rootCmd.PersistentFlags().GetString("format")
 		if format == "json" {
@@ -119,7 +123,8 @@ You can obtain a log ID for a specific log entry using this command.
 
 snap active logs [active snap ID] details [log ID] will return the output for each action - either stdout or stderr.
 	`,
-	Args: cobra.MinimumNArgs(1),
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeActiveSnapLogs,
 	Run: func(cmd *cobra.Command, args []string) {
 		// retrieve activeSnapID as the first argument
 		activeSnapID := args[0]
@@ -182,6 +187,7 @@ var listActiveSnapsCmd = &cobra.Command{
 			utils.PrintErrorMessage("could not retrieve data", err)
 			os.Exit(1)
 		}
+		response = addSnapRevisions(response)
 
 		format, err := rootCmd.PersistentFlags().GetString("format")
 		if format == "json" {
@@ -201,27 +207,33 @@ var listActiveSnapsCmd = &cobra.Command{
 
 // pauseActiveSnapCmd represents the pause active snap subcommand
 var pauseActiveSnapCmd = &cobra.Command{
-	Use:   "pause [active snap ID]",
-	Short: "Pause an active snap",
-	Long:  `Pause an active snap.`,
-	Args:  cobra.MinimumNArgs(1),
+	Use:   "pause [active snap ID]...",
+	Short: "Pause one or more active snaps",
+	Long: `Pause one or more active snaps.
+
+Active snaps can be passed as arguments, or selected with --all, --snap, --state, and --trigger.
+When more than one active snap is selected, they are processed --concurrency at a time, and
+the outcome for each one is printed as a table.`,
+	Args:              idsOrSelectors,
+	ValidArgsFunction: completeActiveSnapIDs,
 	Run: func(cmd *cobra.Command, args []string) {
-		// retrieve activeSnapID as the first argument
-		activeSnapID := args[0]
-		processActiveCommand(activeSnapID, "pause")
+		processBulkActiveCommand(cmd, args, "pause")
 	},
 }
 
 // resumeActiveSnapCmd represents the resume active snap subcommand
 var resumeActiveSnapCmd = &cobra.Command{
-	Use:   "resume [active snap ID]",
-	Short: "Resume an active snap",
-	Long:  `Resume an active snap.`,
-	Args:  cobra.MinimumNArgs(1),
+	Use:   "resume [active snap ID]...",
+	Short: "Resume one or more active snaps",
+	Long: `Resume one or more active snaps.
+
+Active snaps can be passed as arguments, or selected with --all, --snap, --state, and --trigger.
+When more than one active snap is selected, they are processed --concurrency at a time, and
+the outcome for each one is printed as a table.`,
+	Args:              idsOrSelectors,
+	ValidArgsFunction: completeActiveSnapIDs,
 	Run: func(cmd *cobra.Command, args []string) {
-		// retrieve activeSnapID as the first argument
-		activeSnapID := args[0]
-		processActiveCommand(activeSnapID, "resume")
+		processBulkActiveCommand(cmd, args, "resume")
 	},
 }
 
@@ -237,22 +249,24 @@ func init() {
 
 	editActiveSnapCmd.Flags().StringP("params-file", "p", "", "a yaml file that defines snap parameter values")
 
+	for _, cmd := range []*cobra.Command{deactivateSnapCmd, pauseActiveSnapCmd, resumeActiveSnapCmd} {
+		addActiveSnapSelectorFlags(cmd)
+		addBulkFlags(cmd)
+	}
+
+	pickable(deactivateSnapCmd, true, activeSnapIDPicker)
+	pickable(editActiveSnapCmd, false, activeSnapIDPicker)
+	pickable(getActiveSnapCmd, false, activeSnapIDPicker)
+	pickable(getActiveSnapLogsCmd, false, activeSnapIDPicker)
+	pickable(pauseActiveSnapCmd, true, activeSnapIDPicker)
+	pickable(resumeActiveSnapCmd, true, activeSnapIDPicker)
 }
 
 func processActiveCommand(activeSnapID string, action string) {
-	path := "/activesnaps"
-
-	data := make(map[string]string)
-	data["action"] = action
-	data["snapId"] = activeSnapID
-	payload, err := json.Marshal(data)
-	if err != nil {
-		utils.PrintErrorMessage("could not serialize payload into JSON", err)
-		os.Exit(1)
+	response, err := postActiveCommand(activeSnapID, action)
+	if err == api.ErrDryRun {
+		return
 	}
-
-	// execute the API call
-	response, err := api.Post(path, payload)
 	if err != nil {
 		utils.PrintErrorMessage("could not retrieve data", err)
 		os.Exit(1)
@@ -288,3 +302,18 @@ func processGetLogDetailsCommand(activeSnapID string, logID string) {
 
 	print.ActiveSnapLogDetails(response, logID, format)
 }
+
+// postActiveCommand executes a pause, resume, or deactivate action on an active snap.
+// It returns errors rather than exiting, so that it can be used from the dashboard.
+func postActiveCommand(activeSnapID string, action string) ([]byte, error) {
+	data := make(map[string]string)
+	data["action"] = action
+	data["snapId"] = activeSnapID
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// execute the API call
+	return api.Request("POST", "/activesnaps", payload)
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestActiveList(t *testing.T) {
+	server, active := newTestServer(t)
+	defer server.Close()
+
+	runSnap(t, server, "", "active", "list").expect(t, 0, active.ActiveSnapID, "snaptest/gke-deploy")
+}
+
+func TestActivePause(t *testing.T) {
+	server, active := newTestServer(t)
+	defer server.Close()
+
+	runSnap(t, server, "", "active", "pause", active.ActiveSnapID, "--dry-run").expect(t, 0, `"action": "pause"`)
+	if a, _ := server.ActiveSnap(active.ActiveSnapID); a.State != "active" {
+		t.Fatalf("a dry run changed the state to %s", a.State)
+	}
+
+	runSnap(t, server, "", "active", "pause", active.ActiveSnapID).expect(t, 0)
+	if a, _ := server.ActiveSnap(active.ActiveSnapID); a.State != "paused" {
+		t.Errorf("state %s after pausing, want paused", a.State)
+	}
+}
+
+func TestActiveDeactivateConfirmation(t *testing.T) {
+	server, active := newTestServer(t)
+	defer server.Close()
+
+	// stdin isn't a terminal, so the command refuses to run without --yes
+	runSnap(t, server, "", "active", "deactivate", active.ActiveSnapID).expect(t, 1, "pass --yes to confirm")
+	if _, ok := server.ActiveSnap(active.ActiveSnapID); !ok {
+		t.Fatal("deactivated without confirmation")
+	}
+
+	runSnap(t, server, "", "active", "deactivate", active.ActiveSnapID, "--yes").expect(t, 0)
+	if _, ok := server.ActiveSnap(active.ActiveSnapID); ok {
+		t.Error("still active after deactivating with --yes")
+	}
+}
+
+func TestConfirmationNotInTerminal(t *testing.T) {
+	server, active := newTestServer(t)
+	defer server.Close()
+
+	// every destructive command refuses to run unattended without --yes
+	runCommandTests(t, server, []commandTest{
+		{name: "deactivate all", args: []string{"active", "deactivate", "--all"}, status: 1, contains: []string{"pass --yes to confirm"}},
+		{name: "delete all", args: []string{"snaps", "delete", "--all"}, status: 1, contains: []string{"pass --yes to confirm"}},
+		{name: "disconnect", args: []string{"connections", "disconnect", "github", "--force"}, status: 1, contains: []string{"pass --yes to confirm"}},
+		{name: "remove credential set", args: []string{"connections", "credential-set", "remove", "github", "ci", "--force"}, status: 1, contains: []string{"pass --yes to confirm"}},
+	})
+	if _, ok := server.ActiveSnap(active.ActiveSnapID); !ok {
+		t.Error("deactivated without confirmation")
+	}
+	if _, ok := server.Snap("snaptest/gke-deploy"); !ok {
+		t.Error("deleted without confirmation")
+	}
+	for _, path := range []string{"/activesnaps", "/snaps", "/entities/github"} {
+		if bodies := posts(server, path); len(bodies) > 0 {
+			t.Errorf("posted %v to %s", bodies, path)
+		}
+	}
+}
+
+func TestActivate(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	// the parameter value is read from the prompt
+	runSnap(t, server, "staging\n", "activate", "snaptest/gke-deploy").expect(t, 0, "snaptest/gke-deploy")
+	bodies := posts(server, "/activesnaps")
+	if len(bodies) != 1 || !strings.Contains(bodies[0], `"name":"cluster","value":"staging"`) {
+		t.Errorf("posted %v", bodies)
+	}
+}
+
+func TestActivateDryRunRedactsSecrets(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+	text := testSnapDeploy + "  - name: registry\n    description: registry login\n    type: password\n"
+	if _, err := server.AddSnap("snaptest/gke-deploy", text, true); err != nil {
+		t.Fatal(err)
+	}
+
+	r := runSnap(t, server, "prod\ns3cret\n", "activate", "snaptest/gke-deploy", "--dry-run")
+	r.expect(t, 0, `"value": "prod"`, "<redacted>")
+	if strings.Contains(r.stdout, "s3cret") {
+		t.Errorf("the dry run printed the secret value:\n%s", r.stdout)
+	}
+}
@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/snapmaster-io/snap/pkg/api"
+	"github.com/snapmaster-io/snap/pkg/print"
+	"github.com/snapmaster-io/snap/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// flags that select the items a bulk command operates on, in addition to the IDs passed as arguments
+var selectorFlags = []string{"all", "snap", "state", "trigger"}
+
+// addBulkFlags adds the flags that control how a bulk command runs
+func addBulkFlags(cmd *cobra.Command) {
+	cmd.Flags().IntP("concurrency", "", 4, "number of operations to run at the same time")
+	cmd.Flags().BoolP("fail-fast", "", false, "stop starting new operations after the first failure")
+}
+
+// addActiveSnapSelectorFlags adds the flags that select active snaps
+func addActiveSnapSelectorFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolP("all", "", false, "select all active snaps")
+	cmd.Flags().StringP("snap", "", "", "select all active snaps of this snap ID")
+	cmd.Flags().StringP("state", "", "", "select active snaps in this state (e.g. active, paused)")
+	cmd.Flags().StringP("trigger", "", "", "select active snaps triggered by this tool")
+	cmd.RegisterFlagCompletionFunc("snap", completeSnapIDs)
+	cmd.RegisterFlagCompletionFunc("trigger", completeTools)
+}
+
+// addSnapSelectorFlags adds the flags that select snaps
+func addSnapSelectorFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolP("all", "", false, "select all of the user's snaps")
+	cmd.Flags().StringP("trigger", "", "", "select snaps triggered by this tool")
+	cmd.RegisterFlagCompletionFunc("trigger", completeTools)
+}
+
+// selectorsChanged returns whether any of the selector flags were set on the command line
+func selectorsChanged(cmd *cobra.Command) bool {
+	for _, name := range selectorFlags {
+		if cmd.Flags().Lookup(name) != nil && cmd.Flags().Changed(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// idsOrSelectors validates that a bulk command was given at least one ID or a selector
+func idsOrSelectors(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 || selectorsChanged(cmd) {
+		return nil
+	}
+
+	return errors.New("requires at least one ID or a selector such as --all")
+}
+
+// selectActiveSnapIDs returns the active snap IDs in the arguments, followed by those that
+// match the selector flags
+func selectActiveSnapIDs(cmd *cobra.Command, args []string) []string {
+	ids := append([]string{}, args...)
+	if !selectorsChanged(cmd) {
+		return unique(ids)
+	}
+
+	snapID, _ := cmd.Flags().GetString("snap")
+	state, _ := cmd.Flags().GetString("state")
+	trigger, _ := cmd.Flags().GetString("trigger")
+
+	for _, a := range getActiveSnaps() {
+		if (snapID == "" || a.SnapID == snapID) &&
+			(state == "" || strings.EqualFold(a.State, state)) &&
+			(trigger == "" || strings.EqualFold(a.Provider, trigger)) {
+			ids = append(ids, a.ActiveSnapID)
+		}
+	}
+
+	return unique(ids)
+}
+
+// selectSnapIDs returns the snap IDs in the arguments, followed by those that match the selector flags
+func selectSnapIDs(cmd *cobra.Command, args []string) []string {
+	ids := append([]string{}, args...)
+	if !selectorsChanged(cmd) {
+		return unique(ids)
+	}
+
+	trigger, _ := cmd.Flags().GetString("trigger")
+
+	for _, snap := range getSnaps() {
+		if trigger == "" || strings.EqualFold(snap.Provider, trigger) {
+			ids = append(ids, snap.SnapID)
+		}
+	}
+
+	return unique(ids)
+}
+
+// runBulk runs the operation on each ID with a bounded pool of workers, and returns the
+// results in the same order as the IDs.  With --fail-fast, IDs that haven't started when
+// an operation fails are skipped.
+func runBulk(cmd *cobra.Command, ids []string, operation func(id string) (string, error)) []print.BulkResult {
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	failFast, _ := cmd.Flags().GetBool("fail-fast")
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]print.BulkResult, len(ids))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failed := false
+
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				mu.Lock()
+				skip := failFast && failed
+				mu.Unlock()
+				if skip {
+					results[i] = print.BulkResult{ID: ids[i], State: print.StepSkipped, Detail: "skipped after an earlier failure"}
+					continue
+				}
+
+				detail, err := operation(ids[i])
+				if err == api.ErrDryRun {
+					results[i] = print.BulkResult{ID: ids[i], State: print.StepDryRun, Detail: "request not sent"}
+					continue
+				}
+				if err != nil {
+					results[i] = print.BulkResult{ID: ids[i], State: print.StepFailed, Detail: err.Error()}
+					mu.Lock()
+					failed = true
+					mu.Unlock()
+					continue
+				}
+				results[i] = print.BulkResult{ID: ids[i], State: print.StepDone, Detail: detail}
+			}
+		}()
+	}
+
+	for i := range ids {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// printBulkResults prints the results in the requested format, and exits with an error
+// status if any of the operations failed or were skipped because of a failure
+func printBulkResults(title string, idHeader string, results []print.BulkResult) {
+	format, _ := rootCmd.PersistentFlags().GetString("format")
+	if format == "json" {
+		payload, _ := json.Marshal(results)
+		print.JSON(payload)
+	} else {
+		print.BulkResultsTable(title, idHeader, results)
+	}
+
+	for _, result := range results {
+		if result.State == print.StepFailed || result.State == print.StepSkipped {
+			os.Exit(1)
+		}
+	}
+}
+
+// processBulkActiveCommand executes a pause, resume, or deactivate action on the selected
+// active snaps.  A single active snap passed as an argument is processed as before.
+func processBulkActiveCommand(cmd *cobra.Command, args []string, action string) {
+	ids := selectActiveSnapIDs(cmd, args)
+	if len(ids) == 0 {
+		utils.PrintMessage("no active snaps match the selectors")
+		return
+	}
+	if action == "deactivate" {
+		confirmDeactivation(ids)
+	}
+	if len(ids) == 1 && !selectorsChanged(cmd) {
+		processActiveCommand(ids[0], action)
+		return
+	}
+
+	results := runBulk(cmd, ids, func(activeSnapID string) (string, error) {
+		response, err := postActiveCommand(activeSnapID, action)
+		if err == nil {
+			err = api.CheckStatus(response)
+		}
+		if err != nil {
+			return "", err
+		}
+
+		var activeSnapResponse print.ActiveSnapResponse
+		json.Unmarshal(response, &activeSnapResponse)
+		if activeSnapResponse.Data.State != "" {
+			return fmt.Sprintf("state: %s", activeSnapResponse.Data.State), nil
+		}
+		return activeSnapResponse.Message, nil
+	})
+
+	printBulkResults(fmt.Sprintf("%s %s", strings.Title(action), plural(len(ids), "active snap")), "Active Snap ID", results)
+}
+
+// processBulkSnapCommand posts the command built by newData for each of the selected snaps,
+// after calling confirm (if it isn't nil) with the selected snap IDs.  A single snap passed
+// as an argument is processed as before.
+func processBulkSnapCommand(cmd *cobra.Command, args []string, title string, confirm func(snapIDs []string), newData func(snapID string) map[string]interface{}) {
+	ids := selectSnapIDs(cmd, args)
+	if len(ids) == 0 {
+		utils.PrintMessage("no snaps match the selectors")
+		return
+	}
+	if confirm != nil {
+		confirm(ids)
+	}
+	if len(ids) == 1 && !selectorsChanged(cmd) {
+		processSnapCommand(newData(ids[0]))
+		return
+	}
+
+	results := runBulk(cmd, ids, func(snapID string) (string, error) {
+		response, err := postSnapCommand(newData(snapID))
+		if err == nil {
+			err = api.CheckStatus(response)
+		}
+		if err != nil {
+			return "", err
+		}
+
+		var status struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(response, &status)
+		return status.Message, nil
+	})
+
+	printBulkResults(fmt.Sprintf("%s %s", title, plural(len(ids), "snap")), "Snap ID", results)
+}
+
+// unique returns the values without duplicates, keeping the first occurrence of each
+func unique(values []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+
+	return result
+}
+
+// plural returns the count followed by the noun, adding an "s" unless the count is one
+func plural(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, noun)
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
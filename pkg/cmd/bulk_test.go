@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestBulkActive(t *testing.T) {
+	tests := []struct {
+		commandTest
+		paused int
+	}{
+		{
+			commandTest: commandTest{name: "all", args: []string{"active", "pause", "--all"}, contains: []string{"Pause 2 active snaps", "state: paused"}},
+			paused:      2,
+		},
+		{
+			commandTest: commandTest{name: "by snap", args: []string{"active", "pause", "--snap", "snaptest/gke-deploy"}, contains: []string{"Pause 2 active snaps"}},
+			paused:      2,
+		},
+		{
+			commandTest: commandTest{name: "by state", args: []string{"active", "pause", "--state", "paused"}, contains: []string{"no active snaps match the selectors"}},
+		},
+		{
+			commandTest: commandTest{name: "by trigger", args: []string{"active", "pause", "--trigger", "slack"}, contains: []string{"no active snaps match the selectors"}},
+		},
+		{
+			commandTest: commandTest{name: "json", args: []string{"active", "pause", "--all", "--format", "json"}, contains: []string{`"state"`}},
+			paused:      2,
+		},
+		{
+			commandTest: commandTest{name: "nothing selected", args: []string{"active", "pause"}, status: 1, contains: []string{"requires at least one ID or a selector such as --all"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, active := newTestServer(t)
+			defer server.Close()
+			other, err := server.AddActiveSnap("snaptest/gke-deploy", []map[string]string{{"name": "cluster", "value": "dev"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			runSnapEnv(t, server, test.env, test.stdin, test.args...).expect(t, test.status, test.contains...)
+			paused := 0
+			for _, activeSnapID := range []string{active.ActiveSnapID, other.ActiveSnapID} {
+				if a, _ := server.ActiveSnap(activeSnapID); a.State == "paused" {
+					paused++
+				}
+			}
+			if paused != test.paused {
+				t.Errorf("paused %d active snaps, want %d", paused, test.paused)
+			}
+		})
+	}
+}
+
+func TestBulkFailure(t *testing.T) {
+	server, active := newTestServer(t)
+	defer server.Close()
+
+	// a missing active snap fails without stopping the others, and the command exits 1
+	runSnap(t, server, "", "active", "pause", active.ActiveSnapID, "missing", "--concurrency", "1").expect(t, 1, "Pause 2 active snaps")
+	if a, _ := server.ActiveSnap(active.ActiveSnapID); a.State != "paused" {
+		t.Errorf("state %s after pausing, want paused", a.State)
+	}
+}
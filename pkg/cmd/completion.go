@@ -0,0 +1,290 @@
+package cmd
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/snapmaster-io/snap/pkg/api"
+	"github.com/snapmaster-io/snap/pkg/config"
+	"github.com/snapmaster-io/snap/pkg/picker"
+	"github.com/snapmaster-io/snap/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// how long API responses used for completion are cached on disk
+const completionCacheTTL = 30 * time.Second
+
+// completionCmd represents the completion command
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "Generate a shell completion script",
+	Long: `Generate a shell completion script.
+
+Completion covers commands and flags, as well as snap IDs, active snap IDs, log IDs,
+tool names, and credential-set names, which are retrieved from the API and cached
+for a short time under $HOME/.config/snap/cache.
+
+To load completions:
+
+Bash:
+  $ source <(snap completion bash)
+
+Zsh:
+  $ snap completion zsh > "${fpath[1]}/_snap"
+
+Fish:
+  $ snap completion fish > ~/.config/fish/completions/snap.fish
+
+PowerShell:
+  PS> snap completion powershell | Out-String | Invoke-Expression`,
+	ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
+	Args:      cobra.ExactValidArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		switch args[0] {
+		case "bash":
+			err = rootCmd.GenBashCompletion(os.Stdout)
+		case "zsh":
+			_, err = fmt.Fprint(os.Stdout, zshCompletion)
+		case "fish":
+			err = rootCmd.GenFishCompletion(os.Stdout, true)
+		case "powershell":
+			_, err = fmt.Fprint(os.Stdout, powerShellCompletion)
+		}
+
+		if err != nil {
+			utils.PrintErrorMessage(fmt.Sprintf("could not generate %s completion script", args[0]), err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
+
+// completeSnapIDs completes the first argument with the user's snap IDs
+func completeSnapIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeFromAPI("/snaps", "data", "snapId", "description", toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeGallerySnapIDs completes the first argument with the snap IDs in the gallery
+func completeGallerySnapIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeFromAPI("/gallery", "data", "snapId", "description", toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeActiveSnapIDs completes the first argument with the user's active snap IDs
+func completeActiveSnapIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeFromAPI("/activesnaps", "data", "activeSnapId", "snapId", toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeActiveSnapLogs completes "logs [active snap ID] details [log ID]"
+func completeActiveSnapLogs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return completeActiveSnapIDs(cmd, args, toComplete)
+	case 1:
+		return []string{"details"}, cobra.ShellCompDirectiveNoFileComp
+	case 2:
+		path := fmt.Sprintf("/logs/%s", args[0])
+		return completeFromAPI(path, "data", "timestamp", "state", toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeLogIDs completes the first argument with the log IDs of all of the user's active snaps
+func completeLogIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeFromAPI("/logs", "data", "timestamp", "snapId", toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeTools completes the first argument with the tools in the tools library
+func completeTools(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeFromAPI("/connections", "data", "provider", "type", toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeConnectedTools completes the first argument with the tools the user has connected
+func completeConnectedTools(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeFromAPI("/connections", `data.#(connected%"?*")#`, "provider", "type", toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeCredentialSets completes "[tool name] [credential-set name]"
+func completeCredentialSets(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return completeConnectedTools(cmd, args, toComplete)
+	case 1:
+		path := fmt.Sprintf("/entities/%s", args[0])
+		return completeFromAPI(path, "data", "__id", "", toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeToolOperations completes "[tool] [action or trigger]" with the tool's actions or triggers
+func completeToolOperations(operations string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		switch len(args) {
+		case 0:
+			return completeTools(cmd, args, toComplete)
+		case 1:
+			path := fmt.Sprintf("data.#(provider==%s).definition.%s", args[0], operations)
+			return completeFromAPI("/connections", path, "name", "description", toComplete), cobra.ShellCompDirectiveNoFileComp
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeFromAPI retrieves the candidates of an API list (from the cache if they're fresh
+// enough): the values of the field in each element of the array at listPath, along with the
+// descField value as a description.  It returns those that start with toComplete.
+func completeFromAPI(path string, listPath string, field string, descField string, toComplete string) []string {
+	var completions []string
+	for _, item := range getCompletionItems(path, listPath, field, descField) {
+		if !strings.HasPrefix(item.Value, toComplete) {
+			continue
+		}
+		if item.Description != "" {
+			completions = append(completions, fmt.Sprintf("%s\t%s", item.Value, item.Description))
+		} else {
+			completions = append(completions, item.Value)
+		}
+	}
+
+	return completions
+}
+
+// getCompletionItems returns the candidates of an API list, using the on-disk cache for
+// candidates retrieved in the last completionCacheTTL.  Only the candidates are cached, not
+// the response, which can hold credential values (as /entities responses do).  It returns
+// nil rather than exiting on errors, such as when the user isn't logged in, so that
+// completion never prints errors.
+func getCompletionItems(path string, listPath string, field string, descField string) []picker.Item {
+	if viper.GetString("AccessToken") == "" {
+		return nil
+	}
+
+	// key the cache on the API URL as well as the list, so that environments don't mix
+	list := strings.Join([]string{viper.GetString("APIURL"), path, listPath, field, descField}, "\x00")
+	key := fmt.Sprintf("completion-%x.json", sha1.Sum([]byte(list)))
+	var items []picker.Item
+	if cached, ok := config.ReadCacheFile(key, completionCacheTTL); ok && json.Unmarshal(cached, &items) == nil {
+		return items
+	}
+
+	response, err := api.Request("GET", path, nil)
+	if err != nil || api.CheckStatus(response) != nil {
+		return nil
+	}
+	items = itemsFromResponse(response, listPath, field, descField)
+	if cached, err := json.Marshal(items); err == nil {
+		config.WriteCacheFile(key, cached)
+	}
+
+	return items
+}
+
+// zsh completion script, which asks the hidden __complete command for completions
+var zshCompletion = `#compdef snap
+
+# zsh completion for snap
+
+_snap() {
+    local out directive line comp desc
+    local -a lines completions
+
+    # ask snap for the completions of the words up to (and including) the current word
+    out=$(${words[1]} __complete "${(@)words[2,CURRENT]}" 2>/dev/null)
+    lines=("${(@f)out}")
+    directive=${lines[-1]#:}
+    lines=("${(@)lines[1,-2]}")
+
+    # directive 1: error
+    if (( directive & 1 )); then
+        return 1
+    fi
+
+    for line in "${lines[@]}"; do
+        [[ -z $line ]] && continue
+        comp=${line%%$'\t'*}
+        desc=""
+        [[ $line == *$'\t'* ]] && desc=${line#*$'\t'}
+        completions+=("${comp//:/\\:}${desc:+:$desc}")
+    done
+
+    if (( ${#completions} > 0 )); then
+        # directive 2: no space after the completion
+        if (( directive & 2 )); then
+            _describe 'completions' completions -S ''
+        else
+            _describe 'completions' completions
+        fi
+    elif (( ! (directive & 4) )); then
+        # directive 4: no file completion
+        _files
+    fi
+}
+
+if [ "$funcstack[1]" = "_snap" ]; then
+    _snap "$@"
+else
+    compdef _snap snap
+fi
+`
+
+// PowerShell completion script, which asks the hidden __complete command for completions
+var powerShellCompletion = `# powershell completion for snap
+
+Register-ArgumentCompleter -Native -CommandName 'snap' -ScriptBlock {
+    param($WordToComplete, $CommandAst, $CursorPosition)
+
+    # ask snap for the completions of the words up to (and including) the current word
+    $Arguments = @($CommandAst.CommandElements |
+        Where-Object { $_.Extent.EndOffset -le $CursorPosition } |
+        Select-Object -Skip 1 |
+        ForEach-Object { $_.Extent.Text })
+    if ($WordToComplete -eq '') {
+        $Arguments += '""'
+    }
+
+    $Output = @(& snap __complete @Arguments 2>$null)
+    if ($Output.Count -eq 0) {
+        return
+    }
+
+    # directive 1: error
+    $Directive = [int]($Output[-1].TrimStart(':'))
+    if ($Directive -band 1) {
+        return
+    }
+
+    $Output | Select-Object -SkipLast 1 | ForEach-Object {
+        $Name, $Description = $_ -split "` + "`" + `t", 2
+        if (-not $Description) {
+            $Description = $Name
+        }
+        [System.Management.Automation.CompletionResult]::new($Name, $Name, 'ParameterValue', $Description)
+    }
+}
+`
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompleteCredentialSets(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	home, err := ioutil.TempDir("", "snap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	env := []string{"HOME=" + home}
+
+	runSnapEnv(t, server, env, "ci\nci-user\ns3cret-password\n", "connections", "credential-set", "add", "docker").expect(t, 0)
+
+	r := runSnapEnv(t, server, env, "", "__complete", "connections", "credential-set", "update", "docker", "")
+	r.expect(t, 0)
+	if lines := strings.Split(r.stdout, "\n"); lines[0] != "ci" {
+		t.Errorf("completed %q, want ci", r.stdout)
+	}
+
+	// the cache holds the names of the credential sets, but not their values
+	files, _ := filepath.Glob(filepath.Join(home, ".config", "snap", "cache", "completion-*"))
+	if len(files) == 0 {
+		t.Fatal("nothing was cached")
+	}
+	for _, file := range files {
+		contents, _ := ioutil.ReadFile(file)
+		if strings.Contains(string(contents), "s3cret-password") {
+			t.Errorf("%s holds a credential value: %s", file, contents)
+		}
+	}
+}
+
+func TestCompleteWithoutServer(t *testing.T) {
+	server, _ := newTestServer(t)
+	server.Close()
+
+	// completion prints no error, and no candidates, when the API can't be reached
+	r := runSnap(t, server, "", "__complete", "snaps", "get", "")
+	r.expect(t, 0)
+	if lines := strings.Split(strings.TrimSpace(r.stdout), "\n"); len(lines) != 1 || !strings.HasPrefix(lines[0], ":") {
+		t.Errorf("completed %q, want only the directive", r.stdout)
+	}
+}
+
+func TestCompletionScripts(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	runCommandTests(t, server, []commandTest{
+		{name: "bash", args: []string{"completion", "bash"}, contains: []string{"# bash completion"}},
+		{name: "zsh", args: []string{"completion", "zsh"}, contains: []string{"#compdef"}},
+		{name: "fish", args: []string{"completion", "fish"}, contains: []string{"complete -c"}},
+		{name: "powershell", args: []string{"completion", "powershell"}, contains: []string{"Register-ArgumentCompleter"}},
+		{name: "unknown shell", args: []string{"completion", "tcsh"}, status: 1, contains: []string{"invalid argument \"tcsh\""}},
+		{name: "no shell", args: []string{"completion"}, status: 1, contains: []string{"accepts 1 arg(s)"}},
+	})
+}
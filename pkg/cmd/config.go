@@ -104,8 +104,10 @@ func init() {
 	configSetCmd.Flags().StringP("api-url", "", "", "API URL (defaults to https://dev.snapmaster.io)")
 	configSetCmd.Flags().StringP("client-id", "", "", "Auth0 Client ID (required for any non-default API URL)")
 	configSetCmd.Flags().StringP("auth-domain", "", "", "Auth0 Auth Domain (defaults to snapmaster-dev.auth0.com)")
+	configSetCmd.Flags().StringP("time-zone", "", "", "IANA time zone for timestamps, e.g. America/New_York (defaults to the local time zone)")
 
 	viper.BindPFlag("APIURL", configSetCmd.Flags().Lookup("api-url"))
 	viper.BindPFlag("ClientID", configSetCmd.Flags().Lookup("client-id"))
 	viper.BindPFlag("AuthDomain", configSetCmd.Flags().Lookup("auth-domain"))
+	viper.BindPFlag("TimeZone", configSetCmd.Flags().Lookup("time-zone"))
 }
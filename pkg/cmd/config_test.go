@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestTimeZone(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	// the fake server activated the snap at 2020-09-13T12:26:41Z
+	runCommandTests(t, server, []commandTest{
+		{
+			name:     "config setting",
+			env:      []string{"SNAP_TIMEZONE=Asia/Tokyo"},
+			args:     []string{"active", "list", "--time-format", "rfc3339"},
+			contains: []string{"2020-09-13T21:26:41+09:00"},
+		},
+		{
+			name:     "utc",
+			env:      []string{"SNAP_TIMEZONE=UTC"},
+			args:     []string{"active", "list", "--time-format", "rfc3339"},
+			contains: []string{"2020-09-13T12:26:41Z"},
+		},
+		{
+			name:     "enriched json",
+			env:      []string{"SNAP_TIMEZONE=America/New_York"},
+			args:     []string{"active", "list", "--format", "json", "--enrich"},
+			contains: []string{`"activatedISO": "2020-09-13T08:26:41.000-04:00"`},
+		},
+		{
+			name:     "unknown zone",
+			env:      []string{"SNAP_TIMEZONE=Mars/Olympus"},
+			args:     []string{"active", "list"},
+			status:   1,
+			contains: []string{"unknown time zone 'Mars/Olympus'"},
+		},
+		{
+			name:     "config get",
+			env:      []string{"SNAP_TIMEZONE=Asia/Tokyo"},
+			args:     []string{"config", "get"},
+			contains: []string{"Asia/Tokyo"},
+		},
+	})
+}
+
+func TestConfig(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	runCommandTests(t, server, []commandTest{
+		{name: "config", args: []string{"config"}, contains: []string{"Config Values", "API URL", server.URL, "Auth Domain"}},
+		{name: "get", args: []string{"config", "get"}, contains: []string{"Config Values", server.URL, "Time Zone"}},
+		{
+			name:     "set without a config file",
+			args:     []string{"config", "set", "--auth-domain", "example.auth0.com"},
+			status:   1,
+			contains: []string{"could not write config file"},
+		},
+	})
+}
+
+func TestConfigSet(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		args   []string
+		output []string
+		saved  []string
+	}{
+		{
+			name:   "flags",
+			args:   []string{"config", "set", "--auth-domain", "example.auth0.com", "--client-id", "abc123"},
+			output: []string{"updated config file", "example.auth0.com", "abc123"},
+			saved:  []string{`"authdomain": "example.auth0.com"`, `"clientid": "abc123"`},
+		},
+		{
+			name:   "time zone",
+			args:   []string{"config", "set", "--time-zone", "Asia/Tokyo"},
+			output: []string{"updated config file", "Asia/Tokyo"},
+			saved:  []string{`"timezone": "Asia/Tokyo"`},
+		},
+		{
+			name:   "dev",
+			args:   []string{"config", "set", "dev"},
+			output: []string{"updated config file", "https://dev.snapmaster.io", "snapmaster-dev.auth0.com"},
+			saved:  []string{`"apiurl": "https://dev.snapmaster.io"`, `"authdomain": "snapmaster-dev.auth0.com"`},
+		},
+		{
+			name:   "prod",
+			args:   []string{"config", "set", "prod"},
+			output: []string{"updated config file", "https://www.snapmaster.io", "snapmaster.auth0.com"},
+			saved:  []string{`"apiurl": "https://www.snapmaster.io"`, `"authdomain": "snapmaster.auth0.com"`},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := writeTestFile(t, "config.json", "{}")
+			runSnap(t, server, "", append(test.args, "--config", config)...).expect(t, 0, test.output...)
+
+			contents, _ := ioutil.ReadFile(config)
+			for _, s := range test.saved {
+				if !strings.Contains(string(contents), s) {
+					t.Errorf("config file doesn't contain %s:\n%s", s, contents)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigSetTimeZone(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	// the flag is saved in the config file, and applies to later commands
+	config := writeTestFile(t, "config.json", "{}")
+	runSnap(t, server, "", "config", "set", "--time-zone", "Asia/Tokyo", "--config", config).expect(t, 0, "updated config file", "Asia/Tokyo")
+	runSnap(t, server, "", "active", "list", "--time-format", "rfc3339", "--config", config).expect(t, 0, "2020-09-13T21:26:41+09:00")
+}
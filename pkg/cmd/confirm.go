@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/snapmaster-io/snap/pkg/api"
+	"github.com/snapmaster-io/snap/pkg/print"
+	"github.com/snapmaster-io/snap/pkg/utils"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// confirmAction asks the user to confirm an operation, exiting if they don't.
+// describe prints what the operation will affect, and returns the question to ask.
+//
+// --yes skips both the description and the question.  --dry-run shows the description
+// without asking, since no request will be sent.  When stdin isn't a terminal, the question
+// can't be asked, so the operation is refused unless --yes is passed.
+func confirmAction(describe func() string) {
+	if !confirmed(describe) {
+		utils.PrintMessage("cancelled")
+		os.Exit(1)
+	}
+}
+
+// confirmed is like confirmAction, but returns whether the user confirmed the operation
+// instead of exiting if they didn't, so that the caller can clean up
+func confirmed(describe func() string) bool {
+	yes, _ := rootCmd.PersistentFlags().GetBool("yes")
+	dryRun, _ := rootCmd.PersistentFlags().GetBool("dry-run")
+	if yes && !dryRun {
+		return true
+	}
+
+	if !dryRun && !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		utils.PrintError("not running in a terminal, so confirmation can't be asked; pass --yes to confirm")
+		return false
+	}
+
+	question := describe()
+	if dryRun {
+		return true
+	}
+
+	return promptYesNo(question)
+}
+
+// confirmDeactivation shows the active snaps that will be deactivated along with the
+// number of logs that will be deleted, and asks for confirmation
+func confirmDeactivation(activeSnapIDs []string) {
+	confirmAction(func() string {
+		activeSnaps := findActiveSnaps(getActiveSnaps(), activeSnapIDs)
+		counts := getLogCounts()
+		logs := 0
+		for _, a := range activeSnaps {
+			logs += counts[a.ActiveSnapID]
+		}
+
+		print.AffectedActiveSnapsTable("Active snaps to deactivate", activeSnaps, counts)
+		return fmt.Sprintf("Deactivate %s and DELETE %s?", plural(len(activeSnapIDs), "active snap"), plural(logs, "log"))
+	})
+}
+
+// confirmSnapDeletion shows the snaps that will be deleted along with their active
+// snaps, and asks for confirmation
+func confirmSnapDeletion(snapIDs []string) {
+	confirmAction(func() string {
+		snaps := make(map[string]print.Snap)
+		for _, snap := range getSnaps() {
+			snaps[snap.SnapID] = snap
+		}
+
+		selected := make([]print.Snap, len(snapIDs))
+		for i, snapID := range snapIDs {
+			selected[i] = snaps[snapID]
+			selected[i].SnapID = snapID
+		}
+
+		activeCounts := make(map[string]int)
+		var activeSnaps []print.ActiveSnap
+		for _, a := range getActiveSnaps() {
+			if containsFold(snapIDs, a.SnapID) {
+				activeCounts[a.SnapID]++
+				activeSnaps = append(activeSnaps, a)
+			}
+		}
+
+		print.AffectedSnapsTable("Snaps to delete", selected, activeCounts)
+		if len(activeSnaps) > 0 {
+			fmt.Println()
+			print.AffectedActiveSnapsTable("Active snaps of these snaps", activeSnaps, nil)
+		}
+		return fmt.Sprintf("Delete %s?", plural(len(snapIDs), "snap"))
+	})
+}
+
+// confirmDisconnection shows the credential sets that will be removed along with the
+// active snaps that use the tool, and asks for confirmation
+func confirmDisconnection(tool string, usage []print.ToolUsage) {
+	confirmAction(func() string {
+		response, err := api.Get(fmt.Sprintf("/entities/%s", tool))
+		if err != nil {
+			utils.PrintErrorMessage("could not retrieve data", err)
+			os.Exit(1)
+		}
+		print.CredentialsTable(response, tool)
+
+		if len(usage) > 0 {
+			fmt.Println()
+			print.ActiveSnapUsageTable(fmt.Sprintf("Active snaps that use %s", tool), usage)
+		}
+		return fmt.Sprintf("Disconnect %s and remove all of its credential sets%s?", tool, usedBy(usage))
+	})
+}
+
+// confirmCredentialSetRemoval shows the active snaps that may use the credential set, and
+// asks for confirmation
+func confirmCredentialSetRemoval(tool string, credentialSet string, usage []print.ToolUsage) {
+	confirmAction(func() string {
+		if len(usage) > 0 {
+			print.ActiveSnapUsageTable(fmt.Sprintf("Active snaps that use %s", tool), usage)
+		} else {
+			utils.PrintMessage(fmt.Sprintf("no active snaps use credential set %s of %s", credentialSet, tool))
+		}
+		return fmt.Sprintf("Remove credential set %s from %s%s?", credentialSet, tool, usedBy(usage))
+	})
+}
+
+// findActiveSnaps returns the active snaps with the IDs, in the same order; IDs that
+// aren't found are returned with a "not found" state
+func findActiveSnaps(activeSnaps []print.ActiveSnap, activeSnapIDs []string) []print.ActiveSnap {
+	byID := make(map[string]print.ActiveSnap)
+	for _, a := range activeSnaps {
+		byID[a.ActiveSnapID] = a
+	}
+
+	found := make([]print.ActiveSnap, len(activeSnapIDs))
+	for i, id := range activeSnapIDs {
+		a, ok := byID[id]
+		if !ok {
+			a = print.ActiveSnap{ActiveSnapID: id, State: "not found"}
+		}
+		found[i] = a
+	}
+
+	return found
+}
+
+// usedBy describes the active snaps that use a tool, for a confirmation question
+func usedBy(usage []print.ToolUsage) string {
+	ids := activeSnapIDs(usage)
+	if len(ids) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" (used by %s: %s)", plural(len(ids), "active snap"), strings.Join(ids, ", "))
+}
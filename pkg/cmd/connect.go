@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/snapmaster-io/snap/pkg/api"
+	"github.com/snapmaster-io/snap/pkg/importer"
 	"github.com/snapmaster-io/snap/pkg/print"
 	"github.com/snapmaster-io/snap/pkg/utils"
 	"github.com/spf13/cobra"
@@ -21,8 +23,18 @@ var connectCmd = &cobra.Command{
 	If only the tool name is passed in, the command will prompt for credential information.
 	
 	If a credential-set name and credential file name are provided, the command will create 
-	a default connection as well as a named credential-set with those parameters.`,
-	Args: cobra.MinimumNArgs(1),
+	a default connection as well as a named credential-set with those parameters.
+	
+	With --import, the credentials are read from a local source instead, such as a kubeconfig
+	context or an AWS profile (see --source), and mapped onto the tool's parameters; the
+	credential-set name defaults to the context or profile name.  A preview of the values,
+	with secrets redacted, is shown before they are saved.
+	
+	With --verify, the credentials are tested (see "snap connections test") before they are
+	saved, and aren't saved unless the test passes.  If they can't be verified, because the
+	server can't test connections, --force saves them anyway.`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeTools,
 	Run: func(cmd *cobra.Command, args []string) {
 		tool := args[0]
 
@@ -32,8 +44,14 @@ var connectCmd = &cobra.Command{
 
 		utils.PrintMessage(fmt.Sprintf("connecting %s", tool))
 
-		// if no credentials file supplied, prompt for parameters
-		if len(args) == 1 {
+		// import the credentials from a local source, or if no credentials file supplied, prompt for parameters
+		if imported, _ := cmd.Flags().GetBool("import"); imported {
+			if len(args) > 2 {
+				cmd.Help()
+				os.Exit(1)
+			}
+			importCredentials(cmd, tool, credentials, args[1:])
+		} else if len(args) == 1 {
 			// input the parameters and store their values in the credentials slice of maps
 			inputParameters(credentials)
 		} else {
@@ -48,30 +66,33 @@ var connectCmd = &cobra.Command{
 			readParametersFromFile(credentials, credentialName, credentialsFile)
 		}
 
-		// make the POST call to the API
+		// test the credentials if requested, and make the POST call to the API
+		verifyConnection(cmd, tool, credentials)
 		processConnectCommand(tool, "/connections", credentials)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(connectCmd)
+	connectCmd.Flags().BoolP("verify", "", false, "test the credentials before saving them")
+	connectCmd.Flags().BoolP("force", "", false, "with --verify, save credentials that can't be verified")
+	connectCmd.Flags().BoolP("import", "", false, "import the credentials from a local source")
+	connectCmd.Flags().StringP("source", "", "", fmt.Sprintf("local source to import from (%s); defaults to the first one that has credentials for the tool", strings.Join(importer.Names(), ", ")))
+	connectCmd.Flags().StringP("kube-context", "", "", "kubeconfig context to import (defaults to the current context)")
+	connectCmd.Flags().StringP("aws-profile", "", "", "AWS profile to import (defaults to $AWS_PROFILE or default)")
+	connectCmd.Flags().StringP("netrc-machine", "", "", "netrc machine to import (defaults to the tool's host)")
+	connectCmd.RegisterFlagCompletionFunc("source", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return importer.Names(), cobra.ShellCompDirectiveNoFileComp
+	})
+
+	pickable(connectCmd, false, toolPicker)
 }
 
 func processConnectCommand(tool string, path string, params []map[string]string) {
-	// set up the data map
-	data := make(map[string]interface{})
-	data["action"] = "add"
-	data["provider"] = tool
-	data["connectionInfo"] = params
-
-	payload, err := json.Marshal(data)
-	if err != nil {
-		utils.PrintErrorMessage("could not serialize payload into JSON", err)
-		os.Exit(1)
+	response, err := postConnection(tool, path, params)
+	if err == api.ErrDryRun {
+		return
 	}
-
-	// execute the API call
-	response, err := api.Post(path, payload)
 	if err != nil {
 		utils.PrintErrorMessage("could not retrieve data", err)
 		os.Exit(1)
@@ -93,3 +114,100 @@ func processConnectCommand(tool string, path string, params []map[string]string)
 
 	print.Status(response)
 }
+
+// postConnection posts the connection info for a tool to the API and returns the response
+func postConnection(tool string, path string, params []map[string]string) ([]byte, error) {
+	// resolve secret references in the credential values
+	params, err := resolveSecrets(params)
+	if err != nil {
+		return nil, err
+	}
+
+	// set up the data map
+	data := make(map[string]interface{})
+	data["action"] = "add"
+	data["provider"] = tool
+	data["connectionInfo"] = params
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// execute the API call
+	return api.Post(path, payload)
+}
+
+// importCredentials reads the credential values from a local source into the tool's
+// parameters, prompting for any that the source doesn't have, and shows a preview of the
+// values (with secrets redacted) for confirmation.  nameArgs optionally holds the name
+// of the credential set.
+func importCredentials(cmd *cobra.Command, tool string, credentials []map[string]string, nameArgs []string) {
+	source, _ := cmd.Flags().GetString("source")
+	var options importer.Options
+	options.KubeContext, _ = cmd.Flags().GetString("kube-context")
+	options.AWSProfile, _ = cmd.Flags().GetString("aws-profile")
+	options.NetrcMachine, _ = cmd.Flags().GetString("netrc-machine")
+
+	imported, err := importer.Import(tool, source, options)
+	if err == importer.ErrNotFound {
+		err = fmt.Errorf("%s has no credentials for %s", source, tool)
+	}
+	if err != nil {
+		utils.PrintErrorMessage("could not import credentials", err)
+		os.Exit(1)
+	}
+
+	name := imported.Name
+	if len(nameArgs) > 0 {
+		name = nameArgs[0]
+	}
+	missing := importer.Map(imported, credentials, name)
+	if len(missing) == len(credentials)-countNameParameters(credentials) {
+		utils.PrintError(fmt.Sprintf("%s has none of the parameters of %s", imported.Source, tool))
+		os.Exit(1)
+	}
+	prompted := make(map[string]bool)
+	if len(missing) > 0 {
+		utils.PrintMessage(fmt.Sprintf("%s has no value for some of the parameters of %s", imported.Source, tool))
+		inputParameters(missing)
+		for _, param := range missing {
+			prompted[param["name"]] = true
+		}
+	}
+
+	checkCredentialValues(credentials)
+
+	// preview the values, redacting secrets
+	preview := make([]print.CredentialValue, len(credentials))
+	for i, param := range credentials {
+		value := param["value"]
+		if importer.IsSecret(imported, param) {
+			value = importer.Redact(value)
+		}
+		from := imported.Source
+		if prompted[param["name"]] {
+			from = "prompt"
+		} else if param["type"] == "name" {
+			from = "credential-set name"
+		}
+		preview[i] = print.CredentialValue{Name: param["name"], Value: value, Source: from}
+	}
+	print.CredentialPreviewTable(fmt.Sprintf("Credentials to import for %s", tool), preview)
+
+	confirmAction(func() string {
+		return fmt.Sprintf("Save these credentials for %s?", tool)
+	})
+}
+
+// countNameParameters returns the number of parameters that hold the credential set name
+func countNameParameters(params []map[string]string) int {
+	count := 0
+	for _, param := range params {
+		if param["type"] == "name" {
+			count++
+		}
+	}
+
+	return count
+}
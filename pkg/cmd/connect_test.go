@@ -0,0 +1,93 @@
+package cmd
+
+import "testing"
+
+func TestConnect(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	credentialsFile := writeTestFile(t, "credentials", "from-file")
+	netrc := writeTestFile(t, "netrc", "machine index.docker.io login netrc-user password netrc-password\n")
+
+	tests := []struct {
+		commandTest
+		// the credential set the command stores, with its name in __id
+		stored map[string]string
+	}{
+		{
+			commandTest: commandTest{
+				name:     "prompt",
+				stdin:    "prompted\nprompt-user\nprompt-password\n",
+				args:     []string{"connect", "docker"},
+				contains: []string{"connecting docker", "connected docker and stored credentials"},
+			},
+			stored: map[string]string{"__id": "prompted", "username": "prompt-user", "password": "prompt-password"},
+		},
+		{
+			commandTest: commandTest{
+				name:     "file",
+				args:     []string{"connect", "docker", "from-file", credentialsFile},
+				contains: []string{"connected docker and stored credentials"},
+			},
+			stored: map[string]string{"__id": "from-file", "username": "from-file", "password": "from-file"},
+		},
+		{
+			commandTest: commandTest{
+				name:     "import",
+				env:      []string{"NETRC=" + netrc},
+				args:     []string{"connect", "docker", "imported", "--import", "--source", "netrc", "--yes"},
+				contains: []string{"Credentials to import for docker", "netrc-user", "******** (14 characters)", "connected docker and stored credentials"},
+			},
+			stored: map[string]string{"__id": "imported", "username": "netrc-user", "password": "netrc-password"},
+		},
+		{
+			commandTest: commandTest{
+				name:     "import without credentials",
+				env:      []string{"NETRC=" + netrc},
+				args:     []string{"connect", "docker", "--import", "--source", "netrc", "--netrc-machine", "example.com"},
+				status:   1,
+				contains: []string{"could not import credentials", "has no machine example.com"},
+			},
+		},
+		{
+			commandTest: commandTest{
+				name:     "missing file",
+				args:     []string{"connect", "docker", "missing", "missing.txt"},
+				status:   1,
+				contains: []string{"could not read credentials file missing.txt"},
+			},
+		},
+		{
+			commandTest: commandTest{
+				name:     "dry run",
+				stdin:    "dry\ndry-user\ndry-password\n",
+				args:     []string{"connect", "docker", "--dry-run"},
+				contains: []string{"dry run: POST", "/connections"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			before := len(posts(server, "/connections"))
+			runSnapEnv(t, server, test.env, test.stdin, test.args...).expect(t, test.status, test.contains...)
+
+			if test.stored == nil {
+				if after := len(posts(server, "/connections")); after != before {
+					t.Errorf("posted %d connections, want none", after-before)
+				}
+				return
+			}
+			var stored map[string]string
+			for _, set := range server.Credentials("docker") {
+				if set["__id"] == test.stored["__id"] {
+					stored = set
+				}
+			}
+			for name, value := range test.stored {
+				if stored[name] != value {
+					t.Errorf("stored %s = %q, want %q", name, stored[name], value)
+				}
+			}
+		})
+	}
+}
@@ -28,11 +28,18 @@ var connectionsCmd = &cobra.Command{
 var disconnectToolCmd = &cobra.Command{
 	Use:   "disconnect [tool name]",
 	Short: "Disconnect a tool and remove all credential sets associated with it",
-	Long:  `Disconnect a tool and remove all credential sets associated with it.`,
-	Args:  cobra.MinimumNArgs(1),
+	Long: `Disconnect a tool and remove all credential sets associated with it.
+
+If any active snaps use the tool (see "snap connections usage"), or whether they do can't
+be determined, the tool isn't disconnected unless --force is passed.`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeConnectedTools,
 	Run: func(cmd *cobra.Command, args []string) {
 		// retrieve tool as the first argument
 		tool := args[0]
+		usage := refuseIfUsed(cmd, fmt.Sprintf("disconnect %s", tool), tool, "")
+		confirmDisconnection(tool, usage)
+
 		data := make(map[string]interface{})
 		data["action"] = "remove"
 		data["provider"] = tool
@@ -42,10 +49,11 @@ var disconnectToolCmd = &cobra.Command{
 
 // getConnectionCmd represents the get connection subcommand
 var getConnectionCmd = &cobra.Command{
-	Use:   "get [connection name]",
-	Short: "Get credential sets associated with a connection",
-	Long:  `Get credential sets associated with a connection.`,
-	Args:  cobra.MinimumNArgs(1),
+	Use:               "get [connection name]",
+	Short:             "Get credential sets associated with a connection",
+	Long:              `Get credential sets associated with a connection.`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeConnectedTools,
 	Run: func(cmd *cobra.Command, args []string) {
 		// retrieve connection as the first argument
 		connection := args[0]
@@ -74,6 +82,54 @@ var getConnectionCmd = &cobra.Command{
 	},
 }
 
+// usageConnectionCmd represents the connection usage subcommand
+var usageConnectionCmd = &cobra.Command{
+	Use:   "usage [tool name] [credential-set-name]",
+	Short: "List the snaps and active snaps that use a tool or one of its credential sets",
+	Long: `List the steps of the user's snaps and active snaps that use a tool.
+
+If a credential-set name is passed, only the steps that may use that credential set are
+listed: those that name it in their "connection" value (directly or through a snap
+parameter), and those that don't name a credential set and so use the tool's default.`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeCredentialSets,
+	Run: func(cmd *cobra.Command, args []string) {
+		tool := args[0]
+		credentialSet := ""
+		if len(args) > 1 {
+			credentialSet = args[1]
+		}
+
+		usage, err := getToolUsage(tool, credentialSet)
+
+		format, _ := rootCmd.PersistentFlags().GetString("format")
+		if format == "json" {
+			payload, _ := json.Marshal(usage)
+			print.JSON(payload)
+			exitIfUsageUnknown(err)
+			return
+		}
+
+		name := tool
+		if credentialSet != "" {
+			name = fmt.Sprintf("credential set %s of %s", credentialSet, tool)
+		}
+		print.SnapUsageTable(fmt.Sprintf("Snaps that use %s", name), usage.Snaps)
+		fmt.Println()
+		print.ActiveSnapUsageTable(fmt.Sprintf("Active snaps that use %s", name), usage.ActiveSnaps)
+		exitIfUsageUnknown(err)
+	},
+}
+
+// exitIfUsageUnknown exits with an error if the usage of a tool couldn't be fully determined,
+// since the usage that was printed may be missing steps
+func exitIfUsageUnknown(err error) {
+	if err != nil {
+		utils.PrintErrorMessage("the usage may be incomplete", err)
+		os.Exit(1)
+	}
+}
+
 // listConnectionsCmd represents the list tools subcommand
 var listConnectionsCmd = &cobra.Command{
 	Use:   "list",
@@ -109,6 +165,12 @@ func init() {
 	connectionsCmd.AddCommand(disconnectToolCmd)
 	connectionsCmd.AddCommand(getConnectionCmd)
 	connectionsCmd.AddCommand(listConnectionsCmd)
+	connectionsCmd.AddCommand(usageConnectionCmd)
+	disconnectToolCmd.Flags().BoolP("force", "", false, "disconnect the tool even if active snaps use it")
+
+	pickable(disconnectToolCmd, false, connectedToolPicker)
+	pickable(getConnectionCmd, false, connectedToolPicker)
+	pickable(usageConnectionCmd, false, toolPicker)
 }
 
 func processConnectionCommand(path string, connection string, data map[string]interface{}) {
@@ -122,6 +184,9 @@ func processConnectionCommand(path string, connection string, data map[string]in
 
 	// execute the API call
 	response, err := api.Post(path, payload)
+	if err == api.ErrDryRun {
+		return
+	}
 	if err != nil {
 		utils.PrintErrorMessage("could not retrieve data", err)
 		os.Exit(1)
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConnections(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+	runSnap(t, server, "ci\nci-user\nci-password\n", "connect", "docker").expect(t, 0)
+
+	runCommandTests(t, server, []commandTest{
+		{name: "list", args: []string{"connections", "list"}, contains: []string{"PROVIDER", "docker", "github"}},
+		{name: "get", args: []string{"connections", "get", "docker"}, contains: []string{"Credential sets for", "docker connection", "ci"}},
+		{name: "usage", args: []string{"connections", "usage", "docker"}, contains: []string{"snaptest/gke-deploy"}},
+	})
+}
+
+func TestDisconnect(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+	server.AddTool("slack", "simple", strings.Replace(testToolDocker, "name: docker", "name: slack", 1))
+	runSnap(t, server, "ci\nci-user\nci-password\n", "connect", "slack").expect(t, 0)
+
+	// no active snap uses slack
+	runSnap(t, server, "", "connections", "disconnect", "slack", "--yes", "--dry-run").expect(t, 0, "dry run: POST")
+	if len(server.Credentials("slack")) != 1 {
+		t.Fatal("a dry run disconnected the tool")
+	}
+
+	runSnap(t, server, "", "connections", "disconnect", "slack", "--yes").expect(t, 0, "success")
+	if len(server.Credentials("slack")) != 0 {
+		t.Error("the credential sets weren't removed")
+	}
+	bodies := posts(server, "/connections")
+	if last := bodies[len(bodies)-1]; !strings.Contains(last, `"action":"remove"`) || !strings.Contains(last, `"provider":"slack"`) {
+		t.Errorf("posted %s", last)
+	}
+}
+
+func TestDisconnectInUse(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	runSnap(t, server, "", "connections", "disconnect", "docker", "--yes").expect(t, 1, "used by 1 active snap", "pass --force")
+	if sets := posts(server, "/connections"); len(sets) > 0 {
+		t.Errorf("disconnected a tool in use: %v", sets)
+	}
+}
+
+func TestDisconnectUsageUnknown(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	// the active snap's definition can't be retrieved once its snap is deleted, so whether
+	// it uses docker is unknown
+	runSnap(t, server, "", "snaps", "delete", "snaptest/gke-deploy", "--yes").expect(t, 0)
+	runSnap(t, server, "", "connections", "usage", "docker").expect(t, 1, "the usage may be incomplete", "snaptest/gke-deploy")
+	runSnap(t, server, "", "connections", "disconnect", "docker", "--yes").
+		expect(t, 1, "could not check whether active snaps use docker", "pass --force")
+	if sets := posts(server, "/connections"); len(sets) > 0 {
+		t.Errorf("disconnected a tool whose usage is unknown: %v", sets)
+	}
+
+	runSnap(t, server, "", "connections", "disconnect", "docker", "--yes", "--force").expect(t, 0)
+	if sets := posts(server, "/connections"); len(sets) != 1 {
+		t.Errorf("posted %v, want the disconnection", sets)
+	}
+}
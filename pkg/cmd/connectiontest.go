@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/snapmaster-io/snap/pkg/api"
+	"github.com/snapmaster-io/snap/pkg/print"
+	"github.com/snapmaster-io/snap/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/tidwall/gjson"
+)
+
+// testConnectionCmd represents the connection test subcommand
+var testConnectionCmd = &cobra.Command{
+	Use:   "test [tool name] [credential-set-name]",
+	Short: "Test the credential sets of a tool",
+	Long: `Test the credential sets of a tool, by asking the server to make a harmless read call to
+the tool with each of them.  The outcome, latency, and any error message of each test
+are shown.  A test only passes if the server returns its result (the tool's latency or
+output); a plain success, or a rejection of the test action, is reported as "cannot
+verify", and the command fails.
+
+If a credential-set name isn't passed, all of the tool's credential sets are tested.`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeCredentialSets,
+	Run: func(cmd *cobra.Command, args []string) {
+		tool := args[0]
+
+		var credentialSets []string
+		if len(args) > 1 {
+			credentialSets = args[1:]
+		} else {
+			response, err := api.Get(fmt.Sprintf("/entities/%s", tool))
+			if err != nil {
+				utils.PrintErrorMessage("could not retrieve data", err)
+				os.Exit(1)
+			}
+			for _, id := range gjson.GetBytes(response, "data.#.__id").Array() {
+				credentialSets = append(credentialSets, id.String())
+			}
+			if len(credentialSets) == 0 {
+				utils.PrintError(fmt.Sprintf("%s has no credential sets to test", tool))
+				os.Exit(1)
+			}
+		}
+
+		results := make([]print.ConnectionTest, len(credentialSets))
+		for i, credentialSet := range credentialSets {
+			results[i] = testConnection(tool, credentialSet, nil)
+		}
+		printConnectionTests(tool, results)
+	},
+}
+
+func init() {
+	connectionsCmd.AddCommand(testConnectionCmd)
+
+	pickable(testConnectionCmd, false, connectedToolPicker)
+}
+
+// unsupportedAction matches the errors of services that reject an action they don't implement
+var unsupportedAction = regexp.MustCompile(`(?i)(unknown|unsupported|invalid|unrecognized) action`)
+
+// testConnection asks the server to make a harmless read call to the tool, with the values of
+// connectionInfo if it isn't nil, or otherwise with the stored credential set, and returns
+// the outcome along with the latency of the tool call (or else the round-trip latency).  The
+// values of connectionInfo are checked by the CLI itself, with a call to the tool's own API,
+// for tools that have a provider check.
+func testConnection(tool string, credentialSet string, connectionInfo []map[string]string) print.ConnectionTest {
+	data := make(map[string]interface{})
+	data["action"] = "test"
+	path := fmt.Sprintf("/entities/%s", tool)
+	if connectionInfo != nil {
+		path = "/connections"
+		data["provider"] = tool
+		data["connectionInfo"] = connectionInfo
+	} else {
+		data["id"] = credentialSet
+	}
+
+	result := print.ConnectionTest{Tool: tool, CredentialSet: credentialSet}
+	if connectionInfo != nil {
+		resolved, err := resolveSecrets(connectionInfo)
+		if err != nil {
+			result.State = print.StepFailed
+			result.Message = err.Error()
+			return result
+		}
+		data["connectionInfo"] = resolved
+
+		values := make(map[string]string)
+		for _, param := range resolved {
+			if param["type"] != "name" {
+				values[param["name"]] = param["value"]
+			}
+		}
+		if check, ok := findProviderCheck(tool, values); ok {
+			return runProviderCheck(result, check, values)
+		}
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		result.State = print.StepFailed
+		result.Message = err.Error()
+		return result
+	}
+
+	start := time.Now()
+	response, err := api.Request("POST", path, payload)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err == nil {
+		err = api.CheckStatus(response)
+	}
+
+	switch {
+	case err == api.ErrDryRun:
+		result.State = print.StepDryRun
+		result.Message = "request not sent"
+	case err != nil && unsupportedAction.MatchString(err.Error()):
+		result.State = print.StepUnverified
+		result.Message = fmt.Sprintf("the server doesn't support connection tests: %s", err)
+	case err != nil:
+		result.State = print.StepFailed
+		result.Message = err.Error()
+	case !gjson.GetBytes(response, "data.latencyMs").Exists() && !gjson.GetBytes(response, "data.output").Exists():
+		// a success without a test result may be a service accepting an action it doesn't
+		// implement, so it doesn't count as a pass
+		result.State = print.StepUnverified
+		result.Message = "the server returned no test result (latency or tool output); it may not support connection tests"
+	default:
+		result.State = print.StepDone
+		result.Message = gjson.GetBytes(response, "message").String()
+		if latency := gjson.GetBytes(response, "data.latencyMs"); latency.Exists() {
+			result.LatencyMs = latency.Int()
+		}
+		if output := gjson.GetBytes(response, "data.output").String(); output != "" {
+			result.Message = output
+		}
+	}
+
+	return result
+}
+
+// runProviderCheck returns the result with the outcome of the provider check on the values
+func runProviderCheck(result print.ConnectionTest, check providerCheck, values map[string]string) print.ConnectionTest {
+	if dryRun, _ := rootCmd.PersistentFlags().GetBool("dry-run"); dryRun {
+		result.State = print.StepDryRun
+		result.Message = "request not sent"
+		return result
+	}
+
+	start := time.Now()
+	message, err := check.check(values)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.State = print.StepFailed
+		result.Message = err.Error()
+		return result
+	}
+
+	result.State = print.StepDone
+	result.Message = message
+	return result
+}
+
+// printConnectionTests prints the outcome of the tests in the requested format, and exits
+// with an error status if any of them failed
+func printConnectionTests(tool string, results []print.ConnectionTest) {
+	format, _ := rootCmd.PersistentFlags().GetString("format")
+	if format == "json" {
+		payload, _ := json.Marshal(results)
+		print.JSON(payload)
+	} else {
+		print.ConnectionTestsTable(fmt.Sprintf("Connection tests for %s", tool), results)
+	}
+
+	for _, result := range results {
+		if result.State == print.StepFailed || result.State == print.StepUnverified {
+			os.Exit(1)
+		}
+	}
+}
+
+// verifyConnection tests the credentials before they are saved when --verify is passed,
+// and exits without saving them unless the test passes.  --force saves credentials that
+// can't be verified, for servers that can't test connections, but not ones that fail.
+func verifyConnection(cmd *cobra.Command, tool string, credentials []map[string]string) {
+	if verify, _ := cmd.Flags().GetBool("verify"); !verify {
+		return
+	}
+
+	credentialSet := "default"
+	for _, param := range credentials {
+		if param["type"] == "name" && param["value"] != "" {
+			credentialSet = param["value"]
+		}
+	}
+
+	result := testConnection(tool, credentialSet, credentials)
+	switch result.State {
+	case print.StepFailed:
+		print.ConnectionTestsTable(fmt.Sprintf("Connection test for %s", tool), []print.ConnectionTest{result})
+		utils.PrintError("the credentials were not saved")
+		os.Exit(1)
+	case print.StepUnverified:
+		if force, _ := cmd.Flags().GetBool("force"); force {
+			utils.PrintWarning(fmt.Sprintf("could not verify the credentials for %s, so saving them without a test (--force): %s", tool, result.Message))
+			return
+		}
+		print.ConnectionTestsTable(fmt.Sprintf("Connection test for %s", tool), []print.ConnectionTest{result})
+		utils.PrintError("the credentials were not saved, as they could not be verified; pass --force to save them without a test")
+		os.Exit(1)
+	case print.StepDone:
+		utils.PrintMessage(fmt.Sprintf("verified the credentials for %s in %dms", tool, result.LatencyMs))
+	}
+}
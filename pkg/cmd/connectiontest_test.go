@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/snapmaster-io/snap/pkg/snaptest"
+)
+
+func TestConnectionTest(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	runSnap(t, server, "ci\nci-user\n\n", "connections", "credential-set", "add", "docker").expect(t, 0)
+	runSnap(t, server, "", "connections", "test", "docker", "ci").expect(t, 1, "failed", "password is empty")
+
+	runSnap(t, server, "ci\nci-user\npassword\n", "connections", "credential-set", "add", "docker").expect(t, 0)
+
+	runSnap(t, server, "", "connections", "test", "docker", "ci").expect(t, 0, "done", "connected to docker")
+}
+
+func TestConnectionTestUnverified(t *testing.T) {
+	server, _ := newTestServer(t, snaptest.WithoutTestResults())
+	defer server.Close()
+
+	runSnap(t, server, "ci\nci-user\npassword\n", "connections", "credential-set", "add", "docker").expect(t, 0)
+	runSnap(t, server, "", "connections", "test", "docker", "ci").expect(t, 1, "cannot verify", "may not support connection tests")
+}
+
+func TestConnectVerifyUnverified(t *testing.T) {
+	server, _ := newTestServer(t, snaptest.WithoutTestResults())
+	defer server.Close()
+	// slack has no provider check, so its credentials are tested by the server
+	server.AddTool("slack", "simple", strings.Replace(testToolDocker, "name: docker", "name: slack", 1))
+
+	runSnap(t, server, "ci\nci-user\npassword\n", "connections", "credential-set", "add", "slack", "--verify").
+		expect(t, 1, "may not support connection tests", "pass --force to save them without a test")
+	if sets := server.Credentials("slack"); len(sets) > 0 {
+		t.Fatalf("saved %v without verifying them", sets)
+	}
+
+	runSnap(t, server, "ci\nci-user\npassword\n", "connections", "credential-set", "add", "slack", "--verify", "--force").
+		expect(t, 0, "could not verify the credentials for slack", "may not support connection tests")
+	if sets := server.Credentials("slack"); len(sets) != 1 || sets[0]["__id"] != "ci" {
+		t.Errorf("saved %v, want the ci credential set", sets)
+	}
+}
+
+func TestConnectVerifyProviderCheck(t *testing.T) {
+	server, _ := newTestServer(t, snaptest.WithoutTestResults())
+	defer server.Close()
+
+	// docker credentials are checked with a Docker Hub login, not by the server
+	runSnap(t, server, "ci\nci-user\npassword\n", "connect", "docker", "--verify").
+		expect(t, 0, "verified the credentials for docker")
+	for _, body := range posts(server, "/connections") {
+		if strings.Contains(body, `"action":"test"`) {
+			t.Errorf("asked the server to test the credentials: %s", body)
+		}
+	}
+	if sets := server.Credentials("docker"); len(sets) != 1 || sets[0]["__id"] != "ci" {
+		t.Errorf("saved %v, want the ci credential set", sets)
+	}
+}
+
+func TestConnectVerifyFailed(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	runSnap(t, server, "ci\nci-user\n\n", "connections", "credential-set", "add", "docker", "--verify").
+		expect(t, 1, "password is empty", "the credentials were not saved")
+	if sets := server.Credentials("docker"); len(sets) > 0 {
+		t.Errorf("saved %v", sets)
+	}
+}
+
+func TestUnsupportedAction(t *testing.T) {
+	tests := []struct {
+		message     string
+		unsupported bool
+	}{
+		{"unknown action test", true},
+		{"Unsupported action: test", true},
+		{"docker: password is empty", false},
+		{"401 unauthorized: bad credentials", false},
+	}
+
+	for _, tt := range tests {
+		if unsupported := unsupportedAction.MatchString(tt.message); unsupported != tt.unsupported {
+			t.Errorf("%q is an unsupported action: %v, want %v", tt.message, unsupported, tt.unsupported)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/snapmaster-io/snap/pkg/print"
+	"github.com/snapmaster-io/snap/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// snapContext is a named SnapMaster environment, along with the login to it
+type snapContext struct {
+	APIURL      string `json:"apiUrl" mapstructure:"apiUrl"`
+	ClientID    string `json:"clientId" mapstructure:"clientId"`
+	AuthDomain  string `json:"authDomain" mapstructure:"authDomain"`
+	AccessToken string `json:"accessToken,omitempty" mapstructure:"accessToken"`
+	Email       string `json:"email,omitempty" mapstructure:"email"`
+	Name        string `json:"name,omitempty" mapstructure:"name"`
+}
+
+// the settings a context switches
+var contextSettings = []string{"APIURL", "ClientID", "AuthDomain", "AccessToken", "Email", "Name"}
+
+// configContextCmd represents the config context command
+var configContextCmd = &cobra.Command{
+	Use:     "context",
+	Aliases: []string{"contexts"},
+	Short:   "List the saved contexts",
+	Long: `List the saved contexts: named SnapMaster environments (API URL, client ID, and
+auth domain), along with the login to each of them.
+
+snap login saves the environment it logs into as a context, named dev or prod for the
+environments of snap config set dev and snap config set prod (and after the API URL's
+host name otherwise).  Commands that work across environments, like snap import and snap
+promote, take the name of a context.  Context names are case-insensitive.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		contexts := loadContexts()
+		if len(contexts) == 0 {
+			utils.PrintMessage("no saved contexts; log in to save one, or save the current configuration with snap config context save [name]")
+			return
+		}
+
+		current := currentContextName(contexts)
+		var rows []print.Context
+		for _, name := range sortedContextNames(contexts) {
+			c := contexts[name]
+			rows = append(rows, print.Context{Name: name, APIURL: c.APIURL, User: c.Email, LoggedIn: c.AccessToken != "", Current: name == current})
+		}
+		print.ContextsTable(rows)
+	},
+}
+
+// configContextSaveCmd represents the config context save command
+var configContextSaveCmd = &cobra.Command{
+	Use:   "save [name]",
+	Short: "Save the current configuration and login as a context",
+	Long: `Save the current configuration and login as a context.  The name defaults to dev or
+prod for the environments of snap config set dev and prod.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := defaultContextName(viper.GetString("APIURL"))
+		if len(args) > 0 {
+			name = strings.ToLower(args[0])
+		}
+
+		saveContext(name)
+		utils.PrintMessage(fmt.Sprintf("saved context %s", name))
+	},
+}
+
+// configContextUseCmd represents the config context use command
+var configContextUseCmd = &cobra.Command{
+	Use:               "use [name]",
+	Short:             "Switch the configuration and login to a saved context",
+	Long:              `Switch the configuration and login to a saved context.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeContexts,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := switchContext(args[0]); err != nil {
+			utils.PrintError(err.Error())
+			os.Exit(1)
+		}
+		if err := viper.WriteConfig(); err != nil {
+			utils.PrintErrorMessage("could not write config file", err)
+			os.Exit(1)
+		}
+
+		utils.PrintMessage(fmt.Sprintf("switched to context %s (%s)", strings.ToLower(args[0]), viper.GetString("APIURL")))
+	},
+}
+
+// configContextDeleteCmd represents the config context delete command
+var configContextDeleteCmd = &cobra.Command{
+	Use:               "delete [name]",
+	Short:             "Delete a saved context",
+	Long:              `Delete a saved context, along with its login.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeContexts,
+	Run: func(cmd *cobra.Command, args []string) {
+		contexts := loadContexts()
+		name := strings.ToLower(args[0])
+		if _, ok := contexts[name]; !ok {
+			utils.PrintError(fmt.Sprintf("no context named %s", args[0]))
+			os.Exit(1)
+		}
+		delete(contexts, name)
+		storeContexts(contexts)
+
+		utils.PrintMessage(fmt.Sprintf("deleted context %s", args[0]))
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configContextCmd)
+	configContextCmd.AddCommand(configContextSaveCmd)
+	configContextCmd.AddCommand(configContextUseCmd)
+	configContextCmd.AddCommand(configContextDeleteCmd)
+}
+
+// loadContexts returns the saved contexts
+func loadContexts() map[string]snapContext {
+	contexts := make(map[string]snapContext)
+	if err := viper.UnmarshalKey("Contexts", &contexts); err != nil {
+		utils.PrintErrorMessage("could not read the saved contexts", err)
+		os.Exit(1)
+	}
+
+	return contexts
+}
+
+// storeContexts writes the contexts into the config file
+func storeContexts(contexts map[string]snapContext) {
+	// set the contexts as plain maps, the way they are read from the config file
+	var values map[string]interface{}
+	contents, _ := json.Marshal(contexts)
+	json.Unmarshal(contents, &values)
+
+	// replace the contexts in the configuration rather than setting them over it, which would
+	// keep deleted contexts and cleared logins from the config file
+	settings := viper.AllSettings()
+	settings["contexts"] = values
+	contents, err := json.Marshal(settings)
+	if err == nil {
+		err = viper.ReadConfig(bytes.NewReader(contents))
+	}
+	if err == nil {
+		err = viper.WriteConfig()
+	}
+	if err != nil {
+		utils.PrintErrorMessage("could not write config file", err)
+		os.Exit(1)
+	}
+}
+
+// saveContext saves the current configuration and login as a context
+func saveContext(name string) {
+	contexts := loadContexts()
+	contexts[name] = snapContext{
+		APIURL:      viper.GetString("APIURL"),
+		ClientID:    viper.GetString("ClientID"),
+		AuthDomain:  viper.GetString("AuthDomain"),
+		AccessToken: viper.GetString("AccessToken"),
+		Email:       viper.GetString("Email"),
+		Name:        viper.GetString("Name"),
+	}
+	storeContexts(contexts)
+}
+
+// switchContext switches the configuration and login to a saved context, for the rest of
+// the command; the config file isn't changed
+func switchContext(name string) error {
+	contexts := loadContexts()
+	c, ok := contexts[strings.ToLower(name)]
+	if !ok {
+		names := strings.Join(sortedContextNames(contexts), ", ")
+		if names == "" {
+			names = "none"
+		}
+		return fmt.Errorf("no context named %s (contexts: %s); log in to it to save it", name, names)
+	}
+
+	values := []string{c.APIURL, c.ClientID, c.AuthDomain, c.AccessToken, c.Email, c.Name}
+	for i, setting := range contextSettings {
+		viper.Set(setting, values[i])
+	}
+
+	// the account of the logged in user is different in another context
+	journalAccount = ""
+	return nil
+}
+
+// useContextFlag switches to the context named by the command's --context flag, if it is set
+func useContextFlag(cmd *cobra.Command) {
+	name, _ := cmd.Flags().GetString("context")
+	if name == "" {
+		return
+	}
+
+	if err := switchContext(name); err != nil {
+		utils.PrintError(err.Error())
+		os.Exit(1)
+	}
+}
+
+// currentContextName returns the name of the context with the current API URL and login,
+// or "" if none matches
+func currentContextName(contexts map[string]snapContext) string {
+	for _, name := range sortedContextNames(contexts) {
+		c := contexts[name]
+		if c.APIURL == viper.GetString("APIURL") && c.AccessToken == viper.GetString("AccessToken") {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// defaultContextName returns the name of the context for an API URL: dev or prod for the
+// environments of snap config set dev and prod, or else the host name
+func defaultContextName(apiURL string) string {
+	switch apiURL {
+	case "https://dev.snapmaster.io":
+		return "dev"
+	case "https://www.snapmaster.io":
+		return "prod"
+	}
+
+	if u, err := url.Parse(apiURL); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return apiURL
+}
+
+// sortedContextNames returns the names of the contexts, sorted
+func sortedContextNames(contexts map[string]snapContext) []string {
+	var names []string
+	for name := range contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// completeContexts completes the names of the saved contexts
+func completeContexts(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return sortedContextNames(loadContexts()), cobra.ShellCompDirectiveNoFileComp
+}
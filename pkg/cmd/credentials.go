@@ -1,13 +1,16 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/snapmaster-io/snap/pkg/api"
 	"github.com/snapmaster-io/snap/pkg/print"
 	"github.com/snapmaster-io/snap/pkg/utils"
 	"github.com/spf13/cobra"
+	"github.com/tidwall/gjson"
 )
 
 // credentialsCmd represents the credential set command
@@ -31,8 +34,13 @@ var credentialsAddCmd = &cobra.Command{
 	If only the tool name is passed in, the command will prompt for credential information.
 	
 	If a credential-set name and credential file name are provided, the command will create 
-	a named credential-set with those parameters.`,
-	Args: cobra.MinimumNArgs(1),
+	a named credential-set with those parameters.
+	
+	With --verify, the credentials are tested (see "snap connections test") before they are
+	saved, and aren't saved unless the test passes.  If they can't be verified, because the
+	server can't test connections, --force saves them anyway.`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeTools,
 	Run: func(cmd *cobra.Command, args []string) {
 		// retrieve tool as the first argument
 		tool := args[0]
@@ -59,18 +67,126 @@ var credentialsAddCmd = &cobra.Command{
 			readParametersFromFile(credentials, credentialName, credentialsFile)
 		}
 
-		// make the POST call to the API
+		// test the credentials if requested, and make the POST call to the API
+		verifyConnection(cmd, tool, credentials)
 		path := fmt.Sprintf("/entities/%s", tool)
 		processConnectCommand(tool, path, credentials)
 	},
 }
 
+// credentialsUpdateCmd represents the credential set update command
+var credentialsUpdateCmd = &cobra.Command{
+	Use:   "update [tool name] [credential-set-name]",
+	Short: "Replace the values of a credential set associated with this tool",
+	Long: `Replace all of the values of a credential set associated with this tool, keeping its name.
+	
+	The command prompts for each value, unless --file is passed, in which case the contents
+	of the file are used for the values (like "credential-set add").  The new values are
+	tested (see "snap connections test") before the set is replaced, unless --no-verify is
+	passed, and the set isn't replaced unless the test passes.
+	
+	The set is replaced wholesale, by saving it again under its name with all of its values,
+	so active snaps that use it pick up the new values without being reactivated.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeCredentialSets,
+	Run: func(cmd *cobra.Command, args []string) {
+		// retrieve arguments
+		tool := args[0]
+		credentialSet := args[1]
+		credentialsFile, _ := cmd.Flags().GetString("file")
+
+		nameField, credentials := getCredentialFields(tool, credentialSet)
+		if credentialsFile != "" {
+			readParametersFromFile(credentials, credentialSet, credentialsFile)
+		} else {
+			utils.PrintMessage(fmt.Sprintf("updating credential-set %s for %s", credentialSet, tool))
+			inputParameters(credentials)
+		}
+		checkCredentialValues(credentials)
+
+		processCredentialSetUpdate(cmd, tool, credentialSet, nameField, credentials, credentials)
+	},
+}
+
+// credentialsRotateCmd represents the credential set rotate command
+var credentialsRotateCmd = &cobra.Command{
+	Use:   "rotate [tool name] [credential-set-name] [field]...",
+	Short: "Replace selected values of a credential set associated with this tool",
+	Long: `Replace selected values of a credential set associated with this tool, such as a token,
+	keeping its other values and its name.
+	
+	The command prompts for the new value of each field passed as an argument.  Values can
+	also be passed with --value field=value.  The new values, along with the set's other
+	current values, are tested (see "snap connections test") unless --no-verify is passed,
+	and the set isn't replaced unless the test passes.
+	
+	The set is replaced wholesale, by saving it again under its name with all of its values,
+	in a single request, so active snaps that use it never see a partial change.`,
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeCredentialSets,
+	Run: func(cmd *cobra.Command, args []string) {
+		// retrieve arguments
+		tool := args[0]
+		credentialSet := args[1]
+		values, _ := cmd.Flags().GetStringArray("value")
+		if len(args) == 2 && len(values) == 0 {
+			utils.PrintError("pass the fields to rotate as arguments or with --value field=value")
+			os.Exit(1)
+		}
+
+		// index the tool's fields by name
+		nameField, all := getCredentialFields(tool, credentialSet)
+		fields := make(map[string]map[string]string)
+		var names []string
+		for _, field := range all {
+			fields[field["name"]] = field
+			names = append(names, field["name"])
+		}
+
+		// select the fields to rotate, in the order they were passed
+		var credentials []map[string]string
+		selectField := func(name string) map[string]string {
+			field, ok := fields[name]
+			if !ok {
+				utils.PrintError(fmt.Sprintf("%s has no credential field %s (fields: %s)", tool, name, strings.Join(names, ", ")))
+				os.Exit(1)
+			}
+			if _, selected := field["value"]; selected {
+				utils.PrintError(fmt.Sprintf("field %s was passed more than once", name))
+				os.Exit(1)
+			}
+			credentials = append(credentials, field)
+			return field
+		}
+
+		for _, value := range values {
+			parts := strings.SplitN(value, "=", 2)
+			if len(parts) != 2 {
+				utils.PrintError(fmt.Sprintf("invalid value %q: expected field=value", value))
+				os.Exit(1)
+			}
+			selectField(parts[0])["value"] = parts[1]
+		}
+		var prompted []map[string]string
+		for _, name := range args[2:] {
+			field := selectField(name)
+			field["value"] = ""
+			prompted = append(prompted, field)
+		}
+		inputParameters(prompted)
+		checkCredentialValues(credentials)
+
+		processCredentialSetUpdate(cmd, tool, credentialSet, nameField, all, credentials)
+	},
+}
+
 // credentialsListCmd represents the credential set list command
 var credentialsListCmd = &cobra.Command{
-	Use:   "list [tool name]",
-	Short: "List credential sets associated with this tool",
-	Long:  `List credential sets associated with this tool.`,
-	Args:  cobra.MinimumNArgs(1),
+	Use:               "list [tool name]",
+	Short:             "List credential sets associated with this tool",
+	Long:              `List credential sets associated with this tool.`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeConnectedTools,
 	Run: func(cmd *cobra.Command, args []string) {
 		// retrieve connection as the first argument
 		connection := args[0]
@@ -105,12 +221,17 @@ var credentialsRemoveCmd = &cobra.Command{
 	Short: "Removes a credential set associated with this tool",
 	Long: `Removes a credential set associated with this tool.
 	
-	Both the tool name and credential-set name arguments are required.`,
-	Args: cobra.MinimumNArgs(2),
+	Both the tool name and credential-set name arguments are required.  If any active snaps
+	may use the credential set (see "snap connections usage"), it isn't removed unless
+	--force is passed.`,
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeCredentialSets,
 	Run: func(cmd *cobra.Command, args []string) {
 		// retrieve arguments
 		tool := args[0]
 		credentials := args[1]
+		usage := refuseIfUsed(cmd, fmt.Sprintf("remove credential set %s", credentials), tool, credentials)
+		confirmCredentialSetRemoval(tool, credentials, usage)
 
 		data := make(map[string]interface{})
 		data["action"] = "remove"
@@ -127,4 +248,228 @@ func init() {
 	credentialsCmd.AddCommand(credentialsAddCmd)
 	credentialsCmd.AddCommand(credentialsListCmd)
 	credentialsCmd.AddCommand(credentialsRemoveCmd)
+	credentialsCmd.AddCommand(credentialsUpdateCmd)
+	credentialsAddCmd.Flags().BoolP("verify", "", false, "test the credentials before saving them")
+	credentialsAddCmd.Flags().BoolP("force", "", false, "with --verify, save credentials that can't be verified")
+	credentialsCmd.AddCommand(credentialsRotateCmd)
+	credentialsUpdateCmd.Flags().StringP("file", "", "", "read the values from this file instead of prompting")
+	credentialsUpdateCmd.Flags().BoolP("no-verify", "", false, "replace the credential set without testing the new values")
+	credentialsRotateCmd.Flags().StringArrayP("value", "", nil, "new value of a field, as field=value (can be repeated)")
+	credentialsRotateCmd.Flags().BoolP("no-verify", "", false, "replace the credential set without testing the new values")
+	credentialsRemoveCmd.Flags().BoolP("force", "", false, "remove the credential set even if active snaps may use it")
+
+	pickable(credentialsAddCmd, false, toolPicker)
+	pickable(credentialsListCmd, false, connectedToolPicker)
+	pickable(credentialsRemoveCmd, false, connectedToolPicker, credentialSetPicker)
+	pickable(credentialsUpdateCmd, false, connectedToolPicker, credentialSetPicker)
+}
+
+// getCredentialFields checks that the credential set exists, and returns the name of the
+// tool's field that names the set (if it has one), and the descriptions of its other
+// credential fields, with the set's current value of each under "current" when the service
+// returns it
+func getCredentialFields(tool string, credentialSet string) (string, []map[string]string) {
+	response, err := api.Get(fmt.Sprintf("/entities/%s", tool))
+	if err != nil {
+		utils.PrintErrorMessage("could not retrieve data", err)
+		os.Exit(1)
+	}
+	if err := api.CheckStatus(response); err != nil {
+		utils.PrintErrorMessage("could not retrieve data", err)
+		os.Exit(1)
+	}
+	set := gjson.GetBytes(response, fmt.Sprintf(`data.#(__id==%q)`, credentialSet))
+	if !set.Exists() {
+		utils.PrintError(fmt.Sprintf("%s has no credential set %s", tool, credentialSet))
+		os.Exit(1)
+	}
+	current := set.Map()
+
+	jsonPath := fmt.Sprintf("data.#(provider==%s).definition.connection.connectionInfo", tool)
+	nameField := ""
+	var fields []map[string]string
+	for _, field := range getParameterDescriptions("/connections", jsonPath) {
+		if field["type"] == "name" {
+			nameField = field["name"]
+			continue
+		}
+		if value, ok := current[field["name"]]; ok {
+			field["current"] = value.String()
+		}
+		fields = append(fields, field)
+	}
+
+	return nameField, fields
+}
+
+// verifyCredentialSetUpdate tests the new values of a credential set before it is replaced,
+// and exits without replacing it unless the test passes
+func verifyCredentialSetUpdate(tool string, credentialSet string, connectionInfo []map[string]string) {
+	result := testConnection(tool, credentialSet, connectionInfo)
+	switch result.State {
+	case print.StepFailed:
+		print.ConnectionTestsTable(fmt.Sprintf("Connection test for %s", tool), []print.ConnectionTest{result})
+		utils.PrintError(fmt.Sprintf("credential-set %s was not updated; pass --no-verify to update it without a test", credentialSet))
+		os.Exit(1)
+	case print.StepUnverified:
+		print.ConnectionTestsTable(fmt.Sprintf("Connection test for %s", tool), []print.ConnectionTest{result})
+		utils.PrintError(fmt.Sprintf("credential-set %s was not updated, as the new values could not be verified; pass --no-verify to update it without a test", credentialSet))
+		os.Exit(1)
+	case print.StepDone:
+		utils.PrintMessage(fmt.Sprintf("verified the new values of credential-set %s in %dms", credentialSet, result.LatencyMs))
+	}
+}
+
+// verifyCredentialSetStored retrieves the credential sets of the tool after an update, and
+// returns an error unless exactly one set has the name and it holds the posted values.  Values
+// the service doesn't return can't be compared, and aren't checked.
+func verifyCredentialSetStored(tool string, credentialSet string, connectionInfo []map[string]string) error {
+	response, err := api.Request("GET", fmt.Sprintf("/entities/%s", tool), nil)
+	if err == nil {
+		err = api.CheckStatus(response)
+	}
+	if err != nil {
+		return fmt.Errorf("could not verify the update: %s", err)
+	}
+
+	sets := gjson.GetBytes(response, fmt.Sprintf(`data.#(__id==%q)#`, credentialSet)).Array()
+	if len(sets) != 1 {
+		return fmt.Errorf("the service returned success, but has %d credential sets named %s instead of one", len(sets), credentialSet)
+	}
+	stored := sets[0].Map()
+	var stale []string
+	for _, field := range connectionInfo {
+		if field["type"] == "name" {
+			continue
+		}
+		if value, ok := stored[field["name"]]; ok && value.String() != field["value"] {
+			stale = append(stale, field["name"])
+		}
+	}
+	if len(stale) > 0 {
+		return fmt.Errorf("the service returned success, but credential-set %s still has a different %s", credentialSet, strings.Join(stale, ", "))
+	}
+
+	return nil
+}
+
+// checkCredentialValues exits with an error if any of the new credential values are empty
+func checkCredentialValues(credentials []map[string]string) {
+	var empty []string
+	for _, field := range credentials {
+		if strings.TrimSpace(field["value"]) == "" {
+			empty = append(empty, field["name"])
+		}
+	}
+
+	if len(empty) > 0 {
+		utils.PrintError(fmt.Sprintf("no value for %s; the credentials were not saved", strings.Join(empty, ", ")))
+		os.Exit(1)
+	}
+}
+
+// processCredentialSetUpdate replaces the values of the credential set's fields in a single
+// request, after testing them unless --no-verify is passed, and reports the active snaps that
+// use the credential set.  The set is replaced by adding it again under the same name, with
+// the new values of the updated credentials and the current values of the tool's other
+// fields.  This assumes the service replaces the set of that name rather than adding a second
+// one, so the sets are retrieved again afterwards, and the update fails unless exactly one set
+// has the name and it holds the new values.
+func processCredentialSetUpdate(cmd *cobra.Command, tool string, credentialSet string, nameField string, fields []map[string]string, credentials []map[string]string) {
+	updated := make([]map[string]string, len(credentials))
+	for i, field := range credentials {
+		updated[i] = map[string]string{"name": field["name"], "value": field["value"]}
+	}
+	updated, err := resolveSecrets(updated)
+	if err != nil {
+		utils.PrintErrorMessage("could not resolve credential values", err)
+		os.Exit(1)
+	}
+	values := make(map[string]string)
+	for _, field := range updated {
+		values[field["name"]] = field["value"]
+	}
+
+	var connectionInfo []map[string]string
+	if nameField != "" {
+		connectionInfo = append(connectionInfo, map[string]string{"name": nameField, "type": "name", "value": credentialSet})
+	}
+	var missing []string
+	for _, field := range fields {
+		value, ok := values[field["name"]]
+		if !ok {
+			value, ok = field["current"]
+		}
+		if !ok {
+			missing = append(missing, field["name"])
+			continue
+		}
+		connectionInfo = append(connectionInfo, map[string]string{"name": field["name"], "value": value})
+	}
+	if len(missing) > 0 {
+		utils.PrintError(fmt.Sprintf("the service doesn't return the current %s of credential-set %s, so it can't keep them; "+
+			"use \"snap connections credential-set update %s %s\" to replace all of the values", strings.Join(missing, ", "), credentialSet, tool, credentialSet))
+		os.Exit(1)
+	}
+	if noVerify, _ := cmd.Flags().GetBool("no-verify"); !noVerify {
+		verifyCredentialSetUpdate(tool, credentialSet, connectionInfo)
+	}
+
+	data := make(map[string]interface{})
+	data["action"] = "add"
+	data["provider"] = tool
+	data["connectionInfo"] = connectionInfo
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		utils.PrintErrorMessage("could not serialize payload into JSON", err)
+		os.Exit(1)
+	}
+
+	// execute the API call
+	response, err := api.Post(fmt.Sprintf("/entities/%s", tool), payload)
+	if err == api.ErrDryRun {
+		return
+	}
+	if err != nil {
+		utils.PrintErrorMessage("could not retrieve data", err)
+		os.Exit(1)
+	}
+
+	format, _ := rootCmd.PersistentFlags().GetString("format")
+	if err := api.CheckStatus(response); err != nil {
+		if format == "json" {
+			print.JSON(response)
+			return
+		}
+		print.Status(response)
+		os.Exit(1)
+	}
+	if err := verifyCredentialSetStored(tool, credentialSet, connectionInfo); err != nil {
+		utils.PrintErrorMessage(fmt.Sprintf("could not update credential-set %s for %s", credentialSet, tool), err)
+		os.Exit(1)
+	}
+	if format == "json" {
+		print.JSON(response)
+		return
+	}
+
+	names := make([]string, len(credentials))
+	for i, field := range credentials {
+		names[i] = field["name"]
+	}
+	utils.PrintMessage(fmt.Sprintf("updated %s of credential-set %s for %s", strings.Join(names, ", "), credentialSet, tool))
+
+	// the active snaps that may use the set pick up the new values on their next execution
+	usage, err := getActiveSnapUsage(make(snapDefinitions), tool, credentialSet)
+	if err != nil {
+		utils.PrintWarning(fmt.Sprintf("the active snaps that use credential set %s may not all be listed: %s", credentialSet, err))
+	}
+	if len(usage) == 0 {
+		if err == nil {
+			utils.PrintMessage(fmt.Sprintf("no active snaps use credential set %s of %s", credentialSet, tool))
+		}
+		return
+	}
+	print.ActiveSnapUsageTable(fmt.Sprintf("Active snaps that picked up the change to %s", credentialSet), usage)
 }
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/snapmaster-io/snap/pkg/snaptest"
+)
+
+func TestCredentialSetRotate(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	runSnap(t, server, "ci\nci-user\nold-password\n", "connections", "credential-set", "add", "docker").expect(t, 0)
+
+	runSnap(t, server, "", "connections", "credential-set", "rotate", "docker", "ci", "--value", "password=new-password", "--dry-run").
+		expect(t, 0, `"action": "add"`, `"value": "ci"`, "<redacted>")
+	runSnap(t, server, "", "connections", "credential-set", "rotate", "docker", "ci", "--value", "password=new-password").
+		expect(t, 0, "verified the new values of credential-set ci", "updated password of credential-set ci for docker")
+
+	want := []map[string]string{{"__id": "ci", "username": "ci-user", "password": "new-password"}}
+	if got := server.Credentials("docker"); !reflect.DeepEqual(got, want) {
+		t.Errorf("credential sets %v, want %v", got, want)
+	}
+}
+
+func TestCredentialSetUpdate(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	runSnap(t, server, "ci\nci-user\nold-password\n", "connections", "credential-set", "add", "docker").expect(t, 0)
+	runSnap(t, server, "", "connections", "credential-set", "update", "docker", "missing").expect(t, 1, "docker has no credential set missing")
+
+	runSnap(t, server, "bot\nnew-password\n", "connections", "credential-set", "update", "docker", "ci").expect(t, 0)
+	want := []map[string]string{{"__id": "ci", "username": "bot", "password": "new-password"}}
+	if got := server.Credentials("docker"); !reflect.DeepEqual(got, want) {
+		t.Errorf("credential sets %v, want %v", got, want)
+	}
+}
+
+func TestCredentialSetRotateVerify(t *testing.T) {
+	server, _ := newTestServer(t, snaptest.WithCredentialCheck(func(provider string, values map[string]string) error {
+		if values["password"] == "bad-password" {
+			return fmt.Errorf("401 unauthorized: bad credentials")
+		}
+		return nil
+	}))
+	defer server.Close()
+
+	runSnap(t, server, "ci\nci-user\nold-password\n", "connections", "credential-set", "add", "docker").expect(t, 0)
+	old := server.Credentials("docker")
+
+	runSnap(t, server, "", "connections", "credential-set", "rotate", "docker", "ci", "--value", "password=bad-password").
+		expect(t, 1, "bad credentials", "credential-set ci was not updated")
+	if got := server.Credentials("docker"); !reflect.DeepEqual(got, old) {
+		t.Errorf("credential sets %v after a failed test, want %v", got, old)
+	}
+
+	runSnap(t, server, "", "connections", "credential-set", "rotate", "docker", "ci", "--value", "password=bad-password", "--no-verify").
+		expect(t, 0, "updated password of credential-set ci for docker")
+	if got := server.Credentials("docker"); got[0]["password"] != "bad-password" {
+		t.Errorf("credential sets %v after --no-verify, want the new password", got)
+	}
+}
+
+func TestCredentialSetUpdateUnverified(t *testing.T) {
+	server, _ := newTestServer(t, snaptest.WithoutTestResults())
+	defer server.Close()
+	// slack has no provider check, so its credentials are tested by the server
+	server.AddTool("slack", "simple", strings.Replace(testToolDocker, "name: docker", "name: slack", 1))
+
+	runSnap(t, server, "ci\nci-user\nold-password\n", "connections", "credential-set", "add", "slack").expect(t, 0)
+	old := server.Credentials("slack")
+
+	runSnap(t, server, "bot\nnew-password\n", "connections", "credential-set", "update", "slack", "ci").
+		expect(t, 1, "credential-set ci was not updated", "pass --no-verify")
+	if got := server.Credentials("slack"); !reflect.DeepEqual(got, old) {
+		t.Errorf("credential sets %v without a verification, want %v", got, old)
+	}
+
+	runSnap(t, server, "bot\nnew-password\n", "connections", "credential-set", "update", "slack", "ci", "--no-verify").expect(t, 0)
+	if got := server.Credentials("slack"); got[0]["password"] != "new-password" {
+		t.Errorf("credential sets %v after --no-verify, want the new password", got)
+	}
+}
+
+func TestCredentialSetUpdateNotStored(t *testing.T) {
+	tests := []struct {
+		name     string
+		option   snaptest.Option
+		contains string
+	}{
+		{"added as a second set", snaptest.WithoutCredentialSetReplacement(), "credential sets named ci instead of one"},
+		{"update ignored", snaptest.WithoutCredentialSetUpdates(), "credential-set ci still has a different password"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, _ := newTestServer(t, test.option)
+			defer server.Close()
+
+			runSnap(t, server, "ci\nci-user\nold-password\n", "connections", "credential-set", "add", "docker").expect(t, 0)
+
+			for _, format := range []string{"table", "json"} {
+				r := runSnap(t, server, "", "connections", "credential-set", "rotate", "docker", "ci", "--value", "password=new-password", "--format", format)
+				r.expect(t, 1, "could not update credential-set ci for docker", test.contains)
+				if strings.Contains(r.stdout, "updated password") || strings.Contains(r.stdout, `"status"`) {
+					t.Errorf("reported success before the update was verified:\n%s", r.stdout)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/snapmaster-io/snap/pkg/api"
+	"github.com/snapmaster-io/snap/pkg/dashboard"
+	"github.com/snapmaster-io/snap/pkg/print"
+	"github.com/snapmaster-io/snap/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// dashboardCmd represents the dashboard command
+var dashboardCmd = &cobra.Command{
+	Use:   "dashboard",
+	Short: "Show a live dashboard of the user's active snaps",
+	Long: `Show a live dashboard of the user's active snaps.
+
+The dashboard shows a table of active snaps with their state, executions, and errors,
+refreshed every few seconds, and the logs of the selected active snap.  Pressing enter
+on a log entry shows the output of each action, like "snap active logs [active snap ID]
+details [log ID]".
+
+Keys:
+  up/down, j/k   move the cursor
+  tab            switch between the active snaps and the logs
+  enter          show the logs of an active snap, or the details of a log entry
+  p / r / d      pause, resume, or deactivate the selected active snap (after confirmation)
+  f              cycle the state filter
+  R              refresh now
+  q / esc        quit (or close the log details)
+
+The active snaps and logs are retrieved in the background, so the keys keep working while
+a refresh runs.  The dashboard doesn't support --dry-run.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		interval, _ := cmd.Flags().GetDuration("interval")
+		state, _ := cmd.Flags().GetString("state")
+		if interval < time.Second {
+			utils.PrintError("interval must be at least 1s")
+			os.Exit(1)
+		}
+
+		// the requests that a dry run prints would overwrite the screen, so the dashboard's
+		// actions can't be previewed
+		if dryRun, _ := rootCmd.PersistentFlags().GetBool("dry-run"); dryRun {
+			utils.PrintError("the dashboard doesn't support --dry-run; use snap active pause, resume, or deactivate --dry-run instead")
+			os.Exit(1)
+		}
+
+		err := dashboard.Run(dashboardSource{}, dashboard.Options{Interval: interval, State: state})
+		if err != nil {
+			utils.PrintErrorMessage("could not run the dashboard", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dashboardCmd)
+	dashboardCmd.Flags().DurationP("interval", "n", 5*time.Second, "how often to refresh the active snaps")
+	dashboardCmd.Flags().StringP("state", "s", "", "only show active snaps in this state (e.g. active, paused)")
+}
+
+// dashboardSource implements dashboard.Source with the same API calls as the active command
+type dashboardSource struct{}
+
+func (dashboardSource) ActiveSnaps() ([]print.ActiveSnap, error) {
+	response, err := api.Request("GET", "/activesnaps", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var activeSnapsResponse print.ActiveSnapsResponse
+	json.Unmarshal(response, &activeSnapsResponse)
+	if activeSnapsResponse.Status != "success" {
+		return nil, api.CheckStatus(response)
+	}
+
+	return activeSnapsResponse.Data, nil
+}
+
+func (dashboardSource) Logs(activeSnapID string) ([]print.ActiveSnapLog, error) {
+	response, err := api.Request("GET", fmt.Sprintf("/logs/%s", activeSnapID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var logsResponse print.ActiveSnapLogsResponse
+	json.Unmarshal(response, &logsResponse)
+	if logsResponse.Status != "success" {
+		return nil, api.CheckStatus(response)
+	}
+
+	return logsResponse.Data, nil
+}
+
+func (dashboardSource) Execute(activeSnapID string, action string) error {
+	response, err := postActiveCommand(activeSnapID, action)
+	if err != nil {
+		return err
+	}
+
+	return api.CheckStatus(response)
+}
@@ -0,0 +1,19 @@
+package cmd
+
+import "testing"
+
+func TestDashboard(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	runCommandTests(t, server, []commandTest{
+		{name: "dry run", args: []string{"dashboard", "--dry-run"}, status: 1, contains: []string{"the dashboard doesn't support --dry-run"}},
+		{name: "short interval", args: []string{"dashboard", "--interval", "500ms"}, status: 1, contains: []string{"interval must be at least 1s"}},
+		{name: "no terminal", args: []string{"dashboard"}, status: 1, contains: []string{"the dashboard requires a terminal"}},
+	})
+
+	// nothing was requested, since the dashboard never started
+	if requests := server.Requests(); len(requests) > 0 {
+		t.Errorf("requested %v", requests)
+	}
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/snapmaster-io/snap/pkg/api"
+	"github.com/snapmaster-io/snap/pkg/definition"
+	"github.com/snapmaster-io/snap/pkg/diff"
+	"github.com/snapmaster-io/snap/pkg/history"
+	"github.com/snapmaster-io/snap/pkg/utils"
+)
+
+// editText opens the file in the user's editor ($VISUAL, $EDITOR, or vi), waits for it to
+// exit, and returns the file's contents
+func editText(path string) (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+
+	// run the editor through the shell, so that it can include arguments (e.g. "code --wait")
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		if editor == "" {
+			editor = "notepad"
+		}
+		cmd = exec.Command("cmd", "/C", fmt.Sprintf("%s %s", editor, path))
+	} else {
+		if editor == "" {
+			editor = "vi"
+		}
+		cmd = exec.Command("sh", "-c", fmt.Sprintf(`%s "$1"`, editor), "sh", path)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %s failed: %s", editor, err)
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	return string(contents), err
+}
+
+// editSnapDefinition opens the snap's definition in the user's editor, and updates the snap
+// if it was changed.  If the edited definition isn't valid, the user can edit it again.  The
+// changes are left in the temporary file unless the update is made and verified, so that the
+// user can retry with snaps update.
+func editSnapDefinition(snapID string) {
+	text := getSnapDefinition(snapID)
+
+	file, err := ioutil.TempFile("", "snap-*.yaml")
+	if err != nil {
+		utils.PrintErrorMessage("could not create a temporary file", err)
+		os.Exit(1)
+	}
+	path := file.Name()
+	file.WriteString(text)
+	file.Close()
+
+	for {
+		edited, err := editText(path)
+		if err != nil {
+			utils.PrintErrorMessage("could not edit the snap definition", err)
+			os.Exit(1)
+		}
+		if edited == text {
+			os.Remove(path)
+			utils.PrintMessage(fmt.Sprintf("no changes to snap %s", snapID))
+			return
+		}
+
+		if err := validateSnapDefinition(snapID, edited); err != nil {
+			utils.PrintErrorMessage("the edited definition is not valid", err)
+			if promptYesNo("Edit it again?") {
+				continue
+			}
+			utils.PrintError(fmt.Sprintf("snap %s was not updated; the edited definition is in %s", snapID, path))
+			os.Exit(1)
+		}
+
+		printDefinitionDiff(snapID, text, edited)
+		if !confirmed(func() string {
+			return fmt.Sprintf("Update snap %s?", snapID)
+		}) {
+			utils.PrintError(fmt.Sprintf("snap %s was not updated; the edited definition is in %s", snapID, path))
+			os.Exit(1)
+		}
+		if err := updateSnapDefinition(snapID, text, edited, 0); err != nil {
+			utils.PrintErrorMessage(fmt.Sprintf("snap %s was not updated; the edited definition is in %s", snapID, path), err)
+			os.Exit(1)
+		}
+		os.Remove(path)
+		return
+	}
+}
+
+// validateSnapDefinition checks that the text is a valid definition for the snap, which
+// can't be renamed by an update
+func validateSnapDefinition(snapID string, text string) error {
+	snap, err := definition.Parse([]byte(text))
+	if err != nil {
+		return err
+	}
+	if err := snap.Validate(); err != nil {
+		return err
+	}
+
+	name := snapID[strings.LastIndex(snapID, "/")+1:]
+	if snap.Name != name {
+		return fmt.Errorf("name must stay %s (renaming a snap would orphan its activations)", name)
+	}
+
+	return nil
+}
+
+// printDefinitionDiff prints the changes from the current definition of the snap to the updated one
+func printDefinitionDiff(snapID string, current string, updated string) {
+	fmt.Print(diff.Colorize(diff.Unified(fmt.Sprintf("%s (current)", snapID), fmt.Sprintf("%s (updated)", snapID), current, updated)))
+}
+
+// errEditsUnsupported is returned for an in-place edit of a snap's definition that the
+// service doesn't support: it rejects the edit action, or accepts it without storing the
+// definition, as services that only edit a snap's visibility do
+var errEditsUnsupported = errors.New("the server doesn't support in-place edits of snap definitions; " +
+	"delete the snap and create it again instead (its activations must then be recreated)")
+
+// updateSnapDefinition replaces the previous definition of the snap, keeping its ID and
+// activations, and records the change in the local history.  rollback is the revision
+// restored by a rollback, or 0 for an edit.  The updated snap is printed once the service
+// is verified to have stored the definition; otherwise an error is returned.
+func updateSnapDefinition(snapID string, previous string, text string, rollback int) error {
+	change := history.Revision{Action: "edit", Text: text}
+	if rollback > 0 {
+		change.Action = "rollback"
+		change.From = rollback
+	}
+
+	response, err := postDefinitionEdit(snapID, previous, text, rollback)
+	if err == api.ErrDryRun {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	printSnapResponse("edit", response)
+	journalSnapRevision(response, change, previous)
+	return nil
+}
+
+// postDefinitionEdit posts the definition of the snap with the edit action (along with the
+// revision restored by a rollback, unless it is 0), and returns the response once the
+// service is verified to have stored the definition.
+//
+// Posting a definition with the edit action is a protocol change, which needs the server
+// side of the change: services that predate it reject the action, or reply success without
+// storing the definition.  Both are reported with errEditsUnsupported.
+func postDefinitionEdit(snapID string, previous string, text string, rollback int) ([]byte, error) {
+	data := make(map[string]interface{})
+	data["action"] = "edit"
+	data["snapId"] = snapID
+	data["definition"] = text
+	if rollback > 0 {
+		data["rollback"] = rollback
+	}
+
+	response, err := postSnapCommand(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := api.CheckStatus(response); err != nil {
+		if unsupportedAction.MatchString(err.Error()) {
+			return nil, errEditsUnsupported
+		}
+		return nil, err
+	}
+	if err := verifySnapDefinition(snapID, previous, text); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// verifySnapDefinition retrieves the definition of the snap after an edit, and returns an
+// error unless it is the posted text, so that an edit the service accepted without storing
+// it isn't reported (or journaled) as done
+func verifySnapDefinition(snapID string, previous string, text string) error {
+	stored, err := fetchSnapDefinition(snapID)
+	if err != nil {
+		return fmt.Errorf("could not verify the update of snap %s: %s", snapID, err)
+	}
+	if stored == previous && stored != text {
+		return errEditsUnsupported
+	}
+	if stored != text {
+		return fmt.Errorf("the definition of snap %s wasn't updated: the service returned success, but still has a different definition", snapID)
+	}
+
+	return nil
+}
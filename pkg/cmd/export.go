@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/snapmaster-io/snap/pkg/api"
+	"github.com/snapmaster-io/snap/pkg/bundle"
+	"github.com/snapmaster-io/snap/pkg/definition"
+	"github.com/snapmaster-io/snap/pkg/secrets"
+	"github.com/snapmaster-io/snap/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tidwall/gjson"
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the user's snaps, active snaps, connections, and profile into a bundle",
+	Long: `Export the user's snaps, active snaps, connections, and profile into a bundle: a
+gzipped tar archive that snap import recreates them from, in the same account or another
+context.
+
+The bundle holds the definition of each snap, the parameter values of each active snap,
+the connected tools along with the names of their credential sets (but not the
+credentials), and the profile.  Parameters of type secret or password, or named like a
+secret (token, key, password, ...), are redacted - unless --passphrase is set, in which
+case they are encrypted with it (AES-256-GCM with a key derived by scrypt).  Secret
+references (like vault://secret/data/ci#token) are kept as they are.`,
+	Example: `  snap export --out prod.tar.gz --context prod --passphrase env://BUNDLE_PASSPHRASE`,
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		out, _ := cmd.Flags().GetString("out")
+		useContextFlag(cmd)
+
+		b := bundle.New(viper.GetString("APIURL"), api.GetAccount())
+		var key []byte
+		if passphrase := getPassphrase(cmd); passphrase != "" {
+			var err error
+			if key, err = b.Key(passphrase); err != nil {
+				utils.PrintErrorMessage("could not derive the encryption key", err)
+				os.Exit(1)
+			}
+		}
+
+		// the definitions of the user's snaps
+		definitions := make(snapDefinitions)
+		for _, s := range getSnaps() {
+			text, err := fetchSnapDefinition(s.SnapID)
+			if err != nil {
+				utils.PrintErrorMessage("could not retrieve data", err)
+				os.Exit(1)
+			}
+			b.Snaps = append(b.Snaps, bundle.Snap{SnapID: s.SnapID, Private: s.Private, Text: text})
+			definitions[s.SnapID], _ = definition.Parse([]byte(text))
+		}
+
+		// the active snaps, with their parameter values
+		secretValues := 0
+		for _, a := range getActiveSnapParams() {
+			active := bundle.ActiveSnap{ActiveSnapID: a.ActiveSnapID, SnapID: a.SnapID, State: a.State, Params: []bundle.Param{}}
+			snap := definitions.get(a.SnapID)
+			for _, param := range a.Params {
+				p := bundle.Param{Name: param["name"], Value: param["value"]}
+				if isSecretParam(snap, p.Name) && !secrets.IsReference(p.Value) {
+					p.Secret = true
+					secretValues++
+					if key == nil {
+						p.Value = bundle.Redacted
+					} else if sealed, err := bundle.Seal(key, p.Value); err == nil {
+						p.Value = sealed
+					} else {
+						utils.PrintErrorMessage("could not encrypt a secret value", err)
+						os.Exit(1)
+					}
+				}
+				active.Params = append(active.Params, p)
+			}
+			b.ActiveSnaps = append(b.ActiveSnaps, active)
+		}
+
+		// the connected tools, and the names of their credential sets
+		tools, err := fetchTools()
+		if err != nil {
+			utils.PrintErrorMessage("could not retrieve data", err)
+			os.Exit(1)
+		}
+		for _, tool := range tools {
+			if tool.Connected == "" {
+				continue
+			}
+			// a connection whose credential sets can't be retrieved is exported without them,
+			// so that the import doesn't check for them
+			connection := bundle.Connection{Provider: tool.Provider}
+			response, err := api.Request("GET", fmt.Sprintf("/entities/%s", tool.Provider), nil)
+			if err == nil {
+				err = api.CheckStatus(response)
+			}
+			if err != nil {
+				utils.PrintWarning(fmt.Sprintf("could not retrieve the credential sets of %s, so exporting the connection without them: %s", tool.Provider, err))
+			} else {
+				for _, id := range gjson.GetBytes(response, "data.#.__id").Array() {
+					connection.CredentialSets = append(connection.CredentialSets, id.String())
+				}
+			}
+			b.Connections = append(b.Connections, connection)
+		}
+
+		b.Profile = api.GetProfile()
+
+		f, err := os.OpenFile(out, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+		if err == nil {
+			err = b.Write(f)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		if err != nil {
+			utils.PrintErrorMessage(fmt.Sprintf("could not write %s", out), err)
+			os.Exit(1)
+		}
+
+		summary := fmt.Sprintf("exported %s, %s, and %s of account %s to %s",
+			plural(len(b.Snaps), "snap"), plural(len(b.ActiveSnaps), "active snap"), plural(len(b.Connections), "connection"), b.Manifest.Account, out)
+		switch {
+		case secretValues > 0 && key == nil:
+			summary += fmt.Sprintf("; %s redacted (set --passphrase to encrypt secret values instead)", plural(secretValues, "secret value"))
+		case secretValues > 0:
+			summary += fmt.Sprintf("; %s encrypted", plural(secretValues, "secret value"))
+		}
+		utils.PrintMessage(summary)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringP("out", "", "bundle.tar.gz", "the bundle file to write")
+	exportCmd.Flags().StringP("context", "", "", "export from this saved context instead of the current configuration")
+	exportCmd.Flags().StringP("passphrase", "", "", "encrypt secret values with this passphrase (or secret reference) instead of redacting them")
+	exportCmd.RegisterFlagCompletionFunc("context", completeContexts)
+}
+
+// isSecretParam returns whether the value of a parameter is a secret: if the snap declares
+// it as a secret or password, or else if it is named like one.  The snap may be nil.
+func isSecretParam(snap *definition.Snap, name string) bool {
+	if snap != nil {
+		for _, p := range snap.Parameters {
+			if p.Name == name && definition.IsSecret(name, p.Type) {
+				return true
+			}
+		}
+	}
+
+	return definition.IsSecret(name, "")
+}
+
+// getPassphrase returns the command's --passphrase, resolving a secret reference
+func getPassphrase(cmd *cobra.Command) string {
+	passphrase, _ := cmd.Flags().GetString("passphrase")
+	resolved, err := secrets.Resolve(passphrase)
+	if err != nil {
+		utils.PrintErrorMessage("could not resolve the passphrase", err)
+		os.Exit(1)
+	}
+
+	return resolved
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/snapmaster-io/snap/pkg/bundle"
+)
+
+// readTestBundle reads the bundle in the file
+func readTestBundle(t *testing.T, file string) *bundle.Bundle {
+	t.Helper()
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	b, err := bundle.Read(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+func TestExportConnections(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+	runSnap(t, server, "ci\nci-user\npassword\n", "connections", "credential-set", "add", "docker").expect(t, 0)
+
+	// the credential sets of docker can't be retrieved, but the rest of the API works
+	target, _ := url.Parse(server.URL)
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/entities/docker" {
+			w.Write([]byte(`{"status": "error", "message": "upstream unavailable"}`))
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	}))
+	defer failing.Close()
+
+	tests := []struct {
+		name        string
+		apiURL      string
+		connections []bundle.Connection
+		contains    []string
+	}{
+		{
+			name:        "credential sets",
+			apiURL:      server.URL,
+			connections: []bundle.Connection{{Provider: "docker", CredentialSets: []string{"ci"}}, {Provider: "github"}},
+			contains:    []string{"exported 1 snap, 1 active snap, and 2 connections"},
+		},
+		{
+			name:        "credential sets not retrieved",
+			apiURL:      failing.URL,
+			connections: []bundle.Connection{{Provider: "docker"}, {Provider: "github"}},
+			contains:    []string{"could not retrieve the credential sets of docker", "upstream unavailable", "exported 1 snap, 1 active snap, and 2 connections"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out := writeTestFile(t, "bundle.tar.gz", "")
+			runSnapEnv(t, server, []string{"SNAP_APIURL=" + test.apiURL}, "", "export", "--out", out).expect(t, 0, test.contains...)
+
+			if b := readTestBundle(t, out); !reflect.DeepEqual(b.Connections, test.connections) {
+				t.Errorf("exported connections %+v, want %+v", b.Connections, test.connections)
+			}
+		})
+	}
+}
@@ -1,9 +1,15 @@
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/snapmaster-io/snap/pkg/api"
+	"github.com/snapmaster-io/snap/pkg/definition"
 	"github.com/snapmaster-io/snap/pkg/print"
 	"github.com/snapmaster-io/snap/pkg/utils"
 	"github.com/spf13/cobra"
@@ -20,6 +26,53 @@ var galleryCmd = &cobra.Command{
 	},
 }
 
+// getGallerySnapCmd represents the get gallery snap subcommand
+var getGallerySnapCmd = &cobra.Command{
+	Use:   "get [snap ID]",
+	Short: "Get a description of a snap in the gallery",
+	Long: `Get a description of a snap in the gallery.
+
+Shows the author and fork count of the snap, the tools it requires and whether
+each of them is connected, followed by the snap definition.`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeGallerySnapIDs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// retrieve snapID as the first argument
+		snapID := args[0]
+		path := fmt.Sprintf("/snaps/%s", snapID)
+
+		// execute the API call
+		response, err := api.Get(path)
+		if err != nil {
+			utils.PrintErrorMessage("could not retrieve data", err)
+			os.Exit(1)
+		}
+
+		format, err := rootCmd.PersistentFlags().GetString("format")
+		if format == "json" {
+			print.JSON(response)
+			return
+		}
+
+		var snapResponse print.GallerySnapResponse
+		json.Unmarshal(response, &snapResponse)
+		if snapResponse.Status != "success" {
+			utils.PrintStatus(snapResponse.Status, snapResponse.Message)
+			os.Exit(1)
+		}
+
+		// parse the definition to find the tools the snap requires
+		snap, err := definition.Parse([]byte(snapResponse.Data.Text))
+		if err != nil {
+			utils.PrintErrorMessage(fmt.Sprintf("could not parse the definition of snap %s", snapID), err)
+			os.Exit(1)
+		}
+
+		tools := getRequiredTools(snap, getConnectedTools())
+		print.GallerySnapTable(response, tools)
+	},
+}
+
 // listGalleryCmd represents the list gallery subcommand
 var listGalleryCmd = &cobra.Command{
 	Use:   "list",
@@ -50,8 +103,226 @@ var listGalleryCmd = &cobra.Command{
 	},
 }
 
+// searchGalleryCmd represents the search gallery subcommand
+var searchGalleryCmd = &cobra.Command{
+	Use:   "search [query]",
+	Short: "Search the snaps in the gallery",
+	Long: `Search the snaps in the gallery.
+
+Results are ranked by how well each query term matches the snap ID, description,
+and definition text of a snap.  Every term must match for a snap to be returned.
+
+The --trigger, --uses, and --author flags narrow the results to snaps triggered
+by a provider, snaps with an action that uses a provider, and snaps published by
+an account.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		query := strings.Join(args, " ")
+		trigger, _ := cmd.Flags().GetString("trigger")
+		uses, _ := cmd.Flags().GetString("uses")
+		author, _ := cmd.Flags().GetString("author")
+
+		// execute the API call
+		response, err := api.Get("/gallery")
+		if err != nil {
+			utils.PrintErrorMessage("could not retrieve data", err)
+			os.Exit(1)
+		}
+
+		var galleryResponse print.GalleryResponse
+		json.Unmarshal(response, &galleryResponse)
+		if galleryResponse.Status == "error" {
+			utils.PrintStatus(galleryResponse.Status, galleryResponse.Message)
+			os.Exit(1)
+		}
+
+		results, skipped := searchGallery(galleryResponse.Data, query, trigger, uses, author, fetchSnapDefinition)
+
+		format, err := rootCmd.PersistentFlags().GetString("format")
+		if len(skipped) > 0 && format != "json" {
+			utils.PrintError(fmt.Sprintf("skipped %s whose definitions could not be retrieved: %s", plural(len(skipped), "snap"), strings.Join(skipped, ", ")))
+		}
+		if format == "json" {
+			snaps := make([]print.GallerySnap, len(results))
+			for i, result := range results {
+				snaps[i] = result.Snap
+			}
+			payload, _ := json.Marshal(snaps)
+			print.JSON(payload)
+			return
+		}
+
+		print.GallerySearchTable(results, query)
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(galleryCmd)
+	galleryCmd.AddCommand(getGallerySnapCmd)
 	galleryCmd.AddCommand(listGalleryCmd)
-	galleryCmd.AddCommand(getSnapCmd)
+	galleryCmd.AddCommand(searchGalleryCmd)
+
+	searchGalleryCmd.Flags().StringP("trigger", "", "", "only return snaps triggered by this provider")
+	searchGalleryCmd.Flags().StringP("uses", "", "", "only return snaps with an action that uses this provider")
+	searchGalleryCmd.Flags().StringP("author", "", "", "only return snaps published by this account")
+	searchGalleryCmd.RegisterFlagCompletionFunc("trigger", completeTools)
+	searchGalleryCmd.RegisterFlagCompletionFunc("uses", completeTools)
+
+	pickable(getGallerySnapCmd, false, gallerySnapIDPicker)
+}
+
+// how many definitions of gallery snaps are retrieved at the same time
+const galleryFetchConcurrency = 8
+
+// searchGallery filters the gallery snaps and ranks them against the query.  The
+// definitions that the gallery doesn't include are retrieved with fetch, in parallel; the
+// snaps whose definitions can't be retrieved are left out, and their IDs returned.
+func searchGallery(snaps []print.GallerySnap, query string, trigger string, uses string, author string, fetch func(snapID string) (string, error)) ([]print.GallerySearchResult, []string) {
+	terms := strings.Fields(strings.ToLower(query))
+
+	// the definition is only needed if the query or the filters look inside it
+	needsDefinition := func(snap print.GallerySnap) bool {
+		return len(terms) > 0 || uses != "" || (trigger != "" && snap.Provider == "")
+	}
+	var candidates []print.GallerySnap
+	var missing []string
+	for _, snap := range snaps {
+		if author != "" && !strings.EqualFold(snap.Author(), author) {
+			continue
+		}
+		if needsDefinition(snap) && snap.Text == "" {
+			missing = append(missing, snap.SnapID)
+		}
+		candidates = append(candidates, snap)
+	}
+	texts, skipped := fetchGalleryDefinitions(missing, fetch)
+
+	var results []print.GallerySearchResult
+	for _, snap := range candidates {
+		var def *definition.Snap
+		if needsDefinition(snap) {
+			if snap.Text == "" {
+				text, ok := texts[snap.SnapID]
+				if !ok {
+					continue
+				}
+				snap.Text = text
+			}
+			def, _ = definition.Parse([]byte(snap.Text))
+		}
+
+		if trigger != "" {
+			provider := snap.Provider
+			if provider == "" && def != nil {
+				provider = def.TriggerProvider()
+			}
+			if !strings.EqualFold(provider, trigger) {
+				continue
+			}
+		}
+
+		if uses != "" && (def == nil || !containsFold(def.ActionProviders(), uses)) {
+			continue
+		}
+
+		score := scoreSnap(snap, terms)
+		if len(terms) > 0 && score == 0 {
+			continue
+		}
+
+		results = append(results, print.GallerySearchResult{Snap: snap, Score: score})
+	}
+
+	// sort by descending score, and then by snap ID
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Snap.SnapID < results[j].Snap.SnapID
+	})
+
+	return results, skipped
+}
+
+// fetchGalleryDefinitions retrieves the definitions of the snaps with a bounded pool of
+// workers, and returns them by snap ID, along with the sorted IDs of the snaps whose
+// definitions couldn't be retrieved
+func fetchGalleryDefinitions(snapIDs []string, fetch func(snapID string) (string, error)) (map[string]string, []string) {
+	texts := make(map[string]string)
+	var failed []string
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	for w := 0; w < galleryFetchConcurrency && w < len(snapIDs); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for snapID := range jobs {
+				text, err := fetch(snapID)
+				mu.Lock()
+				if err != nil {
+					failed = append(failed, snapID)
+				} else {
+					texts[snapID] = text
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, snapID := range snapIDs {
+		jobs <- snapID
+	}
+	close(jobs)
+	wg.Wait()
+	sort.Strings(failed)
+
+	return texts, failed
+}
+
+// scoreSnap ranks a snap against the query terms, weighting matches in the snap ID
+// above matches in the description, and those above matches in the definition text.
+// A snap that doesn't match every term scores zero.
+func scoreSnap(snap print.GallerySnap, terms []string) int {
+	snapID := strings.ToLower(snap.SnapID)
+	name := snapID[strings.LastIndex(snapID, "/")+1:]
+	description := strings.ToLower(snap.Description)
+	text := strings.ToLower(snap.Text)
+
+	score := 0
+	for _, term := range terms {
+		termScore := 0
+		if name == term {
+			termScore += 10
+		} else if strings.Contains(snapID, term) {
+			termScore += 5
+		}
+		if strings.Contains(description, term) {
+			termScore += 3
+		}
+
+		// count occurrences in the definition, but don't let a long definition dominate
+		occurrences := strings.Count(text, term)
+		if occurrences > 3 {
+			occurrences = 3
+		}
+		termScore += occurrences
+
+		if termScore == 0 {
+			return 0
+		}
+		score += termScore
+	}
+
+	return score
+}
+
+// containsFold returns whether the value is in the slice, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
 }
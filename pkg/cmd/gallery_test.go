@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/snapmaster-io/snap/pkg/print"
+)
+
+func TestSearchGalleryFetches(t *testing.T) {
+	var snaps []print.GallerySnap
+	for i := 0; i < 3*galleryFetchConcurrency; i++ {
+		snaps = append(snaps, print.GallerySnap{SnapID: fmt.Sprintf("acct/snap-%02d", i)})
+	}
+	// a definition the gallery includes isn't fetched
+	snaps = append(snaps, print.GallerySnap{SnapID: "other/included", Text: testSnapDeploy})
+
+	var mu sync.Mutex
+	inFlight, maxInFlight, fetches := 0, 0, 0
+	fetch := func(snapID string) (string, error) {
+		mu.Lock()
+		inFlight++
+		fetches++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		if snapID == "acct/snap-03" || snapID == "acct/snap-07" {
+			return "", errors.New("not found")
+		}
+		return testSnapDeploy, nil
+	}
+
+	results, skipped := searchGallery(snaps, "gke", "", "docker", "", fetch)
+	if fetches != 3*galleryFetchConcurrency {
+		t.Errorf("%d fetches, want %d", fetches, 3*galleryFetchConcurrency)
+	}
+	if maxInFlight > galleryFetchConcurrency {
+		t.Errorf("%d fetches at the same time, want at most %d", maxInFlight, galleryFetchConcurrency)
+	}
+	if want := []string{"acct/snap-03", "acct/snap-07"}; !reflect.DeepEqual(skipped, want) {
+		t.Errorf("skipped %q, want %q", skipped, want)
+	}
+	if len(results) != len(snaps)-2 {
+		t.Errorf("%d results, want %d", len(results), len(snaps)-2)
+	}
+
+	// the author filter applies before fetching, and no definition is needed to list snaps
+	fetches = 0
+	results, _ = searchGallery(snaps, "", "", "", "other", fetch)
+	if fetches != 0 || len(results) != 1 {
+		t.Errorf("%d fetches and %d results, want none and 1", fetches, len(results))
+	}
+}
+
+const testSnapPublic = `name: image-build
+description: build an image on every push
+trigger: github
+actions:
+  - name: build
+    provider: docker
+    action: build
+    image: app
+`
+
+func TestGallery(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+	if _, err := server.AddSnap("community/image-build", testSnapPublic, false); err != nil {
+		t.Fatal(err)
+	}
+
+	runCommandTests(t, server, []commandTest{
+		{name: "list", args: []string{"gallery", "list"}, contains: []string{"community/image-build", "build an image on every push"}},
+		{
+			name:     "get",
+			args:     []string{"gallery", "get", "community/image-build"},
+			contains: []string{"Snap community/image-build", "Required tools", "build:build", "name: image-build"},
+		},
+		{name: "get json", args: []string{"gallery", "get", "community/image-build", "--format", "json"}, contains: []string{`"snapId": "community/image-build"`}},
+		{name: "get missing", args: []string{"gallery", "get", "community/missing"}, status: 1, contains: []string{"snap community/missing not found"}},
+		{name: "search", args: []string{"gallery", "search", "image"}, contains: []string{"Gallery snaps matching 'image'", "community/image-build"}},
+		{name: "search by tool", args: []string{"gallery", "search", "--uses", "docker", "--author", "community"}, contains: []string{"community/image-build"}},
+		{name: "search json", args: []string{"gallery", "search", "push", "--format", "json"}, contains: []string{`"snapId": "community/image-build"`}},
+	})
+
+	// private snaps aren't in the gallery
+	r := runSnap(t, server, "", "gallery", "search", "gke")
+	r.expect(t, 0)
+	if strings.Contains(r.stdout, "snaptest/gke-deploy") {
+		t.Errorf("search found a private snap:\n%s", r.stdout)
+	}
+}
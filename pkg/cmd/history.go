@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/snapmaster-io/snap/pkg/api"
+	"github.com/snapmaster-io/snap/pkg/history"
+	"github.com/snapmaster-io/snap/pkg/print"
+	"github.com/snapmaster-io/snap/pkg/utils"
+	"github.com/spf13/viper"
+)
+
+// the account of the logged in user, retrieved once
+var journalAccount string
+
+// snapJournal opens the local journal of a snap in the logged in user's account
+func snapJournal(snapID string) (*history.Journal, error) {
+	if journalAccount == "" {
+		journalAccount = api.GetAccount()
+	}
+	account := journalAccount
+	if account == "" {
+		account = strings.SplitN(snapID, "/", 2)[0]
+	}
+
+	return history.Open(account, snapID)
+}
+
+// fetchSnapHistory returns the revisions of a snap, oldest first, from the server if it
+// keeps a version history, or else from the local journal, and whether they came from the server
+func fetchSnapHistory(snapID string) ([]history.Revision, bool, error) {
+	// servers without a version history don't have the history path
+	response, err := api.Request("GET", fmt.Sprintf("/snaps/%s/history", snapID), nil)
+	if err == nil {
+		var historyResponse print.SnapHistoryResponse
+		if json.Unmarshal(response, &historyResponse) == nil && historyResponse.Status == "success" {
+			return historyResponse.Data, true, nil
+		}
+	}
+
+	journal, err := snapJournal(snapID)
+	if err != nil {
+		return nil, false, err
+	}
+	revisions, err := journal.Revisions()
+	return revisions, false, err
+}
+
+// getSnapRevision returns a revision of a snap with its text, from the server if it keeps a
+// version history, or else from the local journal.  A revision of 0 is the latest revision.
+func getSnapRevision(snapID string, revision int) *history.Revision {
+	revisions, server, err := fetchSnapHistory(snapID)
+	if err != nil {
+		utils.PrintErrorMessage(fmt.Sprintf("could not retrieve the history of snap %s", snapID), err)
+		os.Exit(1)
+	}
+	if len(revisions) == 0 {
+		utils.PrintError(fmt.Sprintf("snap %s has no history; revisions are recorded when it is created, updated, or forked from the CLI", snapID))
+		os.Exit(1)
+	}
+	if revision == 0 {
+		revision = revisions[len(revisions)-1].Revision
+	}
+
+	if !server {
+		journal, err := snapJournal(snapID)
+		if err == nil {
+			var r *history.Revision
+			if r, err = journal.Get(revision); err == nil {
+				return r
+			}
+		}
+		if err == history.ErrNotFound {
+			utils.PrintError(fmt.Sprintf("snap %s has no revision %d", snapID, revision))
+		} else {
+			utils.PrintErrorMessage(fmt.Sprintf("could not read revision %d of snap %s", revision, snapID), err)
+		}
+		os.Exit(1)
+	}
+
+	// execute the API call
+	response, err := api.Get(fmt.Sprintf("/snaps/%s/history/%d", snapID, revision))
+	if err != nil {
+		utils.PrintErrorMessage("could not retrieve data", err)
+		os.Exit(1)
+	}
+	var revisionResponse print.SnapRevisionResponse
+	json.Unmarshal(response, &revisionResponse)
+	if revisionResponse.Status != "success" {
+		utils.PrintStatus(revisionResponse.Status, revisionResponse.Message)
+		os.Exit(1)
+	}
+
+	return &revisionResponse.Data
+}
+
+// parseSnapRevision splits a snapId@revision argument; the revision is 0 if it is omitted
+func parseSnapRevision(arg string) (string, int, error) {
+	i := strings.LastIndex(arg, "@")
+	if i < 0 {
+		return arg, 0, nil
+	}
+
+	revision, err := strconv.Atoi(arg[i+1:])
+	if err != nil || revision < 1 {
+		return "", 0, fmt.Errorf("invalid revision %q (must be a positive number)", arg[i+1:])
+	}
+	return arg[:i], revision, nil
+}
+
+// journalSnapRevision records a change to a snap in the local journal, unless the server
+// keeps a version history (in which case the snap in the response has a revision).  The
+// text of the change is retrieved if it isn't set.  If the previous definition is known
+// and isn't the latest revision in the journal, the snap was changed outside the CLI, and
+// the previous definition is recorded first.
+func journalSnapRevision(response []byte, change history.Revision, previous string) {
+	var snapResponse print.SnapResponse
+	if json.Unmarshal(response, &snapResponse) != nil || snapResponse.Status != "success" || snapResponse.Data.Revision > 0 {
+		return
+	}
+
+	snapID := snapResponse.Data.SnapID
+	if snapID == "" {
+		return
+	}
+	err := func() error {
+		if change.Text == "" {
+			text, err := fetchSnapDefinition(snapID)
+			if err != nil {
+				return err
+			}
+			change.Text = text
+		}
+
+		journal, err := snapJournal(snapID)
+		if err != nil {
+			return err
+		}
+		if previous != "" {
+			latest, err := journal.Latest()
+			if err != nil {
+				return err
+			}
+			if latest == nil || latest.Text != previous {
+				if _, err := journal.Append(history.Revision{Action: "observed", Text: previous}); err != nil {
+					return err
+				}
+			}
+		}
+
+		change.User = viper.GetString("Email")
+		_, err = journal.Append(change)
+		return err
+	}()
+	if err != nil {
+		utils.PrintErrorMessage(fmt.Sprintf("could not record the change to snap %s in the local history", snapID), err)
+	}
+}
+
+// journalActivation records the revision of its snap an active snap was activated with in
+// the local journal, unless the server reports it, and returns the response with the revision
+func journalActivation(response []byte) []byte {
+	var activeSnapResponse print.ActiveSnapResponse
+	if json.Unmarshal(response, &activeSnapResponse) != nil || activeSnapResponse.Status != "success" {
+		return response
+	}
+	active := activeSnapResponse.Data
+	if active.SnapRevision > 0 || active.ActiveSnapID == "" || active.SnapID == "" {
+		return response
+	}
+
+	revision, err := func() (int, error) {
+		journal, err := snapJournal(active.SnapID)
+		if err != nil {
+			return 0, err
+		}
+
+		// the definition may have changed outside the CLI since the latest revision
+		text, err := fetchSnapDefinition(active.SnapID)
+		if err != nil {
+			return 0, err
+		}
+		latest, err := journal.Append(history.Revision{Action: "observed", Text: text})
+		if err != nil {
+			return 0, err
+		}
+
+		return latest.Revision, journal.SetActivation(active.ActiveSnapID, latest.Revision)
+	}()
+	if err != nil {
+		utils.PrintErrorMessage(fmt.Sprintf("could not record the revision of active snap %s in the local history", active.ActiveSnapID), err)
+		return response
+	}
+
+	return setSnapRevisions(response, func(string, string) int { return revision })
+}
+
+// addSnapRevisions adds the revision of its snap each active snap runs from the local
+// journal to an active snap or active snaps response, where the server doesn't report it
+func addSnapRevisions(response []byte) []byte {
+	journals := make(map[string]*history.Journal)
+	return setSnapRevisions(response, func(snapID string, activeSnapID string) int {
+		journal, ok := journals[snapID]
+		if !ok {
+			journal, _ = snapJournal(snapID)
+			journals[snapID] = journal
+		}
+		if journal == nil {
+			return 0
+		}
+		return journal.Activation(activeSnapID)
+	})
+}
+
+// setSnapRevisions sets the snapRevision of the active snaps in the data of the response
+// that don't have one to the revision returned by the function
+func setSnapRevisions(response []byte, revision func(snapID string, activeSnapID string) int) []byte {
+	var body map[string]interface{}
+	if json.Unmarshal(response, &body) != nil {
+		return response
+	}
+
+	set := func(value interface{}) {
+		active, ok := value.(map[string]interface{})
+		if !ok || active["snapRevision"] != nil {
+			return
+		}
+		snapID, _ := active["snapId"].(string)
+		if snapID == "" {
+			snapID, _ = active["snapID"].(string)
+		}
+		activeSnapID, _ := active["activeSnapId"].(string)
+		if r := revision(snapID, activeSnapID); r > 0 {
+			active["snapRevision"] = r
+		}
+	}
+	if list, ok := body["data"].([]interface{}); ok {
+		for _, value := range list {
+			set(value)
+		}
+	} else {
+		set(body["data"])
+	}
+
+	annotated, err := json.Marshal(body)
+	if err != nil {
+		return response
+	}
+	return annotated
+}
+
+// activeSnapsByRevision returns the IDs of the active snaps of a snap, by the revision they run
+func activeSnapsByRevision(snapID string) map[int][]string {
+	response, err := api.Get("/activesnaps")
+	if err != nil {
+		utils.PrintErrorMessage("could not retrieve data", err)
+		os.Exit(1)
+	}
+
+	var activeSnapsResponse print.ActiveSnapsResponse
+	json.Unmarshal(addSnapRevisions(response), &activeSnapsResponse)
+
+	byRevision := make(map[int][]string)
+	for _, a := range activeSnapsResponse.Data {
+		if a.SnapID == snapID && a.SnapRevision > 0 {
+			byRevision[a.SnapRevision] = append(byRevision[a.SnapRevision], a.ActiveSnapID)
+		}
+	}
+	return byRevision
+}
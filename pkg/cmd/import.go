@@ -0,0 +1,585 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/snapmaster-io/snap/pkg/api"
+	"github.com/snapmaster-io/snap/pkg/bundle"
+	"github.com/snapmaster-io/snap/pkg/definition"
+	"github.com/snapmaster-io/snap/pkg/history"
+	"github.com/snapmaster-io/snap/pkg/print"
+	"github.com/snapmaster-io/snap/pkg/secrets"
+	"github.com/snapmaster-io/snap/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tidwall/gjson"
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import [bundle.tar.gz]",
+	Short: "Recreate the snaps and active snaps of an export bundle",
+	Long: `Recreate the snaps and active snaps of a bundle written by snap export, in the current
+configuration or in the saved context named by --context.
+
+The import runs in order:
+  1. connections: the tools used by the bundle's snaps must be connected, with the
+     credential sets they had when the bundle was exported (connect them first with
+     snap connect; credentials aren't part of a bundle)
+  2. snaps: each snap is created in the account; a snap that already exists with the
+     same definition is left alone, and one with a different definition is a conflict,
+     unless --overwrite is set
+  3. activations: each active snap is activated with its parameter values, and paused
+     if it was paused; an active snap of the same snap with the same values is left
+     alone, and one with different values is a conflict, unless --overwrite is set
+
+Active snaps whose tools aren't connected, or whose secret values were redacted (or are
+encrypted, and --passphrase isn't set), are skipped.  A bundle with parameter values that
+refer to local secrets (cmd://, file://, or env://) is refused unless
+--allow-local-references is set, since resolving them runs commands or reads files on
+this machine.  With --dry-run, the requests are printed instead of sent, with secret
+values redacted.  The command prints the outcome of each step, and exits with status 1
+if any step failed or had a conflict.`,
+	Example: `  snap import prod.tar.gz --context staging --passphrase env://BUNDLE_PASSPHRASE --dry-run`,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		file := args[0]
+		overwrite, _ := cmd.Flags().GetBool("overwrite")
+		dryRun, _ := rootCmd.PersistentFlags().GetBool("dry-run")
+
+		f, err := os.Open(file)
+		if err != nil {
+			utils.PrintErrorMessage(fmt.Sprintf("could not read %s", file), err)
+			os.Exit(1)
+		}
+		b, err := bundle.Read(f)
+		f.Close()
+		if err != nil {
+			utils.PrintErrorMessage(fmt.Sprintf("could not read %s", file), err)
+			os.Exit(1)
+		}
+
+		i := &importation{bundle: b, overwrite: overwrite, dryRun: dryRun}
+		if passphrase := getPassphrase(cmd); passphrase != "" && b.Sealed() {
+			if i.key, err = b.Key(passphrase); err == nil {
+				err = i.checkKey()
+			}
+			if err != nil {
+				utils.PrintErrorMessage(fmt.Sprintf("could not decrypt the secret values of %s", file), err)
+				os.Exit(1)
+			}
+		}
+
+		// references to local secrets would run commands or read files and environment
+		// variables on this machine, as whoever wrote the bundle chose
+		if allow, _ := cmd.Flags().GetBool("allow-local-references"); !allow {
+			if local := localReferences(b, i.key); len(local) > 0 {
+				utils.PrintError(fmt.Sprintf("%s has parameter values that refer to local secrets (%s); set --allow-local-references to resolve them on this machine",
+					file, strings.Join(local, ", ")))
+				os.Exit(1)
+			}
+		}
+
+		useContextFlag(cmd)
+		i.account = api.GetAccount()
+		utils.PrintMessage(fmt.Sprintf("importing %s and %s of account %s (%s) into account %s (%s)",
+			plural(len(b.Snaps), "snap"), plural(len(b.ActiveSnaps), "active snap"),
+			b.Manifest.Account, b.Manifest.APIURL, i.account, viper.GetString("APIURL")))
+
+		i.run()
+
+		fmt.Println()
+		print.StepResultsTable(fmt.Sprintf("Import %s into %s", file, i.account), i.results)
+		if i.failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.Flags().StringP("context", "", "", "import into this saved context instead of the current configuration")
+	importCmd.Flags().BoolP("overwrite", "", false, "replace snap definitions and active snap parameter values that differ from the bundle")
+	importCmd.Flags().StringP("passphrase", "", "", "the passphrase (or secret reference) the bundle's secret values were encrypted with")
+	importCmd.Flags().BoolP("allow-local-references", "", false, "resolve cmd://, file://, and env:// secret references in the bundle's parameter values")
+	importCmd.RegisterFlagCompletionFunc("context", completeContexts)
+}
+
+// localSecretSchemes are the schemes of secret references that are resolved on the local
+// machine, by running a command or reading a file or environment variable
+var localSecretSchemes = map[string]bool{"cmd": true, "file": true, "env": true}
+
+// localReferences returns the active snap parameters of the bundle whose values are
+// references to local secrets, as "activeSnapID.name", checking the sealed values that
+// the key (which may be nil) opens too
+func localReferences(b *bundle.Bundle, key []byte) []string {
+	var local []string
+	for _, a := range b.ActiveSnaps {
+		for _, p := range a.Params {
+			value := p.Value
+			if bundle.IsSealed(value) && key != nil {
+				value, _ = bundle.Open(key, value)
+			}
+			if localSecretSchemes[secrets.Scheme(value)] {
+				local = append(local, fmt.Sprintf("%s.%s", a.ActiveSnapID, p.Name))
+			}
+		}
+	}
+
+	return local
+}
+
+// stepOutcomes tracks the outcome of each step of a multi-step operation that keeps going
+// after a step fails; failed is set if any step failed or had a conflict
+type stepOutcomes struct {
+	results []print.StepResult
+	failed  bool
+}
+
+func (o *stepOutcomes) add(step string, state string, detail string) {
+	o.results = append(o.results, print.StepResult{Step: step, State: state, Detail: detail})
+	if state == print.StepFailed || state == print.StepConflict {
+		o.failed = true
+	}
+}
+
+func (o *stepOutcomes) fail(step string, err error) {
+	if err == api.ErrDryRun {
+		o.add(step, print.StepDryRun, "request not sent")
+		return
+	}
+	o.add(step, print.StepFailed, err.Error())
+}
+
+// importation tracks the outcome of each step of importing a bundle
+type importation struct {
+	stepOutcomes
+	bundle    *bundle.Bundle
+	key       []byte
+	overwrite bool
+	dryRun    bool
+	account   string
+
+	// the tools that are connected with the bundle's credential sets
+	ready map[string]bool
+	// the snap ID in the account of each of the bundle's snaps that was imported
+	imported map[string]string
+	// the definition of each of the bundle's snaps
+	definitions snapDefinitions
+	// the definitions of other accounts' snaps that the bundle's active snaps activate
+	external snapDefinitions
+}
+
+// checkKey opens a sealed value of the bundle to check that the key is right
+func (i *importation) checkKey() error {
+	for _, a := range i.bundle.ActiveSnaps {
+		for _, p := range a.Params {
+			if bundle.IsSealed(p.Value) {
+				_, err := bundle.Open(i.key, p.Value)
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// run executes the connections, snaps, and activations steps
+func (i *importation) run() {
+	i.definitions = make(snapDefinitions)
+	i.external = make(snapDefinitions)
+	for _, s := range i.bundle.Snaps {
+		i.definitions[s.SnapID], _ = definition.Parse([]byte(s.Text))
+	}
+
+	// check that the tools the snaps and active snaps use are connected, along with any
+	// others the exported account had connected
+	usedBy := make(map[string][]string)
+	for _, s := range i.bundle.Snaps {
+		if snap := i.definitions[s.SnapID]; snap != nil {
+			for _, provider := range snap.Tools() {
+				usedBy[provider] = append(usedBy[provider], bundle.Name(s.SnapID))
+			}
+		}
+	}
+	for _, a := range i.bundle.ActiveSnaps {
+		// active snaps of other accounts' snaps, like gallery snaps, use the tools of those snaps
+		_, inBundle := i.definitions[a.SnapID]
+		_, counted := i.external[a.SnapID]
+		if inBundle || counted {
+			continue
+		}
+		if snap := i.external.get(a.SnapID); snap != nil {
+			for _, provider := range snap.Tools() {
+				usedBy[provider] = append(usedBy[provider], a.SnapID)
+			}
+		}
+	}
+	credentialSets := make(map[string][]string)
+	for _, c := range i.bundle.Connections {
+		credentialSets[c.Provider] = c.CredentialSets
+		if _, used := usedBy[c.Provider]; !used {
+			usedBy[c.Provider] = nil
+		}
+	}
+	results, ready, err := checkConnections(usedBy, credentialSets)
+	if err != nil {
+		utils.PrintErrorMessage("could not retrieve data", err)
+		os.Exit(1)
+	}
+	for _, result := range results {
+		i.add(result.Step, result.State, result.Detail)
+	}
+	i.ready = ready
+
+	i.importSnaps()
+	i.importActiveSnaps()
+}
+
+// importSnaps creates (or updates, with --overwrite) each of the bundle's snaps
+func (i *importation) importSnaps() {
+	existing := make(map[string]print.Snap)
+	for _, s := range getSnaps() {
+		existing[s.SnapID] = s
+	}
+
+	i.imported = make(map[string]string)
+	for _, s := range i.bundle.Snaps {
+		snapID := fmt.Sprintf("%s/%s", i.account, bundle.Name(s.SnapID))
+		step := fmt.Sprintf("snap %s", snapID)
+		target, found := existing[snapID]
+
+		var detail string
+		switch {
+		case !found:
+			data := map[string]interface{}{"action": "create", "definition": s.Text}
+			response, err := postSnapCommand(data)
+			if err == nil {
+				err = api.CheckStatus(response)
+			}
+			if err != nil {
+				if err == api.ErrDryRun {
+					i.imported[s.SnapID] = snapID
+				}
+				i.fail(step, err)
+				continue
+			}
+			journalSnapRevision(response, history.Revision{Action: "create", Text: s.Text}, "")
+			detail = "created"
+		default:
+			current, err := fetchSnapDefinition(snapID)
+			if err != nil {
+				i.fail(step, err)
+				continue
+			}
+			if current == s.Text {
+				detail = "unchanged"
+				break
+			}
+			if !i.overwrite {
+				i.add(step, print.StepConflict, "the definition differs from the bundle's; set --overwrite to replace it")
+				continue
+			}
+			response, err := postDefinitionEdit(snapID, current, s.Text, 0)
+			if err != nil {
+				if err == api.ErrDryRun {
+					i.imported[s.SnapID] = snapID
+				}
+				i.fail(step, err)
+				continue
+			}
+			journalSnapRevision(response, history.Revision{Action: "edit", Text: s.Text}, current)
+			detail = "updated"
+		}
+		i.imported[s.SnapID] = snapID
+
+		// publish the snap if it was public
+		if !s.Private && (!found || target.Private) {
+			data := map[string]interface{}{"action": "edit", "snapId": snapID, "private": false}
+			response, err := postSnapCommand(data)
+			if err == nil {
+				err = api.CheckStatus(response)
+			}
+			if err != nil {
+				i.fail(step, fmt.Errorf("%s, but could not publish it: %s", detail, err))
+				continue
+			}
+			detail += ", published"
+		}
+		i.add(step, print.StepDone, detail)
+	}
+}
+
+// importActiveSnaps activates each of the bundle's active snaps, unless the account already
+// has an active snap of the same snap with the same parameter values
+func (i *importation) importActiveSnaps() {
+	// the account's active snaps, by snap ID, which each match at most one of the bundle's
+	existing := make(map[string][]activeSnapParams)
+	for _, a := range getActiveSnapParams() {
+		existing[a.SnapID] = append(existing[a.SnapID], a)
+	}
+
+	for _, a := range i.bundle.ActiveSnaps {
+		// active snaps of the bundle's snaps are activated with the imported snap, and active
+		// snaps of other accounts' snaps with the same snap
+		snapID := a.SnapID
+		_, inBundle := i.definitions[a.SnapID]
+		if inBundle {
+			snapID = i.imported[a.SnapID]
+		}
+		step := fmt.Sprintf("activate %s", a.ActiveSnapID)
+		if snapID == "" {
+			i.add(step, print.StepSkipped, fmt.Sprintf("snap %s was not imported", a.SnapID))
+			continue
+		}
+
+		snap := i.definitions[a.SnapID]
+		if !inBundle {
+			snap = i.external.get(snapID)
+		}
+		if missing := i.unready(snap); len(missing) > 0 {
+			i.add(step, print.StepSkipped, fmt.Sprintf("not connected: %s", strings.Join(missing, ", ")))
+			continue
+		}
+
+		params, err := i.params(a)
+		if err != nil {
+			i.add(step, print.StepSkipped, err.Error())
+			continue
+		}
+
+		// an active snap of the same snap with the same values is already imported
+		candidates := existing[snapID]
+		match, differs := matchActiveSnap(candidates, params)
+		if match != nil {
+			existing[snapID] = removeActiveSnap(candidates, match.ActiveSnapID)
+			i.add(step, print.StepDone, fmt.Sprintf("unchanged: already active as %s", match.ActiveSnapID))
+			continue
+		}
+		if differs != nil {
+			existing[snapID] = removeActiveSnap(candidates, differs.ActiveSnapID)
+			if !i.overwrite {
+				i.add(step, print.StepConflict, fmt.Sprintf("%s is active as %s with different parameter values; set --overwrite to replace them", snapID, differs.ActiveSnapID))
+				continue
+			}
+			response, err := postActivation(differs.ActiveSnapID, "edit", i.requestParams(a, params))
+			if err == nil {
+				err = api.CheckStatus(response)
+			}
+			if err != nil {
+				i.fail(step, err)
+				continue
+			}
+			i.add(step, print.StepDone, fmt.Sprintf("updated the parameter values of %s", differs.ActiveSnapID))
+			continue
+		}
+
+		response, err := postActivation(snapID, "activate", i.requestParams(a, params))
+		if err == nil {
+			err = api.CheckStatus(response)
+		}
+		if err != nil {
+			i.fail(step, err)
+			continue
+		}
+		response = journalActivation(response)
+		var activeSnapResponse print.ActiveSnapResponse
+		json.Unmarshal(response, &activeSnapResponse)
+		activeSnapID := activeSnapResponse.Data.ActiveSnapID
+		detail := fmt.Sprintf("activated %s as %s", snapID, activeSnapID)
+
+		if a.State == "paused" {
+			response, err := postActiveCommand(activeSnapID, "pause")
+			if err == nil {
+				err = api.CheckStatus(response)
+			}
+			if err != nil {
+				i.fail(step, fmt.Errorf("%s, but could not pause it: %s", detail, err))
+				continue
+			}
+			detail += ", paused"
+		}
+		i.add(step, print.StepDone, detail)
+	}
+}
+
+// unready returns the tools the snap uses that aren't connected with the bundle's credential sets
+func (i *importation) unready(snap *definition.Snap) []string {
+	var missing []string
+	if snap != nil {
+		for _, provider := range snap.Tools() {
+			if !i.ready[provider] {
+				missing = append(missing, provider)
+			}
+		}
+	}
+
+	return missing
+}
+
+// params returns the parameter values of an active snap of the bundle, with its secret
+// values decrypted, or an error if they were redacted or can't be decrypted
+func (i *importation) params(a bundle.ActiveSnap) ([]map[string]string, error) {
+	var redacted, sealed []string
+	params := []map[string]string{}
+	for _, p := range a.Params {
+		value := p.Value
+		switch {
+		case p.Secret && value == bundle.Redacted:
+			redacted = append(redacted, p.Name)
+		case p.Secret && bundle.IsSealed(value) && i.key == nil:
+			sealed = append(sealed, p.Name)
+		case p.Secret && bundle.IsSealed(value):
+			opened, err := bundle.Open(i.key, value)
+			if err != nil {
+				return nil, err
+			}
+			value = opened
+		}
+		params = append(params, map[string]string{"name": p.Name, "value": value})
+	}
+
+	if len(redacted) > 0 {
+		return nil, fmt.Errorf("the values of %s were redacted on export", strings.Join(redacted, ", "))
+	}
+	if len(sealed) > 0 {
+		return nil, fmt.Errorf("the values of %s are encrypted; set --passphrase", strings.Join(sealed, ", "))
+	}
+	return params, nil
+}
+
+// requestParams returns the parameter values to post for an active snap of the bundle; for
+// a dry run, which prints the request, its secret values are redacted
+func (i *importation) requestParams(a bundle.ActiveSnap, params []map[string]string) []map[string]string {
+	if !i.dryRun {
+		return params
+	}
+
+	redacted := make([]map[string]string, len(params))
+	for j, p := range params {
+		redacted[j] = map[string]string{"name": p["name"], "value": p["value"]}
+		if a.Params[j].Secret {
+			redacted[j]["value"] = bundle.Redacted
+		}
+	}
+	return redacted
+}
+
+// matchActiveSnap returns the active snap that has the parameter values, or else nil and
+// the first active snap that has different ones (if any)
+func matchActiveSnap(candidates []activeSnapParams, params []map[string]string) (*activeSnapParams, *activeSnapParams) {
+	var differs *activeSnapParams
+	for j := range candidates {
+		if sameParams(candidates[j].Params, params) {
+			return &candidates[j], nil
+		}
+		if differs == nil {
+			differs = &candidates[j]
+		}
+	}
+
+	return nil, differs
+}
+
+// sameParams returns whether an active snap's parameter values are the ones to import.
+// Secret references aren't resolved, so that comparing doesn't run them.
+func sameParams(current []map[string]string, params []map[string]string) bool {
+	if len(current) != len(params) {
+		return false
+	}
+
+	values := make(map[string]string)
+	for _, p := range current {
+		values[p["name"]] = p["value"]
+	}
+	for _, p := range params {
+		if current, ok := values[p["name"]]; !ok || current != p["value"] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// removeActiveSnap returns the active snaps without the one with the ID
+func removeActiveSnap(activeSnaps []activeSnapParams, activeSnapID string) []activeSnapParams {
+	var remaining []activeSnapParams
+	for _, a := range activeSnaps {
+		if a.ActiveSnapID != activeSnapID {
+			remaining = append(remaining, a)
+		}
+	}
+
+	return remaining
+}
+
+// checkConnections checks that each tool is connected, along with the credential sets
+// it needs, and returns the outcome for each tool, and whether it is ready.  usedBy maps
+// each tool to check to the names of the snaps that use it; a tool that no snap uses is
+// skipped rather than failed when it isn't ready.
+func checkConnections(usedBy map[string][]string, credentialSets map[string][]string) ([]print.StepResult, map[string]bool, error) {
+	tools, err := fetchTools()
+	if err != nil {
+		return nil, nil, err
+	}
+	connected := make(map[string]bool)
+	for _, tool := range tools {
+		connected[tool.Provider] = tool.Connected != ""
+	}
+
+	var providers []string
+	for provider := range usedBy {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+
+	var results []print.StepResult
+	ready := make(map[string]bool)
+	for _, provider := range providers {
+		step := fmt.Sprintf("connection %s", provider)
+		used := "not used by the snaps"
+		if len(usedBy[provider]) > 0 {
+			used = fmt.Sprintf("used by %s", strings.Join(usedBy[provider], ", "))
+		}
+
+		failed := print.StepFailed
+		if len(usedBy[provider]) == 0 {
+			failed = print.StepSkipped
+		}
+
+		if !connected[provider] {
+			results = append(results, print.StepResult{Step: step, State: failed, Detail: fmt.Sprintf("not connected (%s); connect it with snap connect %s", used, provider)})
+			continue
+		}
+
+		var missing []string
+		if len(credentialSets[provider]) > 0 {
+			response, err := api.Get(fmt.Sprintf("/entities/%s", provider))
+			if err != nil {
+				return nil, nil, err
+			}
+			names := make(map[string]bool)
+			for _, id := range gjson.GetBytes(response, "data.#.__id").Array() {
+				names[id.String()] = true
+			}
+			for _, name := range credentialSets[provider] {
+				if !names[name] {
+					missing = append(missing, name)
+				}
+			}
+		}
+		if len(missing) > 0 {
+			results = append(results, print.StepResult{Step: step, State: failed, Detail: fmt.Sprintf("missing credential sets %s (%s)", strings.Join(missing, ", "), used)})
+			continue
+		}
+
+		ready[provider] = true
+		results = append(results, print.StepResult{Step: step, State: print.StepDone, Detail: fmt.Sprintf("connected (%s)", used)})
+	}
+
+	return results, ready, nil
+}
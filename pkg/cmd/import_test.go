@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/snapmaster-io/snap/pkg/bundle"
+	"github.com/snapmaster-io/snap/pkg/snaptest"
+)
+
+func TestImportOverwrite(t *testing.T) {
+	for _, ignoreEdits := range []bool{false, true} {
+		var options []snaptest.Option
+		if ignoreEdits {
+			options = append(options, snaptest.WithoutDefinitionEdits())
+		}
+		server, _ := newTestServer(t, options...)
+		defer server.Close()
+
+		dir, err := ioutil.TempDir("", "snap")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		bundle := filepath.Join(dir, "bundle.tar.gz")
+		runSnap(t, server, "", "export", "--out", bundle).expect(t, 0, "exported 1 snap, 1 active snap")
+
+		// change the snap after the export, so that the import has to restore it
+		changed := strings.Replace(testSnapDeploy, "deploy to gke", "deploy to gke (changed)", 1)
+		if _, err := server.AddSnap("snaptest/gke-deploy", changed, true); err != nil {
+			t.Fatal(err)
+		}
+		runSnap(t, server, "", "import", bundle).expect(t, 1, "conflict", "set --overwrite to replace it")
+
+		r := runSnap(t, server, "", "import", bundle, "--overwrite")
+		snap, _ := server.Snap("snaptest/gke-deploy")
+		if ignoreEdits {
+			r.expect(t, 1, "doesn't support in-place edits")
+			continue
+		}
+		r.expect(t, 0, "updated")
+		if snap.Text != testSnapDeploy {
+			t.Errorf("imported definition %q, want %q", snap.Text, testSnapDeploy)
+		}
+	}
+}
+
+// testSnapBuild is a snap with a secret parameter
+const testSnapBuild = `name: build
+description: build an image
+trigger: github
+actions:
+  - name: build
+    provider: docker
+    action: build
+    image: $image
+parameters:
+  - name: image
+    description: image name
+  - name: token
+    description: registry token
+    type: secret
+`
+
+// writeTestBundle writes a bundle of testSnapBuild with an active snap for each of the
+// tokens, sealing those that start with "sealed:" with the passphrase, and returns its path
+func writeTestBundle(t *testing.T, passphrase string, tokens ...string) string {
+	t.Helper()
+
+	b := bundle.New("https://dev.snapmaster.io", "exported")
+	b.Snaps = []bundle.Snap{{SnapID: "exported/build", Private: true, Text: testSnapBuild}}
+	b.Connections = []bundle.Connection{{Provider: "docker"}, {Provider: "github"}}
+	key, err := b.Key(passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, token := range tokens {
+		if strings.HasPrefix(token, "sealed:") {
+			if token, err = bundle.Seal(key, strings.TrimPrefix(token, "sealed:")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		b.ActiveSnaps = append(b.ActiveSnaps, bundle.ActiveSnap{
+			ActiveSnapID: fmt.Sprintf("active-%d", i+1),
+			SnapID:       "exported/build",
+			State:        "active",
+			Params:       []bundle.Param{{Name: "image", Value: fmt.Sprintf("image-%d", i+1)}, {Name: "token", Value: token, Secret: true}},
+		})
+	}
+
+	file := writeTestFile(t, "bundle.tar.gz", "")
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := b.Write(f); err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+func TestImportSecrets(t *testing.T) {
+	tests := []struct {
+		commandTest
+		tokens    []string
+		activated []string
+	}{
+		{
+			commandTest: commandTest{
+				name:     "redacted",
+				contains: []string{"skipped", "the values of token were redacted on export"},
+			},
+			tokens: []string{bundle.Redacted},
+		},
+		{
+			commandTest: commandTest{
+				name:     "sealed without a passphrase",
+				contains: []string{"skipped", "the values of token are encrypted; set --passphrase"},
+			},
+			tokens: []string{"sealed:s3cret"},
+		},
+		{
+			commandTest: commandTest{
+				name:     "sealed with the passphrase",
+				args:     []string{"--passphrase", "correct horse"},
+				contains: []string{"activated snaptest/build", "the values of token were redacted on export"},
+			},
+			tokens:    []string{"sealed:s3cret", bundle.Redacted},
+			activated: []string{`"name":"token","value":"s3cret"`},
+		},
+		{
+			commandTest: commandTest{
+				name:     "sealed with the wrong passphrase",
+				args:     []string{"--passphrase", "wrong horse"},
+				status:   1,
+				contains: []string{"could not decrypt the secret values", "wrong passphrase"},
+			},
+			tokens: []string{"sealed:s3cret"},
+		},
+		{
+			commandTest: commandTest{
+				name:     "local reference",
+				status:   1,
+				contains: []string{"refer to local secrets (active-1.token)", "--allow-local-references"},
+			},
+			tokens: []string{"cmd://cat /etc/passwd"},
+		},
+		{
+			commandTest: commandTest{
+				name:     "sealed local reference",
+				args:     []string{"--passphrase", "correct horse"},
+				status:   1,
+				contains: []string{"refer to local secrets (active-2.token)"},
+			},
+			tokens: []string{"sealed:s3cret", "sealed:env://HOME"},
+		},
+		{
+			commandTest: commandTest{
+				name:     "local reference allowed",
+				env:      []string{"SNAP_TEST_TOKEN=from-env"},
+				args:     []string{"--allow-local-references"},
+				contains: []string{"activated snaptest/build"},
+			},
+			tokens:    []string{"env://SNAP_TEST_TOKEN"},
+			activated: []string{`"name":"token","value":"from-env"`},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, _ := newTestServer(t)
+			defer server.Close()
+
+			file := writeTestBundle(t, "correct horse", test.tokens...)
+			args := append([]string{"import", file}, test.args...)
+			runSnapEnv(t, server, test.env, "", args...).expect(t, test.status, test.contains...)
+
+			activations := posts(server, "/activesnaps")
+			if len(activations) != len(test.activated) {
+				t.Fatalf("posted activations %v, want %d", activations, len(test.activated))
+			}
+			for i, want := range test.activated {
+				if !strings.Contains(activations[i], want) {
+					t.Errorf("posted activation %s, want %s", activations[i], want)
+				}
+			}
+			if test.status != 0 {
+				if _, created := server.Snap("snaptest/build"); created {
+					t.Error("created the snap of a refused bundle")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/snapmaster-io/snap/pkg/api"
+	"github.com/snapmaster-io/snap/pkg/definition"
+	"github.com/snapmaster-io/snap/pkg/history"
+	"github.com/snapmaster-io/snap/pkg/print"
+	"github.com/snapmaster-io/snap/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// installGalleryCmd represents the install gallery snap subcommand
+var installGalleryCmd = &cobra.Command{
+	Use:   "install [snap ID]",
+	Short: "Fork a snap from the gallery, connect the tools it needs, and activate it",
+	Long: `Fork a snap from the gallery, connect the tools it needs, and activate it.
+
+The snap is forked into the user's namespace (optionally under a new name with --as),
+and its definition is checked for every tool used by its trigger and actions.  For each
+tool that isn't connected yet, the command offers to connect it by prompting for
+credentials.  Finally, the command prompts for the snap's parameters and activates it.
+
+If any step fails, the command stops and prints a summary of the steps.`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeGallerySnapIDs,
+	Run: func(cmd *cobra.Command, args []string) {
+		snapID := args[0]
+		newName, _ := cmd.Flags().GetString("as")
+
+		utils.PrintMessage(fmt.Sprintf("installing snap %s", snapID))
+
+		i := &installation{}
+		i.run(snapID, newName)
+
+		fmt.Println()
+		print.StepResultsTable(fmt.Sprintf("Install %s", snapID), i.results)
+		if i.failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	galleryCmd.AddCommand(installGalleryCmd)
+	installGalleryCmd.Flags().StringP("as", "", "", "name of the forked snap (defaults to the name of the gallery snap)")
+
+	pickable(installGalleryCmd, false, gallerySnapIDPicker)
+}
+
+// installation tracks the outcome of each step of installing a gallery snap
+type installation struct {
+	results []print.StepResult
+	failed  bool
+}
+
+func (i *installation) done(step string, detail string) {
+	i.results = append(i.results, print.StepResult{Step: step, State: print.StepDone, Detail: detail})
+}
+
+func (i *installation) fail(step string, err error, remaining ...string) {
+	// a dry run stops at the first request that would have changed anything, without failing
+	if err == api.ErrDryRun {
+		i.results = append(i.results, print.StepResult{Step: step, State: print.StepDryRun, Detail: "request not sent"})
+	} else {
+		i.results = append(i.results, print.StepResult{Step: step, State: print.StepFailed, Detail: err.Error()})
+		i.failed = true
+	}
+	for _, r := range remaining {
+		i.results = append(i.results, print.StepResult{Step: r, State: print.StepSkipped})
+	}
+}
+
+// run executes the fork, connect, and activate steps, stopping at the first failure
+func (i *installation) run(snapID string, newName string) {
+	// fork the snap into the user's namespace
+	data := make(map[string]interface{})
+	data["action"] = "fork"
+	data["snapId"] = snapID
+	if newName != "" {
+		data["name"] = newName
+	}
+	response, err := postSnapCommand(data)
+	if err == nil {
+		err = api.CheckStatus(response)
+	}
+	if err != nil {
+		i.fail("fork", err, "check tools", "activate")
+		return
+	}
+
+	var snapResponse print.SnapResponse
+	json.Unmarshal(response, &snapResponse)
+	forkedID := snapResponse.Data.SnapID
+	if forkedID == "" {
+		i.fail("fork", fmt.Errorf("the service didn't return the ID of the forked snap; check 'snap snaps list' before trying again"), "check tools", "activate")
+		return
+	}
+	i.done("fork", fmt.Sprintf("forked into %s", forkedID))
+
+	// parse the definition and compare the tools it uses with the user's connections
+	text, err := fetchSnapDefinition(forkedID)
+	if err != nil {
+		i.fail("check tools", err, "activate")
+		return
+	}
+	journalSnapRevision(response, history.Revision{Action: "fork", Text: text}, "")
+	snap, err := definition.Parse([]byte(text))
+	if err != nil {
+		i.fail("check tools", fmt.Errorf("could not parse definition: %s", err), "activate")
+		return
+	}
+	tools, err := fetchTools()
+	if err != nil {
+		i.fail("check tools", err, "activate")
+		return
+	}
+
+	library := make(map[string]print.Tool)
+	for _, tool := range tools {
+		library[tool.Provider] = tool
+	}
+	var missing []string
+	for _, provider := range snap.Tools() {
+		if library[provider].Connected == "" {
+			missing = append(missing, provider)
+		}
+	}
+	if len(missing) > 0 {
+		i.done("check tools", fmt.Sprintf("requires %s; not connected: %s", strings.Join(snap.Tools(), ", "), strings.Join(missing, ", ")))
+	} else {
+		i.done("check tools", fmt.Sprintf("requires %s; all connected", strings.Join(snap.Tools(), ", ")))
+	}
+
+	// offer to connect each missing tool, skipping the remaining steps if one fails
+	var steps []string
+	for _, provider := range missing {
+		steps = append(steps, fmt.Sprintf("connect %s", provider))
+	}
+	steps = append(steps, "activate")
+	for n, provider := range missing {
+		step, remaining := steps[n], steps[n+1:]
+		tool, found := library[provider]
+		if !found {
+			i.fail(step, fmt.Errorf("tool %s is not in the tools library", provider), remaining...)
+			return
+		}
+		if tool.Type != "simple" {
+			i.fail(step, fmt.Errorf("%s tools must be connected in the web app at %s", tool.Type, viper.GetString("APIURL")), remaining...)
+			return
+		}
+		if !promptYesNo(fmt.Sprintf("tool %s is not connected. Connect it now?", provider)) {
+			i.fail(step, fmt.Errorf("%s is required by the snap but was not connected", provider), remaining...)
+			return
+		}
+
+		jsonPath := fmt.Sprintf("data.#(provider==%s).definition.connection.connectionInfo", provider)
+		credentials, err := fetchParameterDescriptions("/connections", jsonPath)
+		if err != nil {
+			i.fail(step, err, remaining...)
+			return
+		}
+		inputParameters(credentials)
+		response, err := postConnection(provider, "/connections", credentials)
+		if err == nil {
+			err = api.CheckStatus(response)
+		}
+		if err != nil {
+			i.fail(step, err, remaining...)
+			return
+		}
+		i.done(step, "connected")
+	}
+
+	// prompt for the parameters and activate the snap
+	utils.PrintMessage(fmt.Sprintf("activating snap %s", forkedID))
+	params, err := fetchParameterDescriptions(fmt.Sprintf("/snaps/%s", forkedID), "data.parameters")
+	if err != nil {
+		i.fail("activate", err)
+		return
+	}
+	inputParameters(params)
+	response, err = postActivation(forkedID, "activate", params)
+	if err == nil {
+		err = api.CheckStatus(response)
+	}
+	if err != nil {
+		i.fail("activate", err)
+		return
+	}
+
+	var activeSnapResponse print.ActiveSnapResponse
+	json.Unmarshal(journalActivation(response), &activeSnapResponse)
+	i.done("activate", fmt.Sprintf("active snap ID %s", activeSnapResponse.Data.ActiveSnapID))
+}
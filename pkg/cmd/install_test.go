@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/snapmaster-io/snap/pkg/snaptest"
+)
+
+// newInstallServer starts a fake server configured by the options, with a gallery snap
+// that needs the docker and github tools, neither of them connected
+func newInstallServer(t *testing.T, options ...snaptest.Option) *snaptest.Server {
+	t.Helper()
+
+	server := snaptest.NewServer(options...)
+	server.AddTool("docker", "simple", testToolDocker)
+	server.AddTool("github", "oauth", testToolGithub)
+	if _, err := server.AddSnap("snapmaster/gke-deploy", testSnapDeploy, false); err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+
+	return server
+}
+
+func TestInstallWithoutForkID(t *testing.T) {
+	server := newInstallServer(t, snaptest.WithoutForkIDs())
+	defer server.Close()
+
+	r := runSnap(t, server, "", "gallery", "install", "snapmaster/gke-deploy")
+	r.expect(t, 1, "didn't return the ID of the forked snap")
+	if state := stepState(r.stdout, "check tools"); state != "skipped" {
+		t.Errorf("check tools is %q after the fork failed, want skipped:\n%s", state, r.stdout)
+	}
+}
+
+func TestInstallConnectFails(t *testing.T) {
+	server := newInstallServer(t)
+	defer server.Close()
+
+	// declining to connect docker skips connecting github and activating
+	r := runSnap(t, server, "n\n", "gallery", "install", "snapmaster/gke-deploy")
+	r.expect(t, 1, "docker is required by the snap but was not connected")
+	for _, step := range []string{"connect github", "activate"} {
+		if stepState(r.stdout, step) != "skipped" {
+			t.Errorf("step %s isn't skipped:\n%s", step, r.stdout)
+		}
+	}
+	if bodies := posts(server, "/activesnaps"); len(bodies) != 0 {
+		t.Errorf("activated after a failed connection: %v", bodies)
+	}
+}
+
+// stepState returns the state of a step in a table of step results
+func stepState(output string, step string) string {
+	// each cell of the table ends with a color reset
+	re := regexp.MustCompile(regexp.QuoteMeta(step) + ` *\x1b\[0m\x1b\[[0-9;]*m ([a-z ]*[a-z]) *\x1b\[0m`)
+	if match := re.FindStringSubmatch(output); match != nil {
+		return match[1]
+	}
+	return ""
+}
@@ -19,6 +19,11 @@ var loginCmd = &cobra.Command{
 		redirectURL := viper.GetString("RedirectURL")
 
 		auth.AuthorizeUser(clientID, authDomain, redirectURL)
+
+		// remember the login as a context, for the commands that work across environments
+		if viper.GetString("AccessToken") != "" {
+			saveContext(defaultContextName(viper.GetString("APIURL")))
+		}
 	},
 }
 
@@ -28,6 +33,15 @@ var logoutCmd = &cobra.Command{
 	Short: "Log out of a SnapMaster service",
 	Long:  `Log out of a SnapMaster service.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		// log out of the saved context of the login too
+		contexts := loadContexts()
+		if name := currentContextName(contexts); name != "" {
+			c := contexts[name]
+			c.AccessToken, c.Name, c.Email = "", "", ""
+			contexts[name] = c
+			storeContexts(contexts)
+		}
+
 		viper.Set("AccessToken", "")
 		viper.Set("Name", "")
 		viper.Set("Email", "")
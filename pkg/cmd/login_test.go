@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/snapmaster-io/snap/pkg/snaptest"
+)
+
+// loginEnv returns the environment for a snap login against the fake server, in the home
+// directory, with a browser that follows the authorization URL
+func loginEnv(t *testing.T, server *snaptest.Server, home string) []string {
+	t.Helper()
+
+	// the login opens the authorization URL with xdg-open
+	bin := filepath.Join(home, "bin")
+	if err := os.MkdirAll(bin, 0700); err != nil {
+		t.Fatal(err)
+	}
+	browser := fmt.Sprintf("#!/bin/sh\n%s=1 exec %q \"$1\"\n", browseEnv, os.Args[0])
+	if err := ioutil.WriteFile(filepath.Join(bin, "xdg-open"), []byte(browser), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	// the login listens for the callback on the port of the redirect URL
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	return []string{
+		"HOME=" + home,
+		"PATH=" + bin + string(os.PathListSeparator) + os.Getenv("PATH"),
+		authEnv + "=" + server.URL,
+		fmt.Sprintf("SNAP_REDIRECTURL=http://localhost:%d", port),
+	}
+}
+
+func TestLogin(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []snaptest.Option
+		stdin   string
+		output  []string
+		account string
+	}{
+		{name: "existing account", output: []string{"successfully logged into snapmaster-api!"}, account: "snaptest"},
+		{
+			name:    "first login",
+			options: []snaptest.Option{snaptest.WithAccount("")},
+			stdin:   "newaccount\n",
+			output:  []string{"welcome to SnapMaster!", "account successfully created!"},
+			account: "newaccount",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := snaptest.NewServer(append(tt.options, snaptest.WithUser("Login Test", "login@example.com"))...)
+			defer server.Close()
+			home, err := ioutil.TempDir("", "snap")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(home)
+
+			runSnapEnv(t, server, loginEnv(t, server, home), tt.stdin, "login").expect(t, 0, tt.output...)
+			if account := server.Account(); account != tt.account {
+				t.Errorf("account %q, want %q", account, tt.account)
+			}
+
+			// the access token and identity claims are stored in the configuration file
+			config, err := ioutil.ReadFile(filepath.Join(home, ".config", "snap", "config.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range []string{snaptest.AccessToken, "Login Test", "login@example.com"} {
+				if !strings.Contains(string(config), s) {
+					t.Errorf("configuration doesn't contain %q:\n%s", s, config)
+				}
+			}
+		})
+	}
+}
+
+func TestLogout(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	config := writeTestFile(t, "config.json", `{"accesstoken": "t0ken", "name": "Ada", "email": "ada@example.com"}`)
+	runSnap(t, server, "", "logout", "--config", config).expect(t, 0, "no logged in user.")
+
+	// the access token and the identity are cleared from the configuration file
+	contents, err := ioutil.ReadFile(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"t0ken", "Ada", "ada@example.com"} {
+		if strings.Contains(string(contents), s) {
+			t.Errorf("configuration still contains %q:\n%s", s, contents)
+		}
+	}
+}
@@ -42,10 +42,11 @@ var logsCmd = &cobra.Command{
 
 // getActiveSnapLogsCmd represents the get active snap logs subcommand
 var logDetailsCmd = &cobra.Command{
-	Use:   "details [log ID]",
-	Short: "Get the details of a log entry",
-	Long:  `Get the details of a log entry.`,
-	Args:  cobra.MinimumNArgs(1),
+	Use:               "details [log ID]",
+	Short:             "Get the details of a log entry",
+	Long:              `Get the details of a log entry.`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeLogIDs,
 	Run: func(cmd *cobra.Command, args []string) {
 		// retrieve activeSnapID as the first argument
 		logID := args[0]
@@ -85,4 +86,6 @@ var logDetailsCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(logsCmd)
 	logsCmd.AddCommand(logDetailsCmd)
+
+	pickable(logDetailsCmd, false, logIDPicker)
 }
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLogs(t *testing.T) {
+	server, active := newTestServer(t)
+	defer server.Close()
+
+	built := server.AddLog(active.ActiveSnapID, "complete", []map[string]interface{}{{
+		"provider": "docker", "action": "build", "state": "complete",
+		"output": map[string]interface{}{"status": "success", "data": map[string]interface{}{"stdout": "image built"}},
+	}})
+	failed := server.AddLog(active.ActiveSnapID, "error", []map[string]interface{}{{
+		"provider": "docker", "action": "build", "state": "error",
+		"output": map[string]interface{}{"status": "error", "message": "registry unreachable"},
+	}})
+	builtID, failedID := fmt.Sprintf("%d", built), fmt.Sprintf("%d", failed)
+
+	runCommandTests(t, server, []commandTest{
+		{name: "list", args: []string{"logs"}, contains: []string{"snaptest/gke-deploy", builtID, failedID, "complete", "error"}},
+		{name: "list json", args: []string{"logs", "--format", "json"}, contains: []string{fmt.Sprintf(`"timestamp": %s`, builtID)}},
+		{name: "details", args: []string{"logs", "details", builtID}, contains: []string{"Action log details", "docker", "build", "image built"}},
+		{name: "details of a failure", args: []string{"logs", "details", failedID}, contains: []string{"registry unreachable"}},
+		{name: "details json", args: []string{"logs", "details", builtID, "--format", "json"}, contains: []string{`"stdout": "image built"`}},
+	})
+}
@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/snapmaster-io/snap/pkg/auth"
+	"github.com/snapmaster-io/snap/pkg/snaptest"
+)
+
+// argsEnv carries the arguments of the snap command that a re-executed test binary runs
+const argsEnv = "SNAP_TEST_ARGS"
+
+// browseEnv makes a re-executed test binary stand in for the browser that snap login opens
+const browseEnv = "SNAP_TEST_BROWSE"
+
+// providersEnv points the provider checks of a re-executed test binary at the fake server
+const providersEnv = "SNAP_TEST_PROVIDERS"
+
+// authEnv points the login of a re-executed test binary at the fake authorization server
+const authEnv = "SNAP_TEST_AUTH"
+
+// commands exit the process on errors, so each one runs in a re-executed test binary
+func TestMain(m *testing.M) {
+	if os.Getenv(browseEnv) != "" {
+		// follow the authorization URL, which redirects back to the login's callback
+		res, err := http.Get(os.Args[len(os.Args)-1])
+		if err != nil {
+			os.Exit(1)
+		}
+		res.Body.Close()
+		os.Exit(0)
+	}
+
+	if encoded := os.Getenv(argsEnv); encoded != "" {
+		var args []string
+		if err := json.Unmarshal([]byte(encoded), &args); err != nil {
+			panic(err)
+		}
+		if url := os.Getenv(authEnv); url != "" {
+			auth.AuthorizeURL = url + "/authorize"
+			auth.TokenURL = url + "/oauth/token"
+		}
+		if url := os.Getenv(providersEnv); url != "" {
+			dockerHubURL = url + snaptest.DockerHubPath
+			githubAPIURL = url + snaptest.GitHubPath
+		}
+		rootCmd.SetArgs(args)
+		Execute()
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+// result is the outcome of running a snap command
+type result struct {
+	stdout string
+	stderr string
+	status int
+}
+
+// runSnap runs a snap command against the fake server, with stdin, in a home directory
+// without a configuration file
+func runSnap(t *testing.T, server *snaptest.Server, stdin string, args ...string) result {
+	t.Helper()
+
+	return runSnapEnv(t, server, nil, stdin, args...)
+}
+
+// runSnapEnv runs a snap command like runSnap, with additional environment variables
+func runSnapEnv(t *testing.T, server *snaptest.Server, env []string, stdin string, args ...string) result {
+	t.Helper()
+
+	home, err := ioutil.TempDir("", "snap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+
+	encoded, _ := json.Marshal(args)
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(),
+		argsEnv+"="+string(encoded),
+		"HOME="+home,
+		"SNAP_APIURL="+server.URL,
+		"SNAP_ACCESSTOKEN="+snaptest.AccessToken,
+		providersEnv+"="+server.URL,
+	)
+	cmd.Env = append(cmd.Env, env...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	err = cmd.Run()
+	status := 0
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		status = exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+
+	return result{stdout: stdout.String(), stderr: stderr.String(), status: status}
+}
+
+// expect fails the test unless the command exited with the status, and printed each of
+// the strings
+func (r result) expect(t *testing.T, status int, contains ...string) {
+	t.Helper()
+
+	output := r.stdout + r.stderr
+	if r.status != status {
+		t.Errorf("exit status %d, want %d; output:\n%s", r.status, status, output)
+	}
+	for _, s := range contains {
+		if !strings.Contains(output, s) {
+			t.Errorf("output doesn't contain %q:\n%s", s, output)
+		}
+	}
+}
+
+// writeTestFile writes a file in a temporary directory that is removed when the test ends,
+// and returns its path
+func writeTestFile(t *testing.T, name string, contents string) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "snap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// commandTest is a snap command run by runCommandTests, with the exit status and output it
+// is expected to have
+type commandTest struct {
+	name     string
+	env      []string
+	stdin    string
+	args     []string
+	status   int
+	contains []string
+}
+
+// runCommandTests runs each command against the fake server in a subtest
+func runCommandTests(t *testing.T, server *snaptest.Server, tests []commandTest) {
+	t.Helper()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			runSnapEnv(t, server, test.env, test.stdin, test.args...).expect(t, test.status, test.contains...)
+		})
+	}
+}
+
+// posts returns the bodies of the POST requests the fake server received on a path
+func posts(server *snaptest.Server, path string) []string {
+	var bodies []string
+	for _, r := range server.Requests() {
+		if r.Method == "POST" && r.Path == path {
+			bodies = append(bodies, r.Body)
+		}
+	}
+	return bodies
+}
+
+const testToolDocker = `name: docker
+type: simple
+actions:
+  - name: build
+    description: build an image
+    parameters:
+      - name: image
+        required: true
+connection:
+  type: simple
+  connectionInfo:
+    - name: name
+      type: name
+      description: credential set name
+    - name: username
+      description: registry user
+    - name: password
+      description: registry password
+`
+
+const testToolGithub = `name: github
+type: oauth
+triggers:
+  - name: push
+    description: fires on a push to a repo
+    events: [push]
+    parameters:
+      - name: repo
+        type: string
+        required: true
+`
+
+const testSnapDeploy = `name: gke-deploy
+description: deploy to gke
+trigger: github
+actions:
+  - name: deploy
+    provider: docker
+    action: build
+    image: $cluster
+parameters:
+  - name: cluster
+    description: cluster name
+`
+
+// newTestServer starts a fake server configured by the options, with the docker and github
+// tools connected, the gke-deploy snap, and an activation of it
+func newTestServer(t *testing.T, options ...snaptest.Option) (*snaptest.Server, *snaptest.ActiveSnap) {
+	t.Helper()
+
+	server := snaptest.NewServer(options...)
+	server.AddTool("docker", "simple", testToolDocker)
+	server.AddTool("github", "oauth", testToolGithub)
+	server.Connect("docker")
+	server.Connect("github")
+	if _, err := server.AddSnap("snaptest/gke-deploy", testSnapDeploy, true); err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	active, err := server.AddActiveSnap("snaptest/gke-deploy", []map[string]string{{"name": "cluster", "value": "prod"}})
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+
+	return server, active
+}
@@ -2,72 +2,84 @@ package cmd
 
 import (
 	"bufio"
-	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 
 	"github.com/snapmaster-io/snap/pkg/api"
 	"github.com/snapmaster-io/snap/pkg/utils"
 	"github.com/tidwall/gjson"
+	"gopkg.in/yaml.v2"
 )
 
 // getParameterDescriptions retrieves the definitions via the API call and creates
 // a slice of maps, each containing the name and description of a parameter
 func getParameterDescriptions(path string, jsonPath string) []map[string]string {
-	// execute the API call
-	response, err := api.Get(path)
+	params, err := fetchParameterDescriptions(path, jsonPath)
 	if err != nil {
-		utils.PrintErrorMessage(fmt.Sprintf("could not retrieve snap %s", path), err)
+		utils.PrintErrorMessage(fmt.Sprintf("could not retrieve %s", path), err)
 		os.Exit(1)
 	}
 
-	var status map[string]string
-	json.Unmarshal(response, &status)
-	if status["status"] != "success" {
-		utils.PrintStatus(status["status"], status["message"])
-		os.Exit(1)
+	return params
+}
+
+// fetchParameterDescriptions is like getParameterDescriptions, but returns an error instead of exiting
+func fetchParameterDescriptions(path string, jsonPath string) ([]map[string]string, error) {
+	// execute the API call, returning errors rather than exiting (e.g. on an expired token)
+	response, err := api.Request("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := api.CheckStatus(response); err != nil {
+		return nil, err
 	}
 
 	// json.Unmarshal doesn't do very well with nested arrays / maps in json
 	// gjson is a bit better but still a bit limited... so need to iterate over results and create a new []map
-	// get an array of names and descriptions
-	responseString := string(response)
-	names := gjson.Get(responseString, fmt.Sprintf("%s.#.name", jsonPath)).Array()
-	descriptions := gjson.Get(responseString, fmt.Sprintf("%s.#.description", jsonPath)).Array()
-	types := gjson.Get(responseString, fmt.Sprintf("%s.#.type", jsonPath)).Array()
+	// get an array of parameters; their fields are read from each parameter, since a field
+	// that only some parameters have would be misaligned in an array of that field alone
+	parameters := gjson.Get(string(response), jsonPath).Array()
 
 	// create a slice of maps which will contain parameter names and descriptions
-	params := make([]map[string]string, len(names))
-	for i, name := range names {
+	params := make([]map[string]string, len(parameters))
+	for i, parameter := range parameters {
 		params[i] = make(map[string]string)
-		params[i]["name"] = name.String()
-		if len(descriptions) > i {
-			params[i]["description"] = descriptions[i].String()
-		} else {
-			params[i]["description"] = ""
-		}
-		if len(types) > i {
-			params[i]["type"] = types[i].String()
+		params[i]["name"] = parameter.Get("name").String()
+		params[i]["description"] = parameter.Get("description").String()
+		if paramType := parameter.Get("type"); paramType.Exists() {
+			params[i]["type"] = paramType.String()
 		}
 	}
 
-	return params
+	return params, nil
+}
+
+// a single reader over stdin, so that input read ahead by one prompt isn't lost to the next
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// readLine reads a line from stdin and returns it without the line terminator
+func readLine() string {
+	text, _ := stdinReader.ReadString('\n')
+	return strings.TrimRight(text, "\r\n")
+}
+
+// promptYesNo asks a yes/no question and returns whether the answer was yes
+func promptYesNo(question string) bool {
+	fmt.Printf("%s [y/N]: ", question)
+	answer := strings.ToLower(strings.TrimSpace(readLine()))
+	return answer == "y" || answer == "yes"
 }
 
 // inputParameters expects a slice of maps containing name and description keys
 // it stores inputted values in the value key
 func inputParameters(params []map[string]string) {
-	// create a new reader from stdin
-	reader := bufio.NewReader(os.Stdin)
-
 	// get values for each parameter and store them in the same map
 	for i, param := range params {
 		fmt.Printf("%s (%s): ", param["name"], param["description"])
-		text, _ := reader.ReadString('\n')
-
-		// store the parameter value without the last character (\n)
-		params[i]["value"] = text[:len(text)-1]
+		params[i]["value"] = readLine()
 	}
 }
 
@@ -89,3 +101,21 @@ func readParametersFromFile(params []map[string]string, credentialName string, c
 		}
 	}
 }
+
+// readParamValues reads a yaml file that maps parameter names to values
+func readParamValues(path string) (map[string]string, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var params map[string]interface{}
+	if err := yaml.Unmarshal(contents, &params); err != nil {
+		return nil, fmt.Errorf("could not parse params file %s: %s", path, err)
+	}
+
+	values := make(map[string]string)
+	for name, value := range params {
+		values[name] = fmt.Sprintf("%v", value)
+	}
+	return values, nil
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/snapmaster-io/snap/pkg/api"
+	"github.com/snapmaster-io/snap/pkg/picker"
+	"github.com/snapmaster-io/snap/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/tidwall/gjson"
+)
+
+// argPicker describes how to interactively pick a positional argument that was omitted
+type argPicker struct {
+	title      string
+	candidates func(args []string) ([]picker.Item, error)
+}
+
+var (
+	snapIDPicker = argPicker{
+		title:      "Select a snap",
+		candidates: listCandidates("/snaps", "data", "snapId", "description"),
+	}
+	gallerySnapIDPicker = argPicker{
+		title:      "Select a gallery snap",
+		candidates: listCandidates("/gallery", "data", "snapId", "description"),
+	}
+	activeSnapIDPicker = argPicker{
+		title:      "Select an active snap",
+		candidates: listCandidates("/activesnaps", "data", "activeSnapId", "snapId"),
+	}
+	logIDPicker = argPicker{
+		title:      "Select a log",
+		candidates: listCandidates("/logs", "data", "timestamp", "snapId"),
+	}
+	toolPicker = argPicker{
+		title:      "Select a tool",
+		candidates: listCandidates("/connections", "data", "provider", "type"),
+	}
+	connectedToolPicker = argPicker{
+		title:      "Select a connected tool",
+		candidates: listCandidates("/connections", `data.#(connected%"?*")#`, "provider", "type"),
+	}
+	credentialSetPicker = argPicker{
+		title: "Select a credential set",
+		candidates: func(args []string) ([]picker.Item, error) {
+			return fetchCandidates(fmt.Sprintf("/entities/%s", args[0]), "data", "__id", "")
+		},
+	}
+)
+
+// toolOperationPicker picks one of the actions or triggers of the tool in the first argument
+func toolOperationPicker(operations string) argPicker {
+	return argPicker{
+		title: fmt.Sprintf("Select one of the tool's %s", operations),
+		candidates: func(args []string) ([]picker.Item, error) {
+			listPath := fmt.Sprintf("data.#(provider==%s).definition.%s", args[0], operations)
+			return fetchCandidates("/connections", listPath, "name", "description")
+		},
+	}
+}
+
+// pickable lets the command prompt for its leading positional arguments when they are
+// omitted (that is, when the arguments and flags don't pass the command's validation)
+// and stdin is a terminal, using one picker per argument.  If multi is true,
+// several values can be selected for the last argument, and they are all passed to the
+// command.  When stdin isn't a terminal, the command's argument validation is unchanged.
+func pickable(cmd *cobra.Command, multi bool, pickers ...argPicker) {
+	validate := cmd.Args
+	run := cmd.Run
+
+	valid := func(cmd *cobra.Command, args []string) bool {
+		return validate == nil || validate(cmd, args) == nil
+	}
+
+	cmd.Args = func(cmd *cobra.Command, args []string) error {
+		if valid(cmd, args) || (len(args) < len(pickers) && picker.IsTerminal()) {
+			return nil
+		}
+		return validate(cmd, args)
+	}
+
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		// only prompt when the arguments (and flags) aren't enough on their own
+		if !valid(cmd, args) {
+			for i := len(args); i < len(pickers); i++ {
+				args = append(args, pickArg(pickers[i], args, multi && i == len(pickers)-1)...)
+			}
+		}
+		if validate != nil {
+			if err := validate(cmd, args); err != nil {
+				cmd.Help()
+				os.Exit(1)
+			}
+		}
+		run(cmd, args)
+	}
+}
+
+// pickArg retrieves the candidates for an argument and lets the user select among them
+func pickArg(p argPicker, args []string, multi bool) []string {
+	items, err := p.candidates(args)
+	if err != nil {
+		utils.PrintErrorMessage("could not retrieve data", err)
+		os.Exit(1)
+	}
+	if len(items) == 0 {
+		utils.PrintError(fmt.Sprintf("%s: nothing to select from", strings.ToLower(p.title)))
+		os.Exit(1)
+	}
+
+	values, err := picker.Pick(p.title, items, multi)
+	if err == picker.ErrCancelled {
+		os.Exit(1)
+	}
+	if err != nil {
+		utils.PrintErrorMessage("could not read selection", err)
+		os.Exit(1)
+	}
+
+	return values
+}
+
+// listCandidates returns a candidates function for a list endpoint that doesn't depend
+// on the other arguments
+func listCandidates(path string, listPath string, field string, descField string) func([]string) ([]picker.Item, error) {
+	return func([]string) ([]picker.Item, error) {
+		return fetchCandidates(path, listPath, field, descField)
+	}
+}
+
+// fetchCandidates retrieves the API response for the path, and returns the items of
+// the array at listPath
+func fetchCandidates(path string, listPath string, field string, descField string) ([]picker.Item, error) {
+	response, err := api.Get(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := api.CheckStatus(response); err != nil {
+		return nil, err
+	}
+
+	return itemsFromResponse(response, listPath, field, descField), nil
+}
+
+// itemsFromResponse selects the array at listPath in the response, and returns the value
+// of the field in each element, along with the value of descField as a description
+func itemsFromResponse(response []byte, listPath string, field string, descField string) []picker.Item {
+	var items []picker.Item
+	gjson.GetBytes(response, listPath).ForEach(func(_, element gjson.Result) bool {
+		value := element.Get(field).String()
+		if value == "" {
+			return true
+		}
+
+		description := ""
+		if descField != "" {
+			description = strings.ReplaceAll(element.Get(descField).String(), "\n", " ")
+		}
+		items = append(items, picker.Item{Value: value, Description: description})
+		return true
+	})
+
+	return items
+}
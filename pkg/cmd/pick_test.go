@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestPickableNotInTerminal(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	// stdin isn't a terminal, so the commands fail with their argument errors instead
+	// of retrieving candidates and waiting on a picker
+	tests := []struct {
+		commandTest
+		candidates string
+	}{
+		{commandTest{name: "snaps get", args: []string{"snaps", "get"}, status: 1, contains: []string{"requires at least 1 arg(s), only received 0"}}, "/snaps"},
+		{commandTest{name: "gallery get", args: []string{"gallery", "get"}, status: 1, contains: []string{"requires at least 1 arg(s), only received 0"}}, "/gallery"},
+		{commandTest{name: "logs details", args: []string{"logs", "details"}, status: 1, contains: []string{"requires at least 1 arg(s), only received 0"}}, "/logs"},
+		{commandTest{name: "activate", args: []string{"activate"}, status: 1, contains: []string{"requires at least 1 arg(s), only received 0"}}, "/snaps"},
+		{commandTest{name: "deactivate", args: []string{"active", "deactivate"}, status: 1, contains: []string{"requires at least one ID or a selector such as --all"}}, "/activesnaps"},
+		{commandTest{name: "credential-set remove without a set", args: []string{"connections", "credential-set", "remove", "docker"}, status: 1, contains: []string{"requires at least 2 arg(s), only received 1"}}, "/entities/docker"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			runSnapEnv(t, server, test.env, test.stdin, test.args...).expect(t, test.status, test.contains...)
+		})
+	}
+
+	for _, test := range tests {
+		for _, r := range server.Requests() {
+			if r.Path == test.candidates {
+				t.Errorf("%s retrieved %s: %s %s", test.name, test.candidates, r.Method, r.Path)
+			}
+		}
+	}
+}
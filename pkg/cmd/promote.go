@@ -0,0 +1,366 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/snapmaster-io/snap/pkg/api"
+	"github.com/snapmaster-io/snap/pkg/bundle"
+	"github.com/snapmaster-io/snap/pkg/definition"
+	"github.com/snapmaster-io/snap/pkg/history"
+	"github.com/snapmaster-io/snap/pkg/print"
+	"github.com/snapmaster-io/snap/pkg/runner"
+	"github.com/snapmaster-io/snap/pkg/secrets"
+	"github.com/snapmaster-io/snap/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// promoteCmd represents the promote command
+var promoteCmd = &cobra.Command{
+	Use:   "promote [snap ID]",
+	Short: "Copy a snap from one context to another, optionally with its activations",
+	Long: `Copy a snap's definition from one saved context (--from, or the current configuration)
+to another (--to), into the account logged in there.
+
+The command first checks that the target account has the tools the snap uses connected,
+along with the credential sets its steps name, and stops if it doesn't.  Then it creates
+the snap, or if the target account already has it, shows the changes as a diff and
+updates it.
+
+With --with-activations, each of the snap's activations in the source context is also
+activated in the target, with the parameter values in the --param-overrides file (a yaml
+map of names to values, which can be secret references) replacing the source's; if the
+snap isn't active in the source, it is activated once with the overrides.  An active snap
+in the target with the same values is left alone, and one with different values is
+updated.  The command prints the outcome of each step, and exits with status 1 if any
+step failed.`,
+	Example:           `  snap promote snapmaster/deploy --from dev --to prod --with-activations --param-overrides prod.yaml`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSnapIDs,
+	Run: func(cmd *cobra.Command, args []string) {
+		snapID := args[0]
+		from, _ := cmd.Flags().GetString("from")
+		to, _ := cmd.Flags().GetString("to")
+		withActivations, _ := cmd.Flags().GetBool("with-activations")
+		overridesFile, _ := cmd.Flags().GetString("param-overrides")
+		dryRun, _ := rootCmd.PersistentFlags().GetBool("dry-run")
+
+		overrides := make(map[string]string)
+		if overridesFile != "" {
+			if !withActivations {
+				utils.PrintError("--param-overrides applies to activations; set --with-activations")
+				os.Exit(1)
+			}
+			var err error
+			if overrides, err = readParamValues(overridesFile); err != nil {
+				utils.PrintErrorMessage(fmt.Sprintf("could not read %s", overridesFile), err)
+				os.Exit(1)
+			}
+		}
+
+		// read the snap, and its activations, from the source context
+		if from != "" {
+			if err := switchContext(from); err != nil {
+				utils.PrintError(err.Error())
+				os.Exit(1)
+			}
+		} else {
+			from = currentContextName(loadContexts())
+			if from == "" {
+				from = viper.GetString("APIURL")
+			}
+		}
+		sourceURL := viper.GetString("APIURL")
+		text := getSnapDefinition(snapID)
+		snap, err := definition.Parse([]byte(text))
+		if err == nil {
+			err = snap.Validate()
+		}
+		if err != nil {
+			utils.PrintErrorMessage(fmt.Sprintf("snap %s is not valid", snapID), err)
+			os.Exit(1)
+		}
+		p := &promotion{snap: snap, text: text, dryRun: dryRun}
+		if withActivations {
+			if err := p.planActivations(snapID, overrides); err != nil {
+				utils.PrintErrorMessage("could not apply the parameter overrides", err)
+				os.Exit(1)
+			}
+		}
+
+		// promote it into the target context
+		if err := switchContext(to); err != nil {
+			utils.PrintError(err.Error())
+			os.Exit(1)
+		}
+		p.snapID = fmt.Sprintf("%s/%s", api.GetAccount(), snap.Name)
+		if p.snapID == snapID && viper.GetString("APIURL") == sourceURL {
+			utils.PrintError(fmt.Sprintf("contexts %s and %s are the same account; snap %s is already there", from, to, snapID))
+			os.Exit(1)
+		}
+		utils.PrintMessage(fmt.Sprintf("promoting snap %s from %s to %s as %s", snapID, from, to, p.snapID))
+
+		p.run()
+
+		fmt.Println()
+		print.StepResultsTable(fmt.Sprintf("Promote %s from %s to %s", snapID, from, to), p.results)
+		if p.failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(promoteCmd)
+	promoteCmd.Flags().StringP("from", "", "", "the saved context to copy the snap from (defaults to the current configuration)")
+	promoteCmd.Flags().StringP("to", "", "", "the saved context to copy the snap to")
+	promoteCmd.MarkFlagRequired("to")
+	promoteCmd.Flags().BoolP("with-activations", "", false, "also activate the snap in the target context, like it is activated in the source")
+	promoteCmd.Flags().StringP("param-overrides", "", "", "a yaml file of parameter values that replace the source activations' values")
+	promoteCmd.RegisterFlagCompletionFunc("from", completeContexts)
+	promoteCmd.RegisterFlagCompletionFunc("to", completeContexts)
+
+	pickable(promoteCmd, false, snapIDPicker)
+}
+
+// promotion tracks the outcome of each step of promoting a snap
+type promotion struct {
+	stepOutcomes
+	snap   *definition.Snap
+	text   string
+	dryRun bool
+	// the snap ID in the target account
+	snapID string
+	// the activations to create in the target account
+	activations []plannedActivation
+}
+
+// plannedActivation is an activation to create, from an active snap in the source context
+// (if any) and the parameter overrides
+type plannedActivation struct {
+	source string
+	params []map[string]string
+}
+
+// planActivations reads the snap's activations in the source context, and applies the
+// parameter overrides to their values
+func (p *promotion) planActivations(snapID string, overrides map[string]string) error {
+	var sources []activeSnapParams
+	for _, a := range getActiveSnapParams() {
+		if a.SnapID == snapID {
+			sources = append(sources, a)
+		}
+	}
+	if len(sources) == 0 {
+		sources = append(sources, activeSnapParams{})
+	}
+
+	for _, a := range sources {
+		values := make(map[string]string)
+		for _, param := range a.Params {
+			values[param["name"]] = param["value"]
+		}
+		for name, value := range overrides {
+			values[name] = value
+		}
+		if _, err := runner.ResolveParameters(p.snap, values); err != nil {
+			return err
+		}
+
+		// the values in the order the snap declares its parameters
+		params := []map[string]string{}
+		for _, param := range p.snap.Parameters {
+			if value, ok := values[param.Name]; ok {
+				params = append(params, map[string]string{"name": param.Name, "value": value})
+			}
+		}
+		p.activations = append(p.activations, plannedActivation{source: a.ActiveSnapID, params: params})
+	}
+
+	return nil
+}
+
+// run checks the target account's connections, then creates or updates the snap, and
+// creates or updates its activations
+func (p *promotion) run() {
+	usedBy := make(map[string][]string)
+	for _, provider := range p.snap.Tools() {
+		usedBy[provider] = []string{p.snap.Name}
+	}
+	results, ready, err := checkConnections(usedBy, p.credentialSets())
+	if err != nil {
+		utils.PrintErrorMessage("could not retrieve data", err)
+		os.Exit(1)
+	}
+	for _, result := range results {
+		p.add(result.Step, result.State, result.Detail)
+	}
+	if len(ready) < len(usedBy) {
+		p.add(fmt.Sprintf("snap %s", p.snapID), print.StepSkipped, "the target account's connections aren't ready")
+		return
+	}
+
+	if !p.promoteSnap() {
+		return
+	}
+	p.promoteActivations()
+}
+
+// credentialSets returns the credential sets the snap's steps name, by tool, with the
+// parameter values of each activation (or the parameter defaults, without activations)
+func (p *promotion) credentialSets() map[string][]string {
+	valueSets := []map[string]string{{}}
+	if len(p.activations) > 0 {
+		valueSets = nil
+		for _, a := range p.activations {
+			values := make(map[string]string)
+			for _, param := range a.params {
+				values[param["name"]] = param["value"]
+			}
+			valueSets = append(valueSets, values)
+		}
+	}
+
+	names := make(map[string]map[string]bool)
+	for _, values := range valueSets {
+		resolved := make(map[string]string)
+		for _, param := range p.snap.Parameters {
+			if param.Default != "" {
+				resolved[param.Name] = param.Default
+			}
+		}
+		for name, value := range values {
+			resolved[name] = value
+		}
+
+		for _, step := range append([]definition.Step{p.snap.TriggerStep()}, p.snap.ActionSteps()...) {
+			connection := definition.Expand(step.Connection(), resolved)
+			if step.Provider == "" || connection == "" || len(definition.References([]definition.Value{{Value: connection}})) > 0 {
+				continue
+			}
+			if names[step.Provider] == nil {
+				names[step.Provider] = make(map[string]bool)
+			}
+			names[step.Provider][connection] = true
+		}
+	}
+
+	credentialSets := make(map[string][]string)
+	for provider, set := range names {
+		for name := range set {
+			credentialSets[provider] = append(credentialSets[provider], name)
+		}
+		sort.Strings(credentialSets[provider])
+	}
+	return credentialSets
+}
+
+// promoteSnap creates the snap in the target account if the service replies that it doesn't
+// have it, or else updates it there, showing the changes as a diff; it returns whether the
+// activations can go ahead
+func (p *promotion) promoteSnap() bool {
+	step := fmt.Sprintf("snap %s", p.snapID)
+
+	current, err := fetchSnapDefinition(p.snapID)
+	_, notFound := err.(*snapNotFoundError)
+	if err != nil && !notFound {
+		p.fail(step, err)
+		return false
+	}
+	if !notFound && current == p.text {
+		p.add(step, print.StepDone, "unchanged")
+		return true
+	}
+
+	change := history.Revision{Action: "create", Text: p.text}
+	detail := "created"
+	var response []byte
+	if notFound {
+		current = ""
+		response, err = postSnapCommand(map[string]interface{}{"action": "create", "definition": p.text})
+		if err == nil {
+			err = api.CheckStatus(response)
+		}
+	} else {
+		printDefinitionDiff(p.snapID, current, p.text)
+		change.Action = "edit"
+		detail = "updated"
+		response, err = postDefinitionEdit(p.snapID, current, p.text, 0)
+	}
+	if err != nil {
+		p.fail(step, err)
+		return err == api.ErrDryRun
+	}
+	journalSnapRevision(response, change, current)
+	p.add(step, print.StepDone, detail)
+	return true
+}
+
+// promoteActivations activates the snap in the target account with each planned set of
+// parameter values, unless an active snap there already has them
+func (p *promotion) promoteActivations() {
+	var existing []activeSnapParams
+	if len(p.activations) > 0 {
+		for _, a := range getActiveSnapParams() {
+			if a.SnapID == p.snapID {
+				existing = append(existing, a)
+			}
+		}
+	}
+
+	for _, a := range p.activations {
+		step := fmt.Sprintf("activate %s", p.snapID)
+		if a.source != "" {
+			step = fmt.Sprintf("activate %s", a.source)
+		}
+
+		match, differs := matchActiveSnap(existing, a.params)
+		if match != nil {
+			existing = removeActiveSnap(existing, match.ActiveSnapID)
+			p.add(step, print.StepDone, fmt.Sprintf("unchanged: already active as %s", match.ActiveSnapID))
+			continue
+		}
+
+		target, action := p.snapID, "activate"
+		if differs != nil {
+			existing = removeActiveSnap(existing, differs.ActiveSnapID)
+			target, action = differs.ActiveSnapID, "edit"
+		}
+		response, err := postActivation(target, action, p.requestParams(a.params))
+		if err == nil {
+			err = api.CheckStatus(response)
+		}
+		if err != nil {
+			p.fail(step, err)
+			continue
+		}
+
+		if differs != nil {
+			p.add(step, print.StepDone, fmt.Sprintf("updated the parameter values of %s", differs.ActiveSnapID))
+			continue
+		}
+		var activeSnapResponse print.ActiveSnapResponse
+		json.Unmarshal(journalActivation(response), &activeSnapResponse)
+		p.add(step, print.StepDone, fmt.Sprintf("activated as %s", activeSnapResponse.Data.ActiveSnapID))
+	}
+}
+
+// requestParams returns the parameter values to post; for a dry run, which prints the
+// request, secret values are redacted
+func (p *promotion) requestParams(params []map[string]string) []map[string]string {
+	if !p.dryRun {
+		return params
+	}
+
+	redacted := make([]map[string]string, len(params))
+	for i, param := range params {
+		redacted[i] = map[string]string{"name": param["name"], "value": param["value"]}
+		if isSecretParam(p.snap, param["name"]) && !secrets.IsReference(param["value"]) {
+			redacted[i]["value"] = bundle.Redacted
+		}
+	}
+	return redacted
+}
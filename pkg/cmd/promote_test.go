@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/snapmaster-io/snap/pkg/snaptest"
+)
+
+// testSnapPromote is a snap whose deploy step uses the docker credential set in a parameter
+const testSnapPromote = `name: gke-deploy
+description: deploy to gke
+trigger: github
+actions:
+  - name: deploy
+    provider: docker
+    action: build
+    image: $cluster
+    connection: $registry
+parameters:
+  - name: cluster
+    description: cluster name
+  - name: registry
+    description: docker credential set
+    default: ci
+`
+
+// savePromoteContexts saves the contexts dev, for the source server, and prod, for the
+// target API URL, in a home directory that is removed when the test ends, and returns the
+// environment that sets it
+func savePromoteContexts(t *testing.T, source *snaptest.Server, targetURL string) []string {
+	t.Helper()
+
+	home, err := ioutil.TempDir("", "snap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(home) })
+	// contexts are saved in the configuration file, which snap init creates
+	if err := os.MkdirAll(filepath.Join(home, ".config", "snap"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(home, ".config", "snap", "config.json"), []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	env := []string{"HOME=" + home}
+	runSnapEnv(t, source, env, "", "config", "context", "save", "dev").expect(t, 0)
+	runSnapEnv(t, source, append(env, "SNAP_APIURL="+targetURL), "", "config", "context", "save", "prod").expect(t, 0)
+
+	return env
+}
+
+// newPromoteServers starts a source server with testSnapPromote active twice, and a target
+// server with the tools connected, but without the snap
+func newPromoteServers(t *testing.T) (*snaptest.Server, *snaptest.Server) {
+	t.Helper()
+
+	source, target := snaptest.NewServer(), snaptest.NewServer()
+	for _, server := range []*snaptest.Server{source, target} {
+		server.AddTool("docker", "simple", testToolDocker)
+		server.AddTool("github", "oauth", testToolGithub)
+		server.Connect("docker")
+		server.Connect("github")
+	}
+	if _, err := source.AddSnap("snaptest/gke-deploy", testSnapPromote, true); err != nil {
+		t.Fatal(err)
+	}
+	for _, cluster := range []string{"dev", "staging"} {
+		if _, err := source.AddActiveSnap("snaptest/gke-deploy", []map[string]string{{"name": "cluster", "value": cluster}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return source, target
+}
+
+// postedActivations returns the parameter values of the activations posted to the server
+func postedActivations(t *testing.T, server *snaptest.Server) []map[string]string {
+	t.Helper()
+
+	var activations []map[string]string
+	for _, body := range posts(server, "/activesnaps") {
+		var posted struct {
+			Action string              `json:"action"`
+			Params []map[string]string `json:"params"`
+		}
+		if err := json.Unmarshal([]byte(body), &posted); err != nil {
+			t.Fatal(err)
+		}
+		values := map[string]string{"action": posted.Action}
+		for _, param := range posted.Params {
+			values[param["name"]] = param["value"]
+		}
+		activations = append(activations, values)
+	}
+	return activations
+}
+
+func TestPromoteActivations(t *testing.T) {
+	source, target := newPromoteServers(t)
+	defer source.Close()
+	defer target.Close()
+	env := savePromoteContexts(t, source, target.URL)
+	overrides := writeTestFile(t, "prod.yaml", "registry: prod-ci\n")
+
+	// the overridden credential set must exist in the target
+	runSnapEnv(t, target, nil, "prod-ci\nci-user\npassword\n", "connections", "credential-set", "add", "docker").expect(t, 0)
+
+	args := []string{"promote", "snaptest/gke-deploy", "--from", "dev", "--to", "prod", "--with-activations", "--param-overrides", overrides}
+	runSnapEnv(t, source, env, "", args...).expect(t, 0, "created", "activated as")
+	if snap, ok := target.Snap("snaptest/gke-deploy"); !ok || snap.Text != testSnapPromote {
+		t.Errorf("promoted snap %+v", snap)
+	}
+	want := []map[string]string{
+		{"action": "activate", "cluster": "dev", "registry": "prod-ci"},
+		{"action": "activate", "cluster": "staging", "registry": "prod-ci"},
+	}
+	if got := postedActivations(t, target); !reflect.DeepEqual(got, want) {
+		t.Errorf("activated with %v, want %v", got, want)
+	}
+
+	// promoting again leaves the snap and its activations alone
+	runSnapEnv(t, source, env, "", args...).expect(t, 0, "unchanged", "already active as")
+	if got := postedActivations(t, target); len(got) != 2 {
+		t.Errorf("activated again: %v", got)
+	}
+	if bodies := posts(target, "/snaps"); len(bodies) != 1 {
+		t.Errorf("%d posts of the snap, want 1: %v", len(bodies), bodies)
+	}
+
+	// an override of a parameter the snap doesn't have is refused before anything is posted
+	unknown := writeTestFile(t, "prod.yaml", "region: us\n")
+	runSnapEnv(t, source, env, "", "promote", "snaptest/gke-deploy", "--from", "dev", "--to", "prod", "--with-activations", "--param-overrides", unknown).
+		expect(t, 1, "could not apply the parameter overrides", "'region' is not a parameter of snap gke-deploy")
+	runSnapEnv(t, source, env, "", "promote", "snaptest/gke-deploy", "--from", "dev", "--to", "prod", "--param-overrides", overrides).
+		expect(t, 1, "--param-overrides applies to activations")
+	if got := postedActivations(t, target); len(got) != 2 {
+		t.Errorf("activated after refused overrides: %v", got)
+	}
+}
+
+func TestPromoteMissingCredentialSet(t *testing.T) {
+	source, target := newPromoteServers(t)
+	defer source.Close()
+	defer target.Close()
+	env := savePromoteContexts(t, source, target.URL)
+	overrides := writeTestFile(t, "prod.yaml", "registry: prod-ci\n")
+
+	runSnapEnv(t, source, env, "", "promote", "snaptest/gke-deploy", "--from", "dev", "--to", "prod", "--with-activations", "--param-overrides", overrides).
+		expect(t, 1, "missing credential sets prod-ci", "the target account's connections aren't ready")
+	if bodies := posts(target, "/snaps"); len(bodies) != 0 {
+		t.Errorf("posted the snap with a missing credential set: %v", bodies)
+	}
+	if got := postedActivations(t, target); len(got) != 0 {
+		t.Errorf("activated with a missing credential set: %v", got)
+	}
+}
+
+func TestPromoteRetrievalError(t *testing.T) {
+	source, target := newPromoteServers(t)
+	defer source.Close()
+	defer target.Close()
+
+	// the target fails to retrieve the snap, which isn't the same as not having it
+	targetURL, _ := url.Parse(target.URL)
+	proxy := httputil.NewSingleHostReverseProxy(targetURL)
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && r.URL.Path == "/snaps/snaptest/gke-deploy" {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("upstream unavailable"))
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	}))
+	defer failing.Close()
+	env := savePromoteContexts(t, source, failing.URL)
+	runSnapEnv(t, target, nil, "ci\nci-user\npassword\n", "connections", "credential-set", "add", "docker").expect(t, 0)
+
+	runSnapEnv(t, source, env, "", "promote", "snaptest/gke-deploy", "--from", "dev", "--to", "prod", "--with-activations").
+		expect(t, 1, "could not parse the response")
+	if bodies := posts(target, "/snaps"); len(bodies) != 0 {
+		t.Errorf("posted the snap after a failed retrieval: %v", bodies)
+	}
+	if got := postedActivations(t, target); len(got) != 0 {
+		t.Errorf("activated after a failed retrieval: %v", got)
+	}
+}
+
+func TestPromoteUpdate(t *testing.T) {
+	for _, ignoreEdits := range []bool{false, true} {
+		source, _ := newTestServer(t)
+		defer source.Close()
+		var options []snaptest.Option
+		if ignoreEdits {
+			options = append(options, snaptest.WithoutDefinitionEdits())
+		}
+		target, _ := newTestServer(t, options...)
+		defer target.Close()
+		changed := strings.Replace(testSnapDeploy, "deploy to gke", "deploy to gke (prod)", 1)
+		if _, err := target.AddSnap("snaptest/gke-deploy", changed, true); err != nil {
+			t.Fatal(err)
+		}
+
+		env := savePromoteContexts(t, source, target.URL)
+
+		r := runSnapEnv(t, source, env, "", "promote", "snaptest/gke-deploy", "--from", "dev", "--to", "prod")
+		snap, _ := target.Snap("snaptest/gke-deploy")
+		if ignoreEdits {
+			r.expect(t, 1, "doesn't support in-place edits")
+			continue
+		}
+		r.expect(t, 0, "updated")
+		if snap.Text != testSnapDeploy {
+			t.Errorf("promoted definition %q, want %q", snap.Text, testSnapDeploy)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// providerCheck tests credential values with a harmless read call made directly to a tool's
+// own API, for the values of the fields, and returns a description of the outcome or the
+// tool's error
+type providerCheck struct {
+	fields []string
+	check  func(values map[string]string) (string, error)
+}
+
+// providerChecks are the checks of the tools whose APIs the CLI knows how to call; the
+// credentials of other tools are tested by the server
+var providerChecks = map[string]providerCheck{
+	"docker": {fields: []string{"username", "password"}, check: checkDockerHub},
+	"github": {fields: []string{"token"}, check: checkGitHub},
+}
+
+// base URLs of the tools' APIs, which tests point at a fake server
+var (
+	dockerHubURL = "https://hub.docker.com"
+	githubAPIURL = "https://api.github.com"
+)
+
+// providerClient makes the calls of the provider checks
+var providerClient = &http.Client{Timeout: 30 * time.Second}
+
+// findProviderCheck returns the check of the tool, if it has one and the values hold all of
+// the fields it needs
+func findProviderCheck(tool string, values map[string]string) (providerCheck, bool) {
+	check, ok := providerChecks[tool]
+	if !ok {
+		return check, false
+	}
+	for _, field := range check.fields {
+		if _, ok := values[field]; !ok {
+			return check, false
+		}
+	}
+
+	return check, true
+}
+
+// checkDockerHub logs in to Docker Hub with the username and password
+func checkDockerHub(values map[string]string) (string, error) {
+	payload, err := json.Marshal(map[string]string{"username": values["username"], "password": values["password"]})
+	if err != nil {
+		return "", err
+	}
+
+	response, err := providerClient.Post(dockerHubURL+"/v2/users/login/", "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	body, err := readProviderResponse(response, "detail")
+	if err != nil {
+		return "", fmt.Errorf("docker hub: %s", err)
+	}
+	if !gjson.GetBytes(body, "token").Exists() {
+		return "", fmt.Errorf("docker hub: the login returned no token")
+	}
+
+	return fmt.Sprintf("logged in to Docker Hub as %s", values["username"]), nil
+}
+
+// checkGitHub retrieves the GitHub user that the token authenticates
+func checkGitHub(values map[string]string) (string, error) {
+	request, err := http.NewRequest("GET", githubAPIURL+"/user", nil)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Add("Authorization", "token "+values["token"])
+	request.Header.Add("Accept", "application/vnd.github.v3+json")
+
+	response, err := providerClient.Do(request)
+	if err != nil {
+		return "", err
+	}
+	body, err := readProviderResponse(response, "message")
+	if err != nil {
+		return "", fmt.Errorf("github: %s", err)
+	}
+
+	return fmt.Sprintf("authenticated to GitHub as %s", gjson.GetBytes(body, "login").String()), nil
+}
+
+// readProviderResponse returns the body of a tool's response, or an error with the message
+// in the field of the body (or the HTTP status) if the call wasn't successful
+func readProviderResponse(response *http.Response, messageField string) ([]byte, error) {
+	defer response.Body.Close()
+
+	var body bytes.Buffer
+	if _, err := body.ReadFrom(response.Body); err != nil {
+		return nil, err
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		if message := gjson.GetBytes(body.Bytes(), messageField).String(); message != "" {
+			return nil, fmt.Errorf("%s (%s)", message, response.Status)
+		}
+		return nil, fmt.Errorf("%s", response.Status)
+	}
+
+	return body.Bytes(), nil
+}
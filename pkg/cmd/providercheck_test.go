@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProviderChecks(t *testing.T) {
+	var request *http.Request
+	var body map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		body = nil
+		json.NewDecoder(r.Body).Decode(&body)
+		switch {
+		case r.URL.Path == "/v2/users/login/" && body["password"] == "password":
+			w.Write([]byte(`{"token": "hub-token"}`))
+		case r.URL.Path == "/v2/users/login/":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"detail": "Incorrect authentication credentials"}`))
+		case r.URL.Path == "/user" && r.Header.Get("Authorization") == "token gh-token":
+			w.Write([]byte(`{"login": "octocat"}`))
+		case r.URL.Path == "/user":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message": "Bad credentials"}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	defer func(docker string, github string) { dockerHubURL, githubAPIURL = docker, github }(dockerHubURL, githubAPIURL)
+	dockerHubURL, githubAPIURL = server.URL, server.URL
+
+	tests := []struct {
+		name    string
+		tool    string
+		values  map[string]string
+		message string
+		err     string
+	}{
+		{"docker", "docker", map[string]string{"username": "ci", "password": "password"}, "logged in to Docker Hub as ci", ""},
+		{"docker bad password", "docker", map[string]string{"username": "ci", "password": "wrong"}, "", "docker hub: Incorrect authentication credentials (401 Unauthorized)"},
+		{"github", "github", map[string]string{"token": "gh-token"}, "authenticated to GitHub as octocat", ""},
+		{"github bad token", "github", map[string]string{"token": "wrong"}, "", "github: Bad credentials (401 Unauthorized)"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			check, ok := findProviderCheck(test.tool, test.values)
+			if !ok {
+				t.Fatalf("no provider check for %s", test.tool)
+			}
+			message, err := check.check(test.values)
+			if message != test.message || (err == nil) != (test.err == "") || (err != nil && err.Error() != test.err) {
+				t.Errorf("check returned %q, %v; want %q, %q", message, err, test.message, test.err)
+			}
+		})
+	}
+
+	// the login posts the username and password as JSON, and the token is sent in the header
+	checkDockerHub(map[string]string{"username": "ci", "password": "password"})
+	if request.Method != "POST" || request.Header.Get("Content-Type") != "application/json" || body["username"] != "ci" {
+		t.Errorf("docker hub login was %s %s with %v", request.Method, request.Header.Get("Content-Type"), body)
+	}
+	checkGitHub(map[string]string{"token": "gh-token"})
+	if request.Method != "GET" || !strings.Contains(request.Header.Get("Accept"), "github") {
+		t.Errorf("github request was %s with %v", request.Method, request.Header)
+	}
+}
+
+func TestFindProviderCheck(t *testing.T) {
+	tests := []struct {
+		tool   string
+		values map[string]string
+		found  bool
+	}{
+		{"docker", map[string]string{"username": "ci", "password": ""}, true},
+		{"docker", map[string]string{"token": "t"}, false},
+		{"github", map[string]string{"token": "t"}, true},
+		{"slack", map[string]string{"token": "t"}, false},
+	}
+	for _, test := range tests {
+		if _, found := findProviderCheck(test.tool, test.values); found != test.found {
+			t.Errorf("%s with %v has a provider check: %v, want %v", test.tool, test.values, found, test.found)
+		}
+	}
+}
@@ -3,7 +3,10 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/snapmaster-io/snap/pkg/api"
+	"github.com/snapmaster-io/snap/pkg/print"
 	"github.com/snapmaster-io/snap/pkg/utils"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -44,6 +47,10 @@ func init() {
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.config/snap/config.json)")
 	rootCmd.PersistentFlags().StringP("format", "f", "table", "return output of command as one of {table, json}")
+	rootCmd.PersistentFlags().String("time-format", print.TimeFormatLocal, "format timestamps in tables as one of {rfc3339, relative, unix, local}")
+	rootCmd.PersistentFlags().Bool("enrich", false, "add ISO 8601 fields next to epoch timestamps in json output")
+	rootCmd.PersistentFlags().BoolP("yes", "y", false, "don't ask for confirmation before destructive operations")
+	rootCmd.PersistentFlags().Bool("dry-run", false, "print the requests that would change data instead of sending them")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -90,4 +97,24 @@ func initConfig() {
 		// do not report non-error condition
 		//fmt.Println("Using config file:", viper.ConfigFileUsed())
 	}
+
+	// validate the configured time zone
+	if zone := viper.GetString("TimeZone"); zone != "" {
+		if _, err := time.LoadLocation(zone); err != nil {
+			utils.PrintErrorMessage(fmt.Sprintf("unknown time zone '%s'", zone), err)
+			os.Exit(1)
+		}
+	}
+
+	// configure how timestamps are printed
+	timeFormat, _ := rootCmd.PersistentFlags().GetString("time-format")
+	if err := print.SetTimeFormat(timeFormat); err != nil {
+		utils.PrintErrorMessage("invalid --time-format flag", err)
+		os.Exit(1)
+	}
+	enrich, _ := rootCmd.PersistentFlags().GetBool("enrich")
+	print.SetEnrich(enrich)
+
+	dryRun, _ := rootCmd.PersistentFlags().GetBool("dry-run")
+	api.SetDryRun(dryRun)
 }
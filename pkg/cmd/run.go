@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+
+	"github.com/snapmaster-io/snap/pkg/definition"
+	"github.com/snapmaster-io/snap/pkg/print"
+	"github.com/snapmaster-io/snap/pkg/runner"
+	"github.com/snapmaster-io/snap/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// runCmd represents the run command
+var runCmd = &cobra.Command{
+	Use:   "run --local [definition-file.yaml]",
+	Short: "Run a snap definition locally, against mock tools",
+	Long: `Run a snap definition locally, against mock tools, without activating it.
+
+The definition is parsed and validated, its parameters are resolved from --params (a yaml
+file of parameter values, with the declared defaults for the rest), and its actions run in
+order with the trigger's event payload from --event (a JSON file).  Each action is handled
+by the built-in mock provider, which returns the first matching output from --fixtures, or
+else a successful empty output.  The run stops at the first action that fails, and the
+command exits with status 1 if one does, so that it can check snap definitions in CI.
+
+The result is printed like the details of an active snap's log entry, including the
+resolved parameters of each action; --format json prints it as a logs response.
+` + varsHelp,
+	Example: `  snap run --local deploy.yaml --event push.json --params params.yaml --fixtures fixtures.yaml`,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if local, _ := cmd.Flags().GetBool("local"); !local {
+			utils.PrintError("only local runs are supported: pass --local, or activate the snap to run it against your tools")
+			os.Exit(1)
+		}
+
+		snapFile := args[0]
+		snap, err := definition.Parse([]byte(readSnapFile(cmd, snapFile)))
+		if err != nil {
+			utils.PrintErrorMessage(fmt.Sprintf("could not parse snap definition file %s", snapFile), err)
+			os.Exit(1)
+		}
+
+		values, event, fixtures, err := readRunInputs(cmd)
+		if err != nil {
+			utils.PrintErrorMessage("could not read the inputs of the run", err)
+			os.Exit(1)
+		}
+
+		r := runner.Runner{Default: &runner.Mock{Fixtures: fixtures}}
+		log, err := r.Run(snap, values, event)
+		if err != nil {
+			utils.PrintErrorMessage(fmt.Sprintf("could not run %s", snapFile), err)
+			os.Exit(1)
+		}
+
+		printLocalRun(log)
+		if log.State != runner.StateComplete {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+	runCmd.Flags().BoolP("local", "", false, "run the definition locally, against mock tools")
+	runCmd.Flags().StringP("event", "", "", "a JSON file with the trigger's event payload")
+	runCmd.Flags().StringP("params", "", "", "a yaml file of snap parameter values, as name: value")
+	runCmd.Flags().StringP("fixtures", "", "", "a yaml file of mock action outputs")
+	addVarFlags(runCmd)
+}
+
+// readRunInputs reads the parameter values, event payload, and fixtures files of a local run
+func readRunInputs(cmd *cobra.Command) (map[string]string, map[string]interface{}, []runner.Fixture, error) {
+	values := make(map[string]string)
+	if paramsFile, _ := cmd.Flags().GetString("params"); paramsFile != "" {
+		var err error
+		if values, err = readParamValues(paramsFile); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	var event map[string]interface{}
+	if eventFile, _ := cmd.Flags().GetString("event"); eventFile != "" {
+		contents, err := ioutil.ReadFile(eventFile)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if err := json.Unmarshal(contents, &event); err != nil {
+			return nil, nil, nil, fmt.Errorf("could not parse event file %s (must be a JSON object): %s", eventFile, err)
+		}
+	}
+
+	var fixtures []runner.Fixture
+	if fixturesFile, _ := cmd.Flags().GetString("fixtures"); fixturesFile != "" {
+		var err error
+		if fixtures, err = runner.ReadFixtures(fixturesFile); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	return values, event, fixtures, nil
+}
+
+// printLocalRun prints the log of a local run, in the shape of an active snap logs response
+func printLocalRun(log *print.ActiveSnapLog) {
+	response, err := json.Marshal(print.ActiveSnapLogsResponse{Status: "success", Data: []print.ActiveSnapLog{*log}})
+	if err != nil {
+		utils.PrintErrorMessage("could not serialize the result of the run", err)
+		os.Exit(1)
+	}
+
+	format, _ := rootCmd.PersistentFlags().GetString("format")
+	if format == "json" {
+		print.JSON(response)
+		return
+	}
+
+	print.ActiveSnapLogDetails(response, strconv.FormatInt(log.LogID, 10), format)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestRunLocal(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	snapFile := writeTestFile(t, "deploy.yaml", testSnapDeploy)
+	params := writeTestFile(t, "params.yaml", "cluster: prod\n")
+	event := writeTestFile(t, "push.json", `{"ref": "refs/heads/main"}`)
+	failing := writeTestFile(t, "failing.yaml", `outputs:
+  - step: deploy
+    output:
+      status: error
+      message: cluster not found
+`)
+	outputs := writeTestFile(t, "outputs.yaml", `outputs:
+  - provider: docker
+    action: build
+    output:
+      data:
+        digest: sha256:1234
+`)
+
+	runCommandTests(t, server, []commandTest{
+		{name: "not local", args: []string{"run", snapFile}, status: 1, contains: []string{"only local runs are supported"}},
+		{name: "success", args: []string{"run", "--local", snapFile, "--params", params, "--event", event, "--format", "json"}, contains: []string{
+			`"state": "complete"`, `"image": "prod"`, `"message": "mocked docker:build"`,
+		}},
+		{name: "table", args: []string{"run", "--local", snapFile, "--params", params}, contains: []string{"deploy", "prod"}},
+		{name: "fixture output", args: []string{"run", "--local", snapFile, "--params", params, "--fixtures", outputs, "--format", "json"}, contains: []string{
+			`"state": "complete"`, `"digest": "sha256:1234"`,
+		}},
+		{name: "failed action", args: []string{"run", "--local", snapFile, "--params", params, "--fixtures", failing, "--format", "json"}, status: 1, contains: []string{
+			`"state": "error"`, `"message": "cluster not found"`,
+		}},
+		{name: "failed action table", args: []string{"run", "--local", snapFile, "--params", params, "--fixtures", failing}, status: 1, contains: []string{"cluster not found"}},
+		{name: "missing definition", args: []string{"run", "--local", snapFile + ".missing"}, status: 1},
+	})
+}
+
+func TestRunLocalInputs(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	snapFile := writeTestFile(t, "deploy.yaml", testSnapDeploy)
+	params := writeTestFile(t, "params.yaml", "cluster: prod\n")
+
+	runCommandTests(t, server, []commandTest{
+		{name: "missing params file", args: []string{"run", "--local", snapFile, "--params", params + ".missing"}, status: 1, contains: []string{"could not read the inputs of the run"}},
+		{name: "malformed params", args: []string{"run", "--local", snapFile, "--params", writeTestFile(t, "params.yaml", "cluster: [prod\n")}, status: 1, contains: []string{"could not parse params file"}},
+		{name: "unknown parameter", args: []string{"run", "--local", snapFile, "--params", writeTestFile(t, "params.yaml", "cluster: prod\nregion: us\n")}, status: 1, contains: []string{"'region' is not a parameter of snap gke-deploy"}},
+		{name: "missing event file", args: []string{"run", "--local", snapFile, "--params", params, "--event", params + ".missing"}, status: 1, contains: []string{"could not read the inputs of the run"}},
+		{name: "malformed event", args: []string{"run", "--local", snapFile, "--params", params, "--event", writeTestFile(t, "push.json", `{"ref": `)}, status: 1, contains: []string{"could not parse event file", "must be a JSON object"}},
+		{name: "event not an object", args: []string{"run", "--local", snapFile, "--params", params, "--event", writeTestFile(t, "push.json", `["refs/heads/main"]`)}, status: 1, contains: []string{"could not parse event file"}},
+		{name: "missing fixtures file", args: []string{"run", "--local", snapFile, "--params", params, "--fixtures", params + ".missing"}, status: 1, contains: []string{"could not read the inputs of the run"}},
+		{name: "malformed fixtures", args: []string{"run", "--local", snapFile, "--params", params, "--fixtures", writeTestFile(t, "fixtures.yaml", "outputs:\n  - step: [deploy\n")}, status: 1, contains: []string{"could not parse fixtures file"}},
+		{name: "unknown fixture field", args: []string{"run", "--local", snapFile, "--params", params, "--fixtures", writeTestFile(t, "fixtures.yaml", "outputs:\n  - stage: deploy\n")}, status: 1, contains: []string{"could not parse fixtures file", "field stage not found"}},
+		{name: "fixtures not a list", args: []string{"run", "--local", snapFile, "--params", params, "--fixtures", writeTestFile(t, "fixtures.yaml", "outputs: deploy\n")}, status: 1, contains: []string{"could not parse fixtures file"}},
+	})
+
+	if requests := server.Requests(); len(requests) != 0 {
+		t.Errorf("local runs sent %d requests to the server: %v", len(requests), requests)
+	}
+}
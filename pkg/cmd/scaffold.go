@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/snapmaster-io/snap/pkg/definition"
+	"github.com/snapmaster-io/snap/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// templateData is the data a snap template is rendered with
+type templateData struct {
+	Name        string
+	Description string
+	Trigger     string
+	Actions     []string
+}
+
+// scaffoldSnap generates a snap definition from the init flags, either as a skeleton of
+// the trigger and actions, or from a template
+func scaffoldSnap(cmd *cobra.Command) string {
+	name, _ := cmd.Flags().GetString("name")
+	output, _ := cmd.Flags().GetString("output")
+	if name == "" && output != "" {
+		name = strings.TrimSuffix(filepath.Base(output), filepath.Ext(output))
+	}
+	if name == "" {
+		name = "my-snap"
+	}
+	description, _ := cmd.Flags().GetString("description")
+	trigger, _ := cmd.Flags().GetString("trigger")
+	actions, _ := cmd.Flags().GetStringArray("action")
+
+	templateName, _ := cmd.Flags().GetString("template")
+	if templateName != "" {
+		data := templateData{Name: name, Description: description, Trigger: trigger, Actions: actions}
+		return renderSnapTemplate(templateDir(cmd), templateName, data)
+	}
+
+	tools := make(map[string]*definition.Tool)
+	triggerStep := skeletonStep(tools, trigger, true)
+	actionSteps := []definition.SkeletonStep{}
+	for _, action := range actions {
+		actionSteps = append(actionSteps, skeletonStep(tools, action, false))
+	}
+	if len(actionSteps) == 0 {
+		actionSteps = append(actionSteps, definition.SkeletonStep{})
+	}
+
+	return definition.Skeleton(name, description, triggerStep, actionSteps)
+}
+
+// skeletonStep looks up the trigger or action of a tool:operation spec in the tools
+// library; the operation defaults to the tool's first trigger or action
+func skeletonStep(tools map[string]*definition.Tool, spec string, trigger bool) definition.SkeletonStep {
+	if spec == "" {
+		return definition.SkeletonStep{}
+	}
+
+	parts := strings.SplitN(spec, ":", 2)
+	provider := parts[0]
+	tool, ok := tools[provider]
+	if !ok {
+		tool = getToolDefinition(provider)
+		tools[provider] = tool
+	}
+
+	kind, operations := "action", tool.Actions
+	if trigger {
+		kind, operations = "trigger", tool.Triggers
+	}
+	if len(operations) == 0 {
+		utils.PrintError(fmt.Sprintf("tool %s has no %ss", provider, kind))
+		os.Exit(1)
+	}
+	if len(parts) == 1 {
+		return definition.SkeletonStep{Provider: provider, Operation: &operations[0]}
+	}
+
+	var operation *definition.Operation
+	if trigger {
+		operation = tool.FindTrigger(parts[1])
+	} else {
+		operation = tool.FindAction(parts[1])
+	}
+	if operation == nil {
+		var names []string
+		for _, o := range operations {
+			names = append(names, o.Name)
+		}
+		utils.PrintError(fmt.Sprintf("tool %s has no %s %s (%ss: %s)", provider, kind, parts[1], kind, strings.Join(names, ", ")))
+		os.Exit(1)
+	}
+
+	return definition.SkeletonStep{Provider: provider, Operation: operation}
+}
+
+// templateDir returns the template directory from --template-dir, or the TemplateDir setting
+func templateDir(cmd *cobra.Command) string {
+	dir, _ := cmd.Flags().GetString("template-dir")
+	if dir == "" {
+		dir = viper.GetString("TemplateDir")
+	}
+
+	return dir
+}
+
+// listSnapTemplates returns the names of the templates in the directory, sorted
+func listSnapTemplates(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if !entry.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			names = append(names, strings.TrimSuffix(entry.Name(), ext))
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// renderSnapTemplate renders the named template in the directory.  Templates are snap
+// definitions in Go template syntax; besides the data, they can call {{trigger "tool:trigger"}}
+// and {{step "tool:action"}} to generate config entries from the tools library, with an
+// optional entry name as a second argument.
+func renderSnapTemplate(dir string, name string, data templateData) string {
+	if dir == "" {
+		utils.PrintError("no template directory: pass --template-dir or set TemplateDir in the config")
+		os.Exit(1)
+	}
+	names, err := listSnapTemplates(dir)
+	if err != nil {
+		utils.PrintErrorMessage(fmt.Sprintf("could not read template directory %s", dir), err)
+		os.Exit(1)
+	}
+
+	path := ""
+	for _, ext := range []string{".yaml", ".yml"} {
+		if _, err := os.Stat(filepath.Join(dir, name+ext)); err == nil {
+			path = filepath.Join(dir, name+ext)
+			break
+		}
+	}
+	if path == "" {
+		utils.PrintError(fmt.Sprintf("template %s not found in %s (templates: %s)", name, dir, strings.Join(names, ", ")))
+		os.Exit(1)
+	}
+
+	tools := make(map[string]*definition.Tool)
+	step := func(trigger bool) func(string, ...string) (string, error) {
+		return func(spec string, name ...string) (string, error) {
+			if spec == "" {
+				return "", fmt.Errorf("missing tool:operation")
+			}
+			s := skeletonStep(tools, spec, trigger)
+			entryName := s.Operation.Name
+			if len(name) > 0 {
+				entryName = name[0]
+			}
+			return strings.TrimSuffix(definition.ConfigEntry(entryName, s.Provider, s.Operation, trigger), "\n"), nil
+		}
+	}
+	funcs := template.FuncMap{"trigger": step(true), "step": step(false)}
+
+	t, err := template.New(filepath.Base(path)).Funcs(funcs).ParseFiles(path)
+	if err != nil {
+		utils.PrintErrorMessage(fmt.Sprintf("could not parse template %s", path), err)
+		os.Exit(1)
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		utils.PrintErrorMessage(fmt.Sprintf("could not render template %s", path), err)
+		os.Exit(1)
+	}
+
+	return b.String()
+}
+
+// writeScaffold checks the generated definition, and writes it to the output file, or to
+// stdout if there isn't one
+func writeScaffold(cmd *cobra.Command, text string) {
+	snap, err := definition.Parse([]byte(text))
+	if err != nil {
+		utils.PrintErrorMessage("the generated definition could not be parsed", err)
+		os.Exit(1)
+	}
+	if err := snap.Validate(); err != nil {
+		utils.PrintErrorMessage("warning: the generated definition is not complete", err)
+	}
+
+	output, _ := cmd.Flags().GetString("output")
+	if output == "" {
+		fmt.Print(text)
+		return
+	}
+
+	if force, _ := cmd.Flags().GetBool("force"); !force {
+		if _, err := os.Stat(output); err == nil {
+			utils.PrintError(fmt.Sprintf("%s already exists; pass --force to overwrite it", output))
+			os.Exit(1)
+		}
+	}
+	if err := ioutil.WriteFile(output, []byte(text), 0644); err != nil {
+		utils.PrintErrorMessage(fmt.Sprintf("could not write %s", output), err)
+		os.Exit(1)
+	}
+
+	utils.PrintMessage(fmt.Sprintf("wrote %s; replace the placeholders, then create the snap with: snap snaps create %s", output, output))
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"github.com/snapmaster-io/snap/pkg/secrets"
+)
+
+// resolveSecrets returns a copy of the parameters with the values that are secret references
+// (e.g. vault://secret/data/ci#token) replaced by the secrets they refer to, so that secrets
+// never have to be typed or pasted.  During a dry run the references are only checked,
+// not resolved, and kept in the copy: no command is run and no secret is read.
+func resolveSecrets(params []map[string]string) ([]map[string]string, error) {
+	dryRun, _ := rootCmd.PersistentFlags().GetBool("dry-run")
+
+	resolved := make([]map[string]string, len(params))
+	for i, param := range params {
+		resolved[i] = make(map[string]string, len(param))
+		for k, v := range param {
+			resolved[i][k] = v
+		}
+
+		if dryRun {
+			if err := secrets.Check(param["value"]); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		secret, err := secrets.Resolve(param["value"])
+		if err != nil {
+			return nil, err
+		}
+		resolved[i]["value"] = secret
+	}
+
+	return resolved, nil
+}
@@ -5,11 +5,18 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/snapmaster-io/snap/pkg/api"
+	"github.com/snapmaster-io/snap/pkg/definition"
+	"github.com/snapmaster-io/snap/pkg/diff"
+	"github.com/snapmaster-io/snap/pkg/history"
 	"github.com/snapmaster-io/snap/pkg/print"
 	"github.com/snapmaster-io/snap/pkg/utils"
 	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
 )
 
 // snapsCmd represents the snaps command
@@ -23,68 +30,125 @@ var snapsCmd = &cobra.Command{
 	},
 }
 
-// createSnapCmd represents the create snap subcommand
-var createSnapCmd = &cobra.Command{
-	Use:   "create [definition-file.yaml]",
-	Short: "Create a snap in the user's namespace from a yaml definition file",
-	Long:  `Create a snap in the user's namespace from a yaml definition file.`,
-	Args:  cobra.MinimumNArgs(1),
+// applySnapCmd represents the apply snap subcommand
+var applySnapCmd = &cobra.Command{
+	Use:   "apply [definition-file.yaml]",
+	Short: "Create or update a snap from a yaml definition file",
+	Long: `Create a snap from a yaml definition file, or update it if the user already has a snap
+with that name, showing the changes as a diff.  The snap is only created when the service
+replies that it has no snap with that name; any other error retrieving it fails the command.
+` + varsHelp,
+	Example: `  snap snaps apply deploy.yaml --var-file env/prod.yaml`,
+	Args:    cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		// retrieve yaml file as the first argument
 		snapFile := args[0]
+		text := readSnapFile(cmd, snapFile)
+		snap, err := definition.Parse([]byte(text))
+		if err == nil {
+			err = snap.Validate()
+		}
+		if err != nil {
+			utils.PrintErrorMessage(fmt.Sprintf("%s is not a valid snap definition", snapFile), err)
+			os.Exit(1)
+		}
 
-		// read the file contents
-		contents, err := ioutil.ReadFile(snapFile)
+		snapID := fmt.Sprintf("%s/%s", api.GetAccount(), snap.Name)
+		current, err := fetchSnapDefinition(snapID)
+		if _, notFound := err.(*snapNotFoundError); notFound {
+			createSnap(text)
+			return
+		}
 		if err != nil {
-			utils.PrintErrorMessage(fmt.Sprintf("could not read snap definition file %s", snapFile), err)
+			utils.PrintErrorMessage(fmt.Sprintf("could not apply %s", snapFile), err)
+			os.Exit(1)
+		}
+		if current == text {
+			utils.PrintMessage(fmt.Sprintf("no changes to snap %s", snapID))
+			return
+		}
+		if format, _ := rootCmd.PersistentFlags().GetString("format"); format != "json" {
+			printDefinitionDiff(snapID, current, text)
+		}
+		if err := updateSnapDefinition(snapID, current, text, 0); err != nil {
+			utils.PrintErrorMessage(fmt.Sprintf("could not update snap %s", snapID), err)
 			os.Exit(1)
 		}
+	},
+}
 
-		data := make(map[string]interface{})
-		data["action"] = "create"
-		data["definition"] = string(contents)
-		processSnapCommand(data)
+// createSnapCmd represents the create snap subcommand
+var createSnapCmd = &cobra.Command{
+	Use:   "create [definition-file.yaml]",
+	Short: "Create a snap in the user's namespace from a yaml definition file",
+	Long: `Create a snap in the user's namespace from a yaml definition file.
+` + varsHelp,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// retrieve yaml file as the first argument
+		createSnap(readSnapFile(cmd, args[0]))
 	},
 }
 
 // deleteSnapCmd represents the delete snap subcommand
 var deleteSnapCmd = &cobra.Command{
-	Use:   "delete",
-	Short: "Delete a snap from the user's namespace",
-	Long:  `Delete a snap from the user's namespace.`,
-	Args:  cobra.MinimumNArgs(1),
+	Use:   "delete [snap ID]...",
+	Short: "Delete one or more snaps from the user's namespace",
+	Long: `Delete one or more snaps from the user's namespace.
+
+Snaps can be passed as arguments, or selected with --all and --trigger.`,
+	Args:              idsOrSelectors,
+	ValidArgsFunction: completeSnapIDs,
 	Run: func(cmd *cobra.Command, args []string) {
-		// retrieve snapID as the first argument
-		snapID := args[0]
-		data := make(map[string]interface{})
-		data["action"] = "delete"
-		data["snapId"] = snapID
-		processSnapCommand(data)
+		processBulkSnapCommand(cmd, args, "Delete", confirmSnapDeletion, func(snapID string) map[string]interface{} {
+			data := make(map[string]interface{})
+			data["action"] = "delete"
+			data["snapId"] = snapID
+			return data
+		})
+	},
+}
+
+// editSnapCmd represents the edit snap subcommand
+var editSnapCmd = &cobra.Command{
+	Use:   "edit [snap ID]",
+	Short: "Edit the definition of a snap in your editor",
+	Long: `Edit the definition of a snap in your editor ($VISUAL or $EDITOR, defaulting to vi).
+
+When the editor exits, the definition is validated, the changes are shown as a diff, and
+after confirmation the snap is updated in place, keeping its activations.  If the edited
+definition isn't valid, you can edit it again.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSnapIDs,
+	Run: func(cmd *cobra.Command, args []string) {
+		editSnapDefinition(args[0])
 	},
 }
 
 // forkSnapCmd represents the fork snap subcommand
 var forkSnapCmd = &cobra.Command{
-	Use:   "fork",
-	Short: "Forks a public snap into the user's namespace",
-	Long:  `Forks a public snap into the user's namespace.`,
-	Args:  cobra.MinimumNArgs(1),
+	Use:               "fork",
+	Short:             "Forks a public snap into the user's namespace",
+	Long:              `Forks a public snap into the user's namespace.`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeGallerySnapIDs,
 	Run: func(cmd *cobra.Command, args []string) {
 		// retrieve snapID as the first argument
 		snapID := args[0]
 		data := make(map[string]interface{})
 		data["action"] = "fork"
 		data["snapId"] = snapID
-		processSnapCommand(data)
+		response := processSnapCommand(data)
+		journalSnapRevision(response, history.Revision{Action: "fork"}, "")
 	},
 }
 
 // getSnapCmd represents the get snap subcommand
 var getSnapCmd = &cobra.Command{
-	Use:   "get [snap ID]",
-	Short: "Get a description of a snap",
-	Long:  `Get a description of a snap.`,
-	Args:  cobra.MinimumNArgs(1),
+	Use:               "get [snap ID]",
+	Short:             "Get a description of a snap",
+	Long:              `Get a description of a snap.`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeSnapIDs,
 	Run: func(cmd *cobra.Command, args []string) {
 		// retrieve snapID as the first argument
 		snapID := args[0]
@@ -107,6 +171,118 @@ var getSnapCmd = &cobra.Command{
 	},
 }
 
+// graphSnapCmd represents the graph snap subcommand
+var graphSnapCmd = &cobra.Command{
+	Use:   "graph [snap ID | definition-file.yaml]",
+	Short: "Draw the workflow of a snap as a graph",
+	Long: `Draw the workflow of a snap - its trigger, followed by its actions in order - as a graph,
+with the provider and operation of each step and the snap parameters it consumes.
+
+The snap can be a snap ID or a definition file.  --graph-format is one of {ascii, mermaid,
+dot}, and --format json prints the graph's nodes and edges instead; --markdown wraps mermaid
+and dot output in a fenced code block, for pasting into design docs and pull requests.`,
+	Example: `  snap snaps graph snapmaster/docker-slack
+  snap snaps graph deploy.yaml --graph-format mermaid --markdown
+  snap snaps graph deploy.yaml --graph-format dot | dot -Tsvg > deploy.svg`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSnapIDs,
+	Run: func(cmd *cobra.Command, args []string) {
+		snap, _ := loadSnapDefinition(args[0])
+		graph := print.NewSnapGraph(snap)
+
+		if format, _ := rootCmd.PersistentFlags().GetString("format"); format == "json" {
+			response, _ := json.Marshal(graph)
+			print.JSON(response)
+			return
+		}
+
+		format, _ := cmd.Flags().GetString("graph-format")
+		markdown, _ := cmd.Flags().GetBool("markdown")
+		var output string
+		switch format {
+		case "ascii":
+			output = print.GraphASCII(graph)
+		case "mermaid":
+			output = print.GraphMermaid(graph)
+		case "dot":
+			output = print.GraphDot(graph)
+		default:
+			utils.PrintError(fmt.Sprintf("unknown graph format '%s' (must be one of {ascii, mermaid, dot})", format))
+			os.Exit(1)
+		}
+
+		if markdown && format != "ascii" {
+			output = fmt.Sprintf("```%s\n%s```\n", format, output)
+		}
+		fmt.Print(output)
+	},
+}
+
+// historySnapCmd represents the history snap subcommand
+var historySnapCmd = &cobra.Command{
+	Use:   "history [snap ID]",
+	Short: "List the revisions of a snap's definition",
+	Long: `List the revisions of a snap's definition, along with the active snaps that run each revision.
+
+The history comes from the server if it keeps one, or else from the local journal in
+$HOME/.config/snap/history, which records the snaps created, updated, and forked from the CLI.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSnapIDs,
+	Run: func(cmd *cobra.Command, args []string) {
+		snapID := args[0]
+		revisions, server, err := fetchSnapHistory(snapID)
+		if err != nil {
+			utils.PrintErrorMessage(fmt.Sprintf("could not retrieve the history of snap %s", snapID), err)
+			os.Exit(1)
+		}
+
+		format, err := rootCmd.PersistentFlags().GetString("format")
+		if format == "json" {
+			if revisions == nil {
+				revisions = []history.Revision{}
+			}
+			response, _ := json.Marshal(print.SnapHistoryResponse{Status: "success", Data: revisions})
+			print.JSON(response)
+			return
+		}
+
+		if len(revisions) == 0 {
+			utils.PrintMessage(fmt.Sprintf("snap %s has no history; revisions are recorded when it is created, updated, or forked from the CLI", snapID))
+			return
+		}
+
+		title := fmt.Sprintf("History of %s", snapID)
+		if !server {
+			title += " (local journal)"
+		}
+		print.SnapHistoryTable(title, revisions, activeSnapsByRevision(snapID))
+	},
+}
+
+// initSnapCmd represents the init snap subcommand
+var initSnapCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Generate a snap definition to start from",
+	Long: `Generate a commented snap definition to start from.
+
+The trigger (--trigger tool[:trigger]) and actions (--action tool[:action], repeated) are
+looked up in the tools library, defaulting to the tool's first trigger or action.  Their
+required parameters are declared as snap parameters, and the others get placeholders.
+
+--template generates the definition from a template in --template-dir (or the TemplateDir
+setting) instead.  Templates are snap definitions in Go template syntax, rendered with
+{{.Name}}, {{.Description}}, {{.Trigger}} and {{.Actions}}, and can generate config entries
+with {{trigger "tool:trigger" "name"}} and {{step "tool:action" "name"}} (the name is optional).
+
+The definition is printed, or written to --output.`,
+	Example: `  snap snaps init --trigger github --action gcp:deploy --action slack:send --output deploy.yaml
+  snap snaps init --template-dir ./templates --template deploy --name my-service`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		writeScaffold(cmd, scaffoldSnap(cmd))
+	},
+}
+
 // listSnapsCmd represents the list snaps subcommand
 var listSnapsCmd = &cobra.Command{
 	Use:   "list",
@@ -139,66 +315,313 @@ var listSnapsCmd = &cobra.Command{
 
 // publishSnapCmd represents the publish snap subcommand
 var publishSnapCmd = &cobra.Command{
-	Use:   "publish",
-	Short: "Makes a user's snap public and discoverable by others in the gallery",
-	Long:  `Makes a user's snap public and discoverable by others in the gallery.`,
-	Args:  cobra.MinimumNArgs(1),
+	Use:   "publish [snap ID]...",
+	Short: "Makes a user's snaps public and discoverable by others in the gallery",
+	Long: `Makes a user's snaps public and discoverable by others in the gallery.
+
+Snaps can be passed as arguments, or selected with --all and --trigger.`,
+	Args:              idsOrSelectors,
+	ValidArgsFunction: completeSnapIDs,
+	Run: func(cmd *cobra.Command, args []string) {
+		processBulkSnapCommand(cmd, args, "Publish", nil, func(snapID string) map[string]interface{} {
+			data := make(map[string]interface{})
+			data["action"] = "edit"
+			data["snapId"] = snapID
+			data["private"] = false
+			return data
+		})
+	},
+}
+
+// validateSnapCmd represents the validate snap subcommand
+var validateSnapCmd = &cobra.Command{
+	Use:   "validate [definition-file.yaml]...",
+	Short: "Check yaml definition files without uploading them",
+	Long: `Check that yaml definition files render, parse, and are complete and internally
+consistent, without uploading them.
+` + varsHelp,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		failed := 0
+		for _, snapFile := range args {
+			text := readSnapFile(cmd, snapFile)
+			snap, err := definition.Parse([]byte(text))
+			if err == nil {
+				err = snap.Validate()
+			}
+			if err != nil {
+				utils.PrintErrorMessage(fmt.Sprintf("%s is not valid", snapFile), err)
+				failed++
+				continue
+			}
+			utils.PrintMessage(fmt.Sprintf("%s is valid: snap %s, triggered by %s, with %s", snapFile, snap.Name, snap.TriggerProvider(), plural(len(snap.Actions), "action")))
+		}
+
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// updateSnapCmd represents the update snap subcommand
+var updateSnapCmd = &cobra.Command{
+	Use:   "update [snap ID] [definition-file.yaml]",
+	Short: "Update the definition of a snap from a yaml definition file",
+	Long: `Update the definition of a snap from a yaml definition file, keeping its activations.
+
+The file can be passed as an argument or with --file ("-" reads the definition from stdin).
+The definition is validated and the changes are shown as a diff before the snap is updated.
+` + varsHelp,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeSnapIDs,
 	Run: func(cmd *cobra.Command, args []string) {
-		// retrieve snapID as the first argument
 		snapID := args[0]
-		data := make(map[string]interface{})
-		data["action"] = "edit"
-		data["snapId"] = snapID
-		data["private"] = false
-		processSnapCommand(data)
+		snapFile, _ := cmd.Flags().GetString("file")
+		if len(args) > 1 {
+			snapFile = args[1]
+		}
+		if snapFile == "" {
+			utils.PrintError("pass the definition file as an argument or with --file")
+			os.Exit(1)
+		}
+
+		text := readSnapFile(cmd, snapFile)
+		if err := validateSnapDefinition(snapID, text); err != nil {
+			utils.PrintErrorMessage(fmt.Sprintf("%s is not a valid definition for snap %s", snapFile, snapID), err)
+			os.Exit(1)
+		}
+
+		current := getSnapDefinition(snapID)
+		if current == text {
+			utils.PrintMessage(fmt.Sprintf("no changes to snap %s", snapID))
+			return
+		}
+		if format, _ := rootCmd.PersistentFlags().GetString("format"); format != "json" {
+			printDefinitionDiff(snapID, current, text)
+		}
+		if err := updateSnapDefinition(snapID, current, text, 0); err != nil {
+			utils.PrintErrorMessage(fmt.Sprintf("could not update snap %s", snapID), err)
+			os.Exit(1)
+		}
+	},
+}
+
+// rollbackSnapCmd represents the rollback snap subcommand
+var rollbackSnapCmd = &cobra.Command{
+	Use:   "rollback [snap ID] [revision]",
+	Short: "Restore the definition of a snap from an earlier revision",
+	Long: `Restore the definition of a snap from an earlier revision.
+
+The changes are shown as a diff and confirmed before the snap is updated.  The restored
+definition becomes a new revision, so a rollback can itself be rolled back.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeSnapIDs,
+	Run: func(cmd *cobra.Command, args []string) {
+		snapID := args[0]
+		revision, err := strconv.Atoi(strings.TrimPrefix(args[1], "@"))
+		if err != nil || revision < 1 {
+			utils.PrintError(fmt.Sprintf("invalid revision %q (must be a positive number)", args[1]))
+			os.Exit(1)
+		}
+
+		target := getSnapRevision(snapID, revision)
+		current := getSnapDefinition(snapID)
+		if target.Text == current {
+			utils.PrintMessage(fmt.Sprintf("snap %s already has the definition of revision %d", snapID, revision))
+			return
+		}
+		if err := validateSnapDefinition(snapID, target.Text); err != nil {
+			utils.PrintErrorMessage(fmt.Sprintf("revision %d is not a valid definition for snap %s", revision, snapID), err)
+			os.Exit(1)
+		}
+
+		if format, _ := rootCmd.PersistentFlags().GetString("format"); format != "json" {
+			printDefinitionDiff(snapID, current, target.Text)
+		}
+		confirmAction(func() string {
+			return fmt.Sprintf("Roll back snap %s to revision %d?", snapID, revision)
+		})
+		if err := updateSnapDefinition(snapID, current, target.Text, revision); err != nil {
+			utils.PrintErrorMessage(fmt.Sprintf("could not roll back snap %s", snapID), err)
+			os.Exit(1)
+		}
+	},
+}
+
+// showSnapCmd represents the show snap subcommand
+var showSnapCmd = &cobra.Command{
+	Use:   "show [snap ID]@[revision]",
+	Short: "Show the definition of a snap at a revision",
+	Long: `Show the definition of a snap at a revision, or at its latest revision if none is given.
+
+--diff shows the changes from the revision before it instead.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSnapIDs,
+	Run: func(cmd *cobra.Command, args []string) {
+		snapID, revision, err := parseSnapRevision(args[0])
+		if err != nil {
+			utils.PrintError(err.Error())
+			os.Exit(1)
+		}
+		r := getSnapRevision(snapID, revision)
+
+		format, err := rootCmd.PersistentFlags().GetString("format")
+		if format == "json" {
+			response, _ := json.Marshal(print.SnapRevisionResponse{Status: "success", Data: *r})
+			print.JSON(response)
+			return
+		}
+
+		if showDiff, _ := cmd.Flags().GetBool("diff"); showDiff {
+			previous := ""
+			if r.Revision > 1 {
+				previous = getSnapRevision(snapID, r.Revision-1).Text
+			}
+			fmt.Print(diff.Colorize(diff.Unified(fmt.Sprintf("%s@%d", snapID, r.Revision-1), fmt.Sprintf("%s@%d", snapID, r.Revision), previous, r.Text)))
+			return
+		}
+
+		utils.PrintYAML(r.Text)
+	},
+}
+
+// renderSnapCmd represents the render snap subcommand
+var renderSnapCmd = &cobra.Command{
+	Use:   "render [definition-file.yaml]",
+	Short: "Print a yaml definition file with its variables substituted",
+	Long: `Print a yaml definition file with its variables substituted, to preview the definition
+that create, update, and apply will upload.
+` + varsHelp,
+	Example: `  snap snaps render deploy.yaml --var-file env/staging.yaml --var replicas=2`,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		text := readSnapFile(cmd, args[0])
+
+		format, _ := rootCmd.PersistentFlags().GetString("format")
+		if format == "json" {
+			response, _ := json.Marshal(print.SnapDefinition{Text: text})
+			print.JSON(response)
+			return
+		}
+
+		// only colorize for a terminal, so that the output can be redirected into a file
+		if terminal.IsTerminal(int(os.Stdout.Fd())) {
+			utils.PrintYAML(text)
+		} else {
+			fmt.Print(text)
+		}
 	},
 }
 
 // unpublishSnapCmd represents the publish snap subcommand
 var unpublishSnapCmd = &cobra.Command{
-	Use:   "unpublish",
-	Short: "Makes a user's snap private",
-	Long:  `Makes a user's snap private.`,
-	Args:  cobra.MinimumNArgs(1),
+	Use:   "unpublish [snap ID]...",
+	Short: "Makes a user's snaps private",
+	Long: `Makes a user's snaps private.
+
+Snaps can be passed as arguments, or selected with --all and --trigger.`,
+	Args:              idsOrSelectors,
+	ValidArgsFunction: completeSnapIDs,
 	Run: func(cmd *cobra.Command, args []string) {
-		// retrieve snapID as the first argument
-		snapID := args[0]
-		data := make(map[string]interface{})
-		data["action"] = "edit"
-		data["snapId"] = snapID
-		data["private"] = true
-		processSnapCommand(data)
+		processBulkSnapCommand(cmd, args, "Unpublish", nil, func(snapID string) map[string]interface{} {
+			data := make(map[string]interface{})
+			data["action"] = "edit"
+			data["snapId"] = snapID
+			data["private"] = true
+			return data
+		})
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(snapsCmd)
+	snapsCmd.AddCommand(applySnapCmd)
 	snapsCmd.AddCommand(createSnapCmd)
 	snapsCmd.AddCommand(deleteSnapCmd)
+	snapsCmd.AddCommand(editSnapCmd)
 	snapsCmd.AddCommand(forkSnapCmd)
 	snapsCmd.AddCommand(getSnapCmd)
+	snapsCmd.AddCommand(graphSnapCmd)
+	snapsCmd.AddCommand(historySnapCmd)
+	snapsCmd.AddCommand(initSnapCmd)
 	snapsCmd.AddCommand(listSnapsCmd)
 	snapsCmd.AddCommand(publishSnapCmd)
+	snapsCmd.AddCommand(renderSnapCmd)
+	snapsCmd.AddCommand(rollbackSnapCmd)
+	snapsCmd.AddCommand(showSnapCmd)
 	snapsCmd.AddCommand(unpublishSnapCmd)
+	snapsCmd.AddCommand(updateSnapCmd)
+	snapsCmd.AddCommand(validateSnapCmd)
+
+	graphSnapCmd.Flags().StringP("graph-format", "", "ascii", "draw the graph as one of {ascii, mermaid, dot}")
+	graphSnapCmd.Flags().BoolP("markdown", "", false, "wrap mermaid and dot output in a markdown code block")
+
+	initSnapCmd.Flags().StringP("name", "", "", "name of the snap (default: the output file name, or my-snap)")
+	initSnapCmd.Flags().StringP("description", "", "", "description of the snap")
+	initSnapCmd.Flags().StringP("trigger", "", "", "trigger of the snap, as tool[:trigger]")
+	initSnapCmd.Flags().StringArrayP("action", "", nil, "action of the snap, as tool[:action] (repeatable)")
+	initSnapCmd.Flags().StringP("template", "", "", "name of a template in the template directory")
+	initSnapCmd.Flags().StringP("template-dir", "", "", "directory of snap templates (default: the TemplateDir setting)")
+	initSnapCmd.Flags().StringP("output", "o", "", "file to write the definition to (default: stdout)")
+	initSnapCmd.Flags().BoolP("force", "", false, "overwrite the output file if it exists")
+	initSnapCmd.RegisterFlagCompletionFunc("trigger", completeTools)
+	initSnapCmd.RegisterFlagCompletionFunc("action", completeTools)
+
+	showSnapCmd.Flags().BoolP("diff", "", false, "show the changes from the previous revision")
+
+	updateSnapCmd.Flags().StringP("file", "", "", "yaml definition file, or - for stdin")
+
+	for _, cmd := range []*cobra.Command{applySnapCmd, createSnapCmd, renderSnapCmd, updateSnapCmd, validateSnapCmd} {
+		addVarFlags(cmd)
+	}
+
+	for _, cmd := range []*cobra.Command{deleteSnapCmd, publishSnapCmd, unpublishSnapCmd} {
+		addSnapSelectorFlags(cmd)
+		addBulkFlags(cmd)
+	}
+
+	pickable(deleteSnapCmd, true, snapIDPicker)
+	pickable(editSnapCmd, false, snapIDPicker)
+	pickable(forkSnapCmd, false, gallerySnapIDPicker)
+	pickable(getSnapCmd, false, snapIDPicker)
+	pickable(graphSnapCmd, false, snapIDPicker)
+	pickable(historySnapCmd, false, snapIDPicker)
+	pickable(showSnapCmd, false, snapIDPicker)
+	pickable(publishSnapCmd, true, snapIDPicker)
+	pickable(unpublishSnapCmd, true, snapIDPicker)
 }
 
-func processSnapCommand(data map[string]interface{}) {
-	path := "/snaps"
+// createSnap creates a snap from the text of its definition, and records it in the local history
+func createSnap(text string) {
+	data := make(map[string]interface{})
+	data["action"] = "create"
+	data["definition"] = text
+	response := processSnapCommand(data)
+	journalSnapRevision(response, history.Revision{Action: "create", Text: text}, "")
+}
+
+// processSnapCommand posts a snap command to the API, prints the response, and returns it
+// (or nil for a dry run)
+func processSnapCommand(data map[string]interface{}) []byte {
 	action := data["action"]
-	payload, err := json.Marshal(data)
-	if err != nil {
-		utils.PrintErrorMessage("could not serialize payload into JSON", err)
-		os.Exit(1)
-	}
 
 	// execute the API call
-	response, err := api.Post(path, payload)
+	response, err := postSnapCommand(data)
+	if err == api.ErrDryRun {
+		return nil
+	}
 	if err != nil {
 		utils.PrintErrorMessage("could not retrieve data", err)
 		os.Exit(1)
 	}
 
-	format, err := rootCmd.PersistentFlags().GetString("format")
+	printSnapResponse(action, response)
+	return response
+}
+
+// printSnapResponse prints the response to a snap command
+func printSnapResponse(action interface{}, response []byte) {
+	format, _ := rootCmd.PersistentFlags().GetString("format")
 	if format == "json" {
 		print.JSON(response)
 		return
@@ -210,3 +633,114 @@ func processSnapCommand(data map[string]interface{}) {
 		print.SnapStatusTable(response)
 	}
 }
+
+// postSnapCommand posts a snap command to the API and returns the response
+func postSnapCommand(data map[string]interface{}) ([]byte, error) {
+	path := "/snaps"
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// execute the API call, returning errors rather than exiting so that bulk commands can continue
+	return api.Request("POST", path, payload)
+}
+
+// snapNotFound matches the messages of services that have no snap with an ID
+var snapNotFound = regexp.MustCompile(`(?i)not found|doesn't exist|does not exist|no such snap`)
+
+// snapNotFoundError is returned by fetchSnapDefinition when the service has no snap with the ID
+type snapNotFoundError struct {
+	snapID  string
+	message string
+}
+
+func (e *snapNotFoundError) Error() string {
+	return fmt.Sprintf("could not retrieve snap %s: %s", e.snapID, e.message)
+}
+
+// fetchSnapDefinition retrieves a snap and returns the text of its definition, returning
+// errors rather than exiting.  A *snapNotFoundError is returned only when the service
+// replies that it has no snap with the ID, so that callers can tell it apart from requests
+// that failed.
+func fetchSnapDefinition(snapID string) (string, error) {
+	// execute the API call
+	path := fmt.Sprintf("/snaps/%s", snapID)
+	response, err := api.Request("GET", path, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var snapResponse print.SnapDefinitionResponse
+	if err := json.Unmarshal(response, &snapResponse); err != nil {
+		return "", fmt.Errorf("could not retrieve snap %s: could not parse the response: %s", snapID, err)
+	}
+	if snapResponse.Status != "success" {
+		if snapNotFound.MatchString(snapResponse.Message) {
+			return "", &snapNotFoundError{snapID: snapID, message: snapResponse.Message}
+		}
+		return "", fmt.Errorf("could not retrieve snap %s: %s", snapID, snapResponse.Message)
+	}
+
+	return snapResponse.Data.Text, nil
+}
+
+// getSnapDefinition retrieves a snap and returns the text of its definition
+func getSnapDefinition(snapID string) string {
+	text, err := fetchSnapDefinition(snapID)
+	if err != nil {
+		utils.PrintErrorMessage("could not retrieve data", err)
+		os.Exit(1)
+	}
+
+	return text
+}
+
+// loadSnapDefinition reads a snap definition from a file, or else retrieves the snap with
+// that ID, and returns it parsed along with its text
+func loadSnapDefinition(arg string) (*definition.Snap, string) {
+	var text string
+	if _, err := os.Stat(arg); err == nil {
+		contents, err := ioutil.ReadFile(arg)
+		if err != nil {
+			utils.PrintErrorMessage(fmt.Sprintf("could not read snap definition file %s", arg), err)
+			os.Exit(1)
+		}
+		text = string(contents)
+	} else {
+		text = getSnapDefinition(arg)
+	}
+
+	snap, err := definition.Parse([]byte(text))
+	if err != nil {
+		utils.PrintErrorMessage(fmt.Sprintf("could not parse the definition of %s", arg), err)
+		os.Exit(1)
+	}
+
+	return snap, text
+}
+
+// getRequiredTools parses a snap definition and returns the tools it uses, and whether each is connected
+func getRequiredTools(snap *definition.Snap, connected map[string]bool) []print.RequiredTool {
+	usedFor := make(map[string][]string)
+	trigger := snap.TriggerProvider()
+	if trigger != "" {
+		usedFor[trigger] = append(usedFor[trigger], "trigger")
+	}
+	for _, step := range snap.ActionSteps() {
+		if step.Provider != "" {
+			usedFor[step.Provider] = append(usedFor[step.Provider], fmt.Sprintf("%s:%s", step.Name, step.Action))
+		}
+	}
+
+	var tools []print.RequiredTool
+	for _, provider := range snap.Tools() {
+		tools = append(tools, print.RequiredTool{
+			Provider:  provider,
+			UsedFor:   strings.Join(usedFor[provider], ", "),
+			Connected: connected[provider],
+		})
+	}
+
+	return tools
+}
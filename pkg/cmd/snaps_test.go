@@ -0,0 +1,448 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/snapmaster-io/snap/pkg/snaptest"
+)
+
+func TestSnapsList(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	runSnap(t, server, "", "snaps", "list").expect(t, 0, "snaptest/gke-deploy", "deploy to gke")
+
+	r := runSnap(t, server, "", "snaps", "list", "--format", "json")
+	r.expect(t, 0)
+	var response struct {
+		Data []struct {
+			SnapID string `json:"snapId"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(r.stdout), &response); err != nil {
+		t.Fatalf("could not parse the json output: %s\n%s", err, r.stdout)
+	}
+	if len(response.Data) != 1 || response.Data[0].SnapID != "snaptest/gke-deploy" {
+		t.Errorf("snaps list returned %+v", response.Data)
+	}
+}
+
+func TestSnapsCreate(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "snap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "build.yaml")
+	text := strings.Replace(testSnapDeploy, "gke-deploy", "build", 1)
+	if err := ioutil.WriteFile(file, []byte(text), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	runSnap(t, server, "", "snaps", "create", file, "--dry-run").expect(t, 0, "dry run: POST")
+	if _, ok := server.Snap("snaptest/build"); ok {
+		t.Fatal("a dry run created the snap")
+	}
+
+	runSnap(t, server, "", "snaps", "create", file).expect(t, 0, "snaptest/build")
+	snap, ok := server.Snap("snaptest/build")
+	if !ok {
+		t.Fatal("the snap wasn't created")
+	}
+	if snap.Text != text {
+		t.Errorf("created definition %q, want %q", snap.Text, text)
+	}
+}
+
+func TestSnapsCreateInvalid(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	runSnap(t, server, "", "snaps", "create", "missing.yaml").expect(t, 1, "could not read snap definition file missing.yaml")
+	if bodies := posts(server, "/snaps"); len(bodies) > 0 {
+		t.Errorf("posted %v", bodies)
+	}
+}
+
+func TestUnauthorized(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	r := runSnapEnv(t, server, []string{"SNAP_ACCESSTOKEN=expired"}, "", "snaps", "list")
+	r.expect(t, 1, "token expired; please log in again")
+}
+
+func TestSnapsUpdate(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	text := strings.Replace(testSnapDeploy, "deploy to gke", "deploy to gke (prod)", 1)
+	runSnap(t, server, text, "snaps", "update", "snaptest/gke-deploy", "-").expect(t, 0, "deploy to gke (prod)")
+	if snap, _ := server.Snap("snaptest/gke-deploy"); snap.Text != text {
+		t.Errorf("updated definition %q, want %q", snap.Text, text)
+	}
+}
+
+func TestSnapsUpdateNotStored(t *testing.T) {
+	tests := []struct {
+		name   string
+		option snaptest.Option
+	}{
+		{"edits ignored", snaptest.WithoutDefinitionEdits()},
+		{"edit action rejected", snaptest.WithoutEditAction()},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, _ := newTestServer(t, test.option)
+			defer server.Close()
+
+			text := strings.Replace(testSnapDeploy, "deploy to gke", "deploy to gke (prod)", 1)
+			r := runSnap(t, server, text, "snaps", "update", "snaptest/gke-deploy", "-")
+			r.expect(t, 1, "could not update snap snaptest/gke-deploy", "the server doesn't support in-place edits of snap definitions")
+			if strings.Contains(r.stdout, "Snap Values") {
+				t.Errorf("reported success before the update was verified:\n%s", r.stdout)
+			}
+		})
+	}
+}
+
+func TestSnapsApplyRetrievalError(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	// the service fails to retrieve the snap, which isn't the same as not having it
+	var posted []string
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/profile":
+			w.Write([]byte(`{"account": "snaptest"}`))
+		case r.Method == "POST":
+			posted = append(posted, r.URL.Path)
+			w.Write([]byte(`{"status": "success"}`))
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("upstream unavailable"))
+		}
+	}))
+	defer failing.Close()
+
+	file := writeTestFile(t, "deploy.yaml", testSnapDeploy)
+	runSnapEnv(t, server, []string{"SNAP_APIURL=" + failing.URL}, "", "snaps", "apply", file).
+		expect(t, 1, "could not apply", "could not parse the response")
+	failing.Close()
+	if len(posted) > 0 {
+		t.Errorf("posted to %v after a failed retrieval", posted)
+	}
+}
+
+func TestSnapsEdit(t *testing.T) {
+	for _, ignoreEdits := range []bool{false, true} {
+		var options []snaptest.Option
+		if ignoreEdits {
+			options = append(options, snaptest.WithoutDefinitionEdits())
+		}
+		server, _ := newTestServer(t, options...)
+		defer server.Close()
+		tmp, err := ioutil.TempDir("", "snap")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(tmp)
+
+		env := []string{"TMPDIR=" + tmp, "VISUAL=sed -i 's/deploy to gke/deploy to gke (prod)/'"}
+		r := runSnapEnv(t, server, env, "", "snaps", "edit", "snaptest/gke-deploy", "--yes")
+		files, _ := filepath.Glob(filepath.Join(tmp, "snap-*.yaml"))
+		if !ignoreEdits {
+			r.expect(t, 0, "deploy to gke (prod)")
+			if len(files) > 0 {
+				t.Errorf("left the edited definition in %v after the update", files)
+			}
+			continue
+		}
+
+		// the edits are kept for a retry when the update fails
+		r.expect(t, 1, "doesn't support in-place edits", "the edited definition is in")
+		if len(files) != 1 || !strings.Contains(r.stdout, files[0]) {
+			t.Fatalf("the edited definition isn't kept in a file named in the output (%v):\n%s", files, r.stdout)
+		}
+		if edited, _ := ioutil.ReadFile(files[0]); !strings.Contains(string(edited), "deploy to gke (prod)") {
+			t.Errorf("the kept definition doesn't have the edits:\n%s", edited)
+		}
+	}
+}
+
+func TestSnapsGraph(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	graph := []string{"snaps", "graph", "snaptest/gke-deploy"}
+	runCommandTests(t, server, []commandTest{
+		{name: "ascii", args: graph, contains: []string{"gke-deploy", "docker", "build"}},
+		{name: "mermaid", args: append(graph, "--graph-format", "mermaid", "--markdown"), contains: []string{"```mermaid\nflowchart LR"}},
+		{name: "dot", args: append(graph, "--graph-format", "dot"), contains: []string{`digraph "gke-deploy" {`}},
+		// the global --format still selects json output
+		{name: "json", args: append(graph, "--format", "json"), contains: []string{`"trigger"`, `"actions"`}},
+		{name: "json shorthand", args: append(graph, "-f", "json"), contains: []string{`"trigger"`}},
+		{name: "unknown format", args: append(graph, "--graph-format", "svg"), status: 1, contains: []string{"unknown graph format 'svg'"}},
+	})
+}
+
+func TestSnapsEditNotUpdated(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	edit := func(visual string) []string { return []string{"TMPDIR=" + os.TempDir(), "VISUAL=" + visual} }
+	runCommandTests(t, server, []commandTest{
+		{name: "no changes", env: edit("true"), args: []string{"snaps", "edit", "snaptest/gke-deploy"}, contains: []string{"no changes to snap snaptest/gke-deploy"}},
+		{name: "editor fails", env: edit("false"), args: []string{"snaps", "edit", "snaptest/gke-deploy"}, status: 1, contains: []string{"could not edit the snap definition"}},
+		{
+			// the answer to "Edit it again?" is no
+			name:     "renamed",
+			env:      edit("sed -i 's/name: gke-deploy/name: other/'"),
+			stdin:    "n\n",
+			args:     []string{"snaps", "edit", "snaptest/gke-deploy"},
+			status:   1,
+			contains: []string{"name must stay gke-deploy", "snap snaptest/gke-deploy was not updated"},
+		},
+	})
+	if bodies := posts(server, "/snaps"); len(bodies) > 0 {
+		t.Errorf("posted %v", bodies)
+	}
+}
+
+func TestSnapsGet(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	runCommandTests(t, server, []commandTest{
+		{name: "yaml", args: []string{"snaps", "get", "snaptest/gke-deploy"}, contains: []string{"name: gke-deploy", "image: $cluster"}},
+		{name: "json", args: []string{"snaps", "get", "snaptest/gke-deploy", "--format", "json"}, contains: []string{`"snapId": "snaptest/gke-deploy"`}},
+	})
+}
+
+func TestSnapsDelete(t *testing.T) {
+	build := strings.Replace(testSnapDeploy, "gke-deploy", "build", 1)
+	tests := []struct {
+		commandTest
+		deleted []string
+	}{
+		{
+			commandTest: commandTest{name: "one snap", args: []string{"snaps", "delete", "snaptest/build", "--yes"}},
+			deleted:     []string{"snaptest/build"},
+		},
+		{
+			commandTest: commandTest{name: "selected by trigger", args: []string{"snaps", "delete", "--trigger", "github", "--yes"}, contains: []string{"Delete"}},
+			deleted:     []string{"snaptest/build", "snaptest/gke-deploy"},
+		},
+		{
+			commandTest: commandTest{name: "no match", args: []string{"snaps", "delete", "--trigger", "slack", "--yes"}, contains: []string{"no snaps match the selectors"}},
+		},
+		{
+			commandTest: commandTest{name: "dry run", args: []string{"snaps", "delete", "snaptest/build", "--yes", "--dry-run"}, contains: []string{"dry run: POST"}},
+		},
+		{
+			commandTest: commandTest{name: "nothing selected", args: []string{"snaps", "delete"}, status: 1, contains: []string{"requires at least one ID or a selector"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, _ := newTestServer(t)
+			defer server.Close()
+			if _, err := server.AddSnap("snaptest/build", build, true); err != nil {
+				t.Fatal(err)
+			}
+
+			runSnapEnv(t, server, test.env, test.stdin, test.args...).expect(t, test.status, test.contains...)
+			for _, snapID := range []string{"snaptest/build", "snaptest/gke-deploy"} {
+				_, exists := server.Snap(snapID)
+				if deleted := containsFold(test.deleted, snapID); exists == deleted {
+					t.Errorf("snap %s exists: %v, want %v", snapID, exists, !deleted)
+				}
+			}
+		})
+	}
+}
+
+func TestSnapsApply(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	build := strings.Replace(testSnapDeploy, "gke-deploy", "build", 1)
+	created := writeTestFile(t, "build.yaml", build)
+	changed := writeTestFile(t, "build.yaml", strings.Replace(build, "cluster name", "target cluster", 1))
+	templated := writeTestFile(t, "build.yaml", strings.Replace(build, "cluster name", "${description}", 1))
+	invalid := writeTestFile(t, "build.yaml", "name: build\n")
+
+	// the steps run in order against the same server
+	runCommandTests(t, server, []commandTest{
+		{name: "create", args: []string{"snaps", "apply", created}, contains: []string{"snaptest/build"}},
+		{name: "no changes", args: []string{"snaps", "apply", created}, contains: []string{"no changes to snap snaptest/build"}},
+		{name: "update", args: []string{"snaps", "apply", changed}, contains: []string{"-    description: cluster name", "+    description: target cluster"}},
+		{name: "variables", args: []string{"snaps", "apply", templated, "--var", "description=cluster to build in"}, contains: []string{"+    description: cluster to build in"}},
+		{name: "undefined variable", args: []string{"snaps", "apply", templated, "--var", "other=x"}, status: 1, contains: []string{"description"}},
+		{name: "invalid", args: []string{"snaps", "apply", invalid}, status: 1, contains: []string{"is not a valid snap definition"}},
+	})
+
+	snap, _ := server.Snap("snaptest/build")
+	if !strings.Contains(snap.Text, "description: cluster to build in") {
+		t.Errorf("applied definition:\n%s", snap.Text)
+	}
+}
+
+func TestSnapsValidate(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	valid := writeTestFile(t, "deploy.yaml", testSnapDeploy)
+	templated := writeTestFile(t, "deploy.yaml", strings.Replace(testSnapDeploy, "trigger: github", "trigger: ${trigger}", 1))
+	invalid := writeTestFile(t, "invalid.yaml", "name: invalid\nactions: []\n")
+
+	runCommandTests(t, server, []commandTest{
+		{name: "valid", args: []string{"snaps", "validate", valid}, contains: []string{"is valid: snap gke-deploy, triggered by github, with 1 action"}},
+		{name: "variables", args: []string{"snaps", "validate", templated, "--var", "trigger=gitlab"}, contains: []string{"triggered by gitlab"}},
+		{name: "invalid", args: []string{"snaps", "validate", invalid}, status: 1, contains: []string{"invalid.yaml is not valid"}},
+		{name: "one of several invalid", args: []string{"snaps", "validate", valid, invalid}, status: 1, contains: []string{"deploy.yaml is valid", "invalid.yaml is not valid"}},
+		{name: "missing file", args: []string{"snaps", "validate", "missing.yaml"}, status: 1, contains: []string{"missing.yaml"}},
+	})
+
+	// nothing is uploaded
+	if requests := server.Requests(); len(requests) > 0 {
+		t.Errorf("requested %v", requests)
+	}
+}
+
+func TestSnapsRender(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	templated := writeTestFile(t, "deploy.yaml", strings.Replace(testSnapDeploy, "trigger: github", "trigger: ${trigger}", 1))
+	gotemplate := writeTestFile(t, "deploy.yaml", strings.Replace(testSnapDeploy, "trigger: github", "trigger: {{.trigger}}", 1))
+	vars := writeTestFile(t, "vars.yaml", "trigger: bitbucket\n")
+
+	runCommandTests(t, server, []commandTest{
+		{name: "var", args: []string{"snaps", "render", templated, "--var", "trigger=gitlab"}, contains: []string{"trigger: gitlab", "image: $cluster"}},
+		{name: "var file", args: []string{"snaps", "render", templated, "--var-file", vars}, contains: []string{"trigger: bitbucket"}},
+		{name: "var overrides var file", args: []string{"snaps", "render", templated, "--var-file", vars, "--var", "trigger=gitlab"}, contains: []string{"trigger: gitlab"}},
+		{name: "go template", args: []string{"snaps", "render", gotemplate, "--var", "trigger=gitlab"}, contains: []string{"trigger: gitlab"}},
+		{name: "json", args: []string{"snaps", "render", templated, "--var", "trigger=gitlab", "--format", "json"}, contains: []string{`trigger: gitlab`, `"text"`}},
+		{name: "without variables", args: []string{"snaps", "render", templated}, contains: []string{"trigger: ${trigger}"}},
+		{name: "undefined variable", args: []string{"snaps", "render", templated, "--var", "other=x"}, status: 1, contains: []string{"trigger"}},
+	})
+}
+
+func TestSnapsHistory(t *testing.T) {
+	changed := strings.Replace(testSnapDeploy, "deploy to gke", "deploy to gke (prod)", 1)
+
+	t.Run("server", func(t *testing.T) {
+		server, _ := newTestServer(t, snaptest.WithHistory())
+		defer server.Close()
+		runSnap(t, server, changed, "snaps", "update", "snaptest/gke-deploy", "-").expect(t, 0)
+
+		runCommandTests(t, server, []commandTest{
+			{name: "table", args: []string{"snaps", "history", "snaptest/gke-deploy"}, contains: []string{"History of snaptest/gke-deploy", "create", "edit"}},
+			{name: "json", args: []string{"snaps", "history", "snaptest/gke-deploy", "--format", "json"}, contains: []string{`"action": "edit"`}},
+		})
+	})
+
+	t.Run("local journal", func(t *testing.T) {
+		server, _ := newTestServer(t)
+		defer server.Close()
+		home, err := ioutil.TempDir("", "snap")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(home)
+		env := []string{"HOME=" + home}
+
+		runCommandTests(t, server, []commandTest{
+			{name: "empty", env: env, args: []string{"snaps", "history", "snaptest/gke-deploy"}, contains: []string{"snap snaptest/gke-deploy has no history"}},
+			{name: "update", env: env, stdin: changed, args: []string{"snaps", "update", "snaptest/gke-deploy", "-"}},
+			{name: "table", env: env, args: []string{"snaps", "history", "snaptest/gke-deploy"}, contains: []string{"History of snaptest/gke-deploy (local journal)", "observed", "edit"}},
+		})
+	})
+}
+
+func TestSnapsShowAndRollback(t *testing.T) {
+	changed := strings.Replace(testSnapDeploy, "deploy to gke", "deploy to gke (prod)", 1)
+
+	tests := []struct {
+		name    string
+		options []snaptest.Option
+	}{
+		{"server", []snaptest.Option{snaptest.WithHistory()}},
+		{"local journal", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, _ := newTestServer(t, test.options...)
+			defer server.Close()
+			home, err := ioutil.TempDir("", "snap")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(home)
+			env := []string{"HOME=" + home}
+
+			// revision 1 is the original definition, and revision 2 the changed one
+			runSnapEnv(t, server, env, changed, "snaps", "update", "snaptest/gke-deploy", "-").expect(t, 0)
+
+			r := runSnapEnv(t, server, env, "", "snaps", "show", "snaptest/gke-deploy@1")
+			r.expect(t, 0, "description: deploy to gke")
+			if strings.Contains(r.stdout, "(prod)") {
+				t.Errorf("revision 1 has the changed definition:\n%s", r.stdout)
+			}
+			runCommandTests(t, server, []commandTest{
+				{name: "show revision", env: env, args: []string{"snaps", "show", "snaptest/gke-deploy@2"}, contains: []string{"description: deploy to gke (prod)"}},
+				{name: "show latest", env: env, args: []string{"snaps", "show", "snaptest/gke-deploy"}, contains: []string{"description: deploy to gke (prod)"}},
+				{name: "show diff", env: env, args: []string{"snaps", "show", "snaptest/gke-deploy@2", "--diff"}, contains: []string{
+					"--- snaptest/gke-deploy@1", "+++ snaptest/gke-deploy@2", "-description: deploy to gke\n", "+description: deploy to gke (prod)\n",
+				}},
+				{name: "show json", env: env, args: []string{"snaps", "show", "snaptest/gke-deploy@2", "--format", "json"}, contains: []string{`"revision": 2`, `"action": "edit"`}},
+				{name: "show missing revision", env: env, args: []string{"snaps", "show", "snaptest/gke-deploy@5"}, status: 1, contains: []string{"has no revision 5"}},
+				{name: "show invalid revision", env: env, args: []string{"snaps", "show", "snaptest/gke-deploy@latest"}, status: 1, contains: []string{`invalid revision "latest"`}},
+				{name: "rollback invalid revision", env: env, args: []string{"snaps", "rollback", "snaptest/gke-deploy", "0"}, status: 1, contains: []string{`invalid revision "0"`}},
+				{name: "rollback to the current definition", env: env, args: []string{"snaps", "rollback", "snaptest/gke-deploy", "2"}, contains: []string{"already has the definition of revision 2"}},
+			})
+
+			// nothing but the update was posted
+			if bodies := posts(server, "/snaps"); len(bodies) != 1 {
+				t.Fatalf("%d posts, want 1: %v", len(bodies), bodies)
+			}
+
+			runSnapEnv(t, server, env, "", "snaps", "rollback", "snaptest/gke-deploy", "@1", "--yes").expect(t, 0, "+description: deploy to gke\n")
+
+			bodies := posts(server, "/snaps")
+			if len(bodies) != 2 {
+				t.Fatalf("%d posts, want 2: %v", len(bodies), bodies)
+			}
+			var posted map[string]interface{}
+			if err := json.Unmarshal([]byte(bodies[1]), &posted); err != nil {
+				t.Fatal(err)
+			}
+			if posted["action"] != "edit" || posted["snapId"] != "snaptest/gke-deploy" || posted["definition"] != testSnapDeploy || posted["rollback"] != float64(1) {
+				t.Errorf("posted %v for the rollback", posted)
+			}
+			if snap, _ := server.Snap("snaptest/gke-deploy"); snap.Text != testSnapDeploy {
+				t.Errorf("rolled back definition %q, want %q", snap.Text, testSnapDeploy)
+			}
+
+			// the rollback is a new revision
+			runSnapEnv(t, server, env, "", "snaps", "history", "snaptest/gke-deploy").expect(t, 0, "rollback to 1")
+			r = runSnapEnv(t, server, env, "", "snaps", "show", "snaptest/gke-deploy@3")
+			r.expect(t, 0, "description: deploy to gke")
+			if strings.Contains(r.stdout, "(prod)") {
+				t.Errorf("revision 3 has the changed definition:\n%s", r.stdout)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/snapmaster-io/snap/pkg/runner"
+	"github.com/snapmaster-io/snap/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// testCmd represents the test command
+var testCmd = &cobra.Command{
+	Use:   "test [path]...",
+	Short: "Run the test suites of snap definitions locally",
+	Long: `Run the test suites of snap definitions locally, without a server.
+
+Test suites are files named *.snaptest.yaml, found by searching the paths (the current
+directory by default) recursively.  Each suite names a snap definition, and has tests that
+run it against mock tools - like snap run --local - with parameter values, a trigger event,
+and mocked action outputs, and check which actions run, in what order, and with what
+resolved parameters:
+
+  snap: deploy.yaml             # relative to the suite file
+  varFiles: [env/prod.yaml]     # optional, like --var-file (and vars, like --var)
+  tests:
+    - name: notifies the channel
+      params:
+        channel: "#deploys"
+      event:                    # or eventFile: push.json
+        ref: refs/heads/main
+      outputs:                  # mocked action outputs, as in a --fixtures file
+        - step: build
+          output:
+            data:
+              stdout: built
+      expect:
+        state: complete         # or error, if an action fails
+        actions: [build, notify]
+        parameters:
+          notify:
+            channel: "#deploys"
+
+The results are reported in TAP (the default) or JUnit XML, and the command exits with
+status 1 if a test fails.`,
+	Example: `  snap test snaps/ --reporter junit --output report.xml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		reporter, _ := cmd.Flags().GetString("reporter")
+		var write func(io.Writer, []runner.Result) error
+		switch reporter {
+		case "tap":
+			write = runner.WriteTAP
+		case "junit":
+			write = runner.WriteJUnit
+		default:
+			utils.PrintError(fmt.Sprintf("unknown reporter %s (must be tap or junit)", reporter))
+			os.Exit(1)
+		}
+
+		paths := args
+		if len(paths) == 0 {
+			paths = []string{"."}
+		}
+		suites, err := runner.Discover(paths)
+		if err != nil {
+			utils.PrintErrorMessage("could not find test suites", err)
+			os.Exit(1)
+		}
+		if len(suites) == 0 {
+			utils.PrintError(fmt.Sprintf("no test suites (*%s files) found", runner.SuiteSuffix))
+			os.Exit(1)
+		}
+
+		var results []runner.Result
+		for _, path := range suites {
+			suite, err := runner.ReadSuite(path)
+			if err == nil && len(suite.Tests) == 0 {
+				err = fmt.Errorf("test suite %s has no tests", path)
+			}
+			if err != nil {
+				// a suite that can't be read is reported as a failed test
+				results = append(results, runner.Result{Suite: path, Name: "read suite", Failures: []string{err.Error()}})
+				continue
+			}
+			results = append(results, suite.Run()...)
+		}
+
+		out := os.Stdout
+		output, _ := cmd.Flags().GetString("output")
+		if output != "" {
+			if out, err = os.Create(output); err != nil {
+				utils.PrintErrorMessage(fmt.Sprintf("could not create %s", output), err)
+				os.Exit(1)
+			}
+		}
+		err = write(out, results)
+		if output != "" {
+			if closeErr := out.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		if err != nil {
+			utils.PrintErrorMessage("could not write the test report", err)
+			os.Exit(1)
+		}
+
+		for _, result := range results {
+			if !result.Passed() {
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(testCmd)
+	testCmd.Flags().StringP("reporter", "", "tap", "the format of the test report: tap or junit")
+	testCmd.Flags().StringP("output", "o", "", "write the test report to a file instead of stdout")
+	testCmd.RegisterFlagCompletionFunc("reporter", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"tap", "junit"}, cobra.ShellCompDirectiveNoFileComp
+	})
+}
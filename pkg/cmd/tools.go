@@ -1,10 +1,12 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 
 	"github.com/snapmaster-io/snap/pkg/api"
+	"github.com/snapmaster-io/snap/pkg/definition"
 	"github.com/snapmaster-io/snap/pkg/print"
 	"github.com/snapmaster-io/snap/pkg/utils"
 	"github.com/spf13/cobra"
@@ -23,12 +25,114 @@ var toolsCmd = &cobra.Command{
 	},
 }
 
+// getToolActionCmd represents the get tool action subcommand
+var getToolActionCmd = &cobra.Command{
+	Use:   "action [tool] [action]",
+	Short: "Get the parameters of a tool's action",
+	Long: `Get the parameters of a tool's action.
+
+Prints the name, type, and description of each parameter, followed by an example 
+config entry for a snap step that uses the action.`,
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeToolOperations("actions"),
+	Run: func(cmd *cobra.Command, args []string) {
+		// retrieve tool and action as the first two arguments
+		toolName := args[0]
+		actionName := args[1]
+
+		tool := getToolDefinition(toolName)
+		action := tool.FindAction(actionName)
+		if action == nil {
+			utils.PrintError(fmt.Sprintf("action %s not found for tool %s", actionName, toolName))
+			os.Exit(1)
+		}
+
+		format, _ := rootCmd.PersistentFlags().GetString("format")
+		if format == "json" {
+			payload, _ := json.Marshal(action)
+			print.JSON(payload)
+			return
+		}
+
+		print.ToolOperationDetails(toolName, action, false)
+	},
+}
+
+// listToolActionsCmd represents the list tool actions subcommand
+var listToolActionsCmd = &cobra.Command{
+	Use:               "actions [tool]",
+	Short:             "List the actions of a tool",
+	Long:              `List the actions of a tool.`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeTools,
+	Run: func(cmd *cobra.Command, args []string) {
+		// retrieve tool as the first argument
+		toolName := args[0]
+		tool := getToolDefinition(toolName)
+
+		format, _ := rootCmd.PersistentFlags().GetString("format")
+		if format == "json" {
+			payload, _ := json.Marshal(tool.Actions)
+			print.JSON(payload)
+			return
+		}
+
+		print.ToolOperationsTable(fmt.Sprintf("Actions of %s", toolName), tool.Actions)
+	},
+}
+
+// listToolTriggersCmd represents the list tool triggers subcommand
+var listToolTriggersCmd = &cobra.Command{
+	Use:   "triggers [tool] [trigger]",
+	Short: "List the triggers of a tool",
+	Long: `List the triggers of a tool.
+
+If a trigger name is provided, prints the name, type, and description of each of its
+parameters, followed by an example config entry for a snap trigger.`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeToolOperations("triggers"),
+	Run: func(cmd *cobra.Command, args []string) {
+		// retrieve tool as the first argument
+		toolName := args[0]
+		tool := getToolDefinition(toolName)
+
+		format, _ := rootCmd.PersistentFlags().GetString("format")
+
+		// second form of the command (triggers [tool] [trigger])
+		if len(args) > 1 {
+			trigger := tool.FindTrigger(args[1])
+			if trigger == nil {
+				utils.PrintError(fmt.Sprintf("trigger %s not found for tool %s", args[1], toolName))
+				os.Exit(1)
+			}
+
+			if format == "json" {
+				payload, _ := json.Marshal(trigger)
+				print.JSON(payload)
+				return
+			}
+
+			print.ToolOperationDetails(toolName, trigger, true)
+			return
+		}
+
+		if format == "json" {
+			payload, _ := json.Marshal(tool.Triggers)
+			print.JSON(payload)
+			return
+		}
+
+		print.ToolOperationsTable(fmt.Sprintf("Triggers of %s", toolName), tool.Triggers)
+	},
+}
+
 // getToolCmd represents the get tool subcommand
 var getToolCmd = &cobra.Command{
-	Use:   "get [tool]",
-	Short: "Get a description of a tool",
-	Long:  `Get a description of a tool.`,
-	Args:  cobra.MinimumNArgs(1),
+	Use:               "get [tool]",
+	Short:             "Get a description of a tool",
+	Long:              `Get a description of a tool.`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeTools,
 	Run: func(cmd *cobra.Command, args []string) {
 		// retrieve tool as the first argument
 		tool := args[0]
@@ -92,6 +196,72 @@ var listToolsCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(toolsCmd)
+	toolsCmd.AddCommand(getToolActionCmd)
 	toolsCmd.AddCommand(getToolCmd)
+	toolsCmd.AddCommand(listToolActionsCmd)
 	toolsCmd.AddCommand(listToolsCmd)
+	toolsCmd.AddCommand(listToolTriggersCmd)
+
+	pickable(getToolActionCmd, false, toolPicker, toolOperationPicker("actions"))
+	pickable(getToolCmd, false, toolPicker)
+	pickable(listToolActionsCmd, false, toolPicker)
+	pickable(listToolTriggersCmd, false, toolPicker)
+}
+
+// getToolDefinition retrieves the tools library and parses the definition of a tool
+func getToolDefinition(toolName string) *definition.Tool {
+	// execute the API call
+	response, err := api.Get("/connections")
+	if err != nil {
+		utils.PrintErrorMessage("could not retrieve data", err)
+		os.Exit(1)
+	}
+
+	// select the definition of the entry that matches the provider name
+	text := gjson.GetBytes(response, fmt.Sprintf("data.#(provider==%s).definition.text", toolName))
+	if !text.Exists() {
+		utils.PrintError(fmt.Sprintf("tool %s not found", toolName))
+		os.Exit(1)
+	}
+
+	tool, err := definition.ParseTool([]byte(text.String()))
+	if err != nil {
+		utils.PrintErrorMessage(fmt.Sprintf("could not parse the definition of tool %s", toolName), err)
+		os.Exit(1)
+	}
+
+	return tool
+}
+
+// fetchTools retrieves the tools library, including whether each tool is connected
+func fetchTools() ([]print.Tool, error) {
+	// execute the API call
+	response, err := api.Get("/connections")
+	if err != nil {
+		return nil, err
+	}
+
+	var toolsResponse print.ToolsResponse
+	json.Unmarshal(response, &toolsResponse)
+	if toolsResponse.Status != "success" {
+		return nil, fmt.Errorf("could not retrieve tools: %s", toolsResponse.Message)
+	}
+
+	return toolsResponse.Data, nil
+}
+
+// getConnectedTools retrieves the tools library and returns whether each tool is connected
+func getConnectedTools() map[string]bool {
+	tools, err := fetchTools()
+	if err != nil {
+		utils.PrintErrorMessage("could not retrieve data", err)
+		os.Exit(1)
+	}
+
+	connected := make(map[string]bool)
+	for _, tool := range tools {
+		connected[tool.Provider] = tool.Connected != ""
+	}
+
+	return connected
 }
@@ -0,0 +1,31 @@
+package cmd
+
+import "testing"
+
+func TestTools(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+	server.AddTool("slack", "simple", "name: slack\ntype: simple\n")
+
+	runCommandTests(t, server, []commandTest{
+		{name: "list", args: []string{"tools", "list"}, contains: []string{"Tools Library", "docker", "github", "slack"}},
+		{name: "list json", args: []string{"tools", "list", "--format", "json"}, contains: []string{`"provider": "slack"`}},
+		{name: "get", args: []string{"tools", "get", "docker"}, contains: []string{"name: docker", "description: registry password"}},
+		{name: "get json", args: []string{"tools", "get", "docker", "--format", "json"}, contains: []string{`"provider": "docker"`}},
+		{name: "actions", args: []string{"tools", "actions", "docker"}, contains: []string{"Actions of docker", "build an image"}},
+		{
+			name:     "action",
+			args:     []string{"tools", "action", "docker", "build"},
+			contains: []string{"Parameters of action docker:build", "provider: docker", "image: <string>  # (required)"},
+		},
+		{name: "missing action", args: []string{"tools", "action", "docker", "push"}, status: 1, contains: []string{"action push not found for tool docker"}},
+		{name: "missing tool", args: []string{"tools", "actions", "jenkins"}, status: 1, contains: []string{"tool jenkins not found"}},
+		{name: "triggers", args: []string{"tools", "triggers", "github"}, contains: []string{"Triggers of github", "fires on a push to a repo"}},
+		{
+			name:     "trigger",
+			args:     []string{"tools", "triggers", "github", "push"},
+			contains: []string{"Parameters of trigger github:push", "event: push  # one of: push", "repo: <string>  # (required)"},
+		},
+		{name: "missing trigger", args: []string{"tools", "triggers", "github", "pull"}, status: 1, contains: []string{"trigger pull not found for tool github"}},
+	})
+}
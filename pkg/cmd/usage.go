@@ -0,0 +1,281 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/snapmaster-io/snap/pkg/api"
+	"github.com/snapmaster-io/snap/pkg/definition"
+	"github.com/snapmaster-io/snap/pkg/print"
+	"github.com/snapmaster-io/snap/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// getActiveSnaps retrieves all of the user's active snaps
+func getActiveSnaps() []print.ActiveSnap {
+	response, err := api.Get("/activesnaps")
+	if err != nil {
+		utils.PrintErrorMessage("could not retrieve data", err)
+		os.Exit(1)
+	}
+
+	var activeSnapsResponse print.ActiveSnapsResponse
+	json.Unmarshal(response, &activeSnapsResponse)
+	if activeSnapsResponse.Status != "success" {
+		utils.PrintStatus(activeSnapsResponse.Status, activeSnapsResponse.Message)
+		os.Exit(1)
+	}
+
+	return activeSnapsResponse.Data
+}
+
+// activeSnapParams is an active snap, along with the parameter values it was activated with
+type activeSnapParams struct {
+	print.ActiveSnap
+	Params []map[string]string `json:"params"`
+}
+
+// getActiveSnapParams retrieves all of the user's active snaps, with their parameter values
+func getActiveSnapParams() []activeSnapParams {
+	response, err := api.Get("/activesnaps")
+	if err != nil {
+		utils.PrintErrorMessage("could not retrieve data", err)
+		os.Exit(1)
+	}
+
+	var activeSnapsResponse struct {
+		Status  string             `json:"status"`
+		Message string             `json:"message"`
+		Data    []activeSnapParams `json:"data"`
+	}
+	json.Unmarshal(response, &activeSnapsResponse)
+	if activeSnapsResponse.Status != "success" {
+		utils.PrintStatus(activeSnapsResponse.Status, activeSnapsResponse.Message)
+		os.Exit(1)
+	}
+
+	return activeSnapsResponse.Data
+}
+
+// getSnaps retrieves all of the user's snaps
+func getSnaps() []print.Snap {
+	response, err := api.Get("/snaps")
+	if err != nil {
+		utils.PrintErrorMessage("could not retrieve data", err)
+		os.Exit(1)
+	}
+
+	var snapsResponse print.SnapsResponse
+	json.Unmarshal(response, &snapsResponse)
+	if snapsResponse.Status != "success" {
+		utils.PrintStatus(snapsResponse.Status, snapsResponse.Message)
+		os.Exit(1)
+	}
+
+	return snapsResponse.Data
+}
+
+// getLogCounts retrieves all of the user's logs, and counts them by active snap ID
+func getLogCounts() map[string]int {
+	response, err := api.Get("/logs")
+	if err != nil {
+		utils.PrintErrorMessage("could not retrieve data", err)
+		os.Exit(1)
+	}
+
+	var logsResponse print.ActiveSnapLogsResponse
+	json.Unmarshal(response, &logsResponse)
+
+	counts := make(map[string]int)
+	for _, log := range logsResponse.Data {
+		counts[log.ActiveSnapID]++
+	}
+
+	return counts
+}
+
+// snapDefinitions retrieves and parses snap definitions, caching them by snap ID
+type snapDefinitions map[string]*definition.Snap
+
+// get returns the definition of the snap, or nil if it couldn't be retrieved or parsed
+func (d snapDefinitions) get(snapID string) *definition.Snap {
+	snap, _ := d.lookup(snapID)
+	return snap
+}
+
+// lookup returns the definition of the snap, or the error that kept it from being retrieved
+// or parsed.  Only the definitions that were found are cached.
+func (d snapDefinitions) lookup(snapID string) (*definition.Snap, error) {
+	if snap, found := d[snapID]; found {
+		return snap, nil
+	}
+
+	text, err := fetchSnapDefinition(snapID)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := definition.Parse([]byte(text))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the definition of snap %s: %s", snapID, err)
+	}
+	d[snapID] = snap
+
+	return snap, nil
+}
+
+// usageErrors collects the snaps whose usage of a tool couldn't be determined, once each
+type usageErrors map[string]error
+
+// lookup returns the definition of the snap from the definitions, recording the error if
+// it couldn't be retrieved or parsed
+func (e usageErrors) lookup(definitions snapDefinitions, snapID string) *definition.Snap {
+	snap, err := definitions.lookup(snapID)
+	if err != nil {
+		e[snapID] = err
+	}
+
+	return snap
+}
+
+// err returns an error listing the snaps whose usage couldn't be determined, or nil
+func (e usageErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	var messages []string
+	for snapID, err := range e {
+		messages = append(messages, fmt.Sprintf("%s: %s", snapID, err))
+	}
+	sort.Strings(messages)
+	return fmt.Errorf("could not determine the tool usage of %s (%s)", plural(len(e), "snap"), strings.Join(messages, "; "))
+}
+
+// getToolUsage finds the steps of the user's snaps and active snaps that use the tool, and
+// if credentialSet isn't empty, that may use that credential set of the tool.  The usage of
+// snaps whose definitions can't be retrieved is only checked for their trigger, and they are
+// reported in the error.
+func getToolUsage(tool string, credentialSet string) (print.ToolUsageResponse, error) {
+	definitions := make(snapDefinitions)
+	activeSnaps, activeErr := getActiveSnapUsage(definitions, tool, credentialSet)
+	usage := print.ToolUsageResponse{
+		Tool:          tool,
+		CredentialSet: credentialSet,
+		Snaps:         []print.ToolUsage{},
+		ActiveSnaps:   activeSnaps,
+	}
+
+	errs := make(usageErrors)
+	for _, s := range getSnaps() {
+		for _, u := range snapUsage(errs.lookup(definitions, s.SnapID), s.Provider, tool, credentialSet, nil) {
+			u.SnapID = s.SnapID
+			usage.Snaps = append(usage.Snaps, u)
+		}
+	}
+	if activeErr != nil {
+		return usage, activeErr
+	}
+
+	return usage, errs.err()
+}
+
+// getActiveSnapUsage finds the steps of the user's active snaps that use the tool, and if
+// credentialSet isn't empty, that may use that credential set of the tool.  Parameter
+// references in a step's connection are resolved with the active snap's parameter values.
+// The usage of active snaps whose definitions can't be retrieved is only checked for their
+// trigger, and their snaps are reported in the error.
+func getActiveSnapUsage(definitions snapDefinitions, tool string, credentialSet string) ([]print.ToolUsage, error) {
+	usage := []print.ToolUsage{}
+	errs := make(usageErrors)
+	for _, a := range getActiveSnapParams() {
+		values := make(map[string]string)
+		for _, param := range a.Params {
+			values[param["name"]] = param["value"]
+		}
+
+		for _, u := range snapUsage(errs.lookup(definitions, a.SnapID), a.Provider, tool, credentialSet, values) {
+			u.SnapID = a.SnapID
+			u.ActiveSnapID = a.ActiveSnapID
+			u.State = a.State
+			usage = append(usage, u)
+		}
+	}
+
+	return usage, errs.err()
+}
+
+// snapUsage returns the steps of the snap definition that use the tool, and if credentialSet
+// isn't empty, that may use that credential set: those that name it, and those that don't name
+// a credential set or whose connection can't be resolved.  If the definition is nil, only the
+// trigger provider is checked.
+func snapUsage(snap *definition.Snap, trigger string, tool string, credentialSet string, values map[string]string) []print.ToolUsage {
+	if snap == nil {
+		if strings.EqualFold(trigger, tool) {
+			return []print.ToolUsage{{Step: "trigger"}}
+		}
+		return nil
+	}
+
+	// parameter defaults apply unless the active snap has its own value
+	resolved := make(map[string]string)
+	for _, param := range snap.Parameters {
+		if param.Default != "" {
+			resolved[param.Name] = param.Default
+		}
+	}
+	for name, value := range values {
+		resolved[name] = value
+	}
+
+	var usage []print.ToolUsage
+	for _, step := range append([]definition.Step{snap.TriggerStep()}, snap.ActionSteps()...) {
+		if !strings.EqualFold(step.Provider, tool) {
+			continue
+		}
+
+		connection := definition.Expand(step.Connection(), resolved)
+		unresolved := len(definition.References([]definition.Value{{Value: connection}})) > 0
+		if credentialSet == "" || connection == "" || connection == credentialSet || unresolved {
+			usage = append(usage, print.ToolUsage{Step: step.Name, CredentialSet: connection})
+		}
+	}
+
+	return usage
+}
+
+// activeSnapIDs returns the unique active snap IDs in the usage
+func activeSnapIDs(usage []print.ToolUsage) []string {
+	var ids []string
+	for _, u := range usage {
+		ids = append(ids, u.ActiveSnapID)
+	}
+
+	return unique(ids)
+}
+
+// refuseIfUsed exits with an error if active snaps would break because of the operation on
+// the tool (or its credential set), or if their usage of the tool can't be determined, unless
+// --force is passed.  It returns the active snaps' usage of the tool, for the confirmation.
+func refuseIfUsed(cmd *cobra.Command, operation string, tool string, credentialSet string) []print.ToolUsage {
+	usage, err := getActiveSnapUsage(make(snapDefinitions), tool, credentialSet)
+	if force, _ := cmd.Flags().GetBool("force"); force {
+		return usage
+	}
+	if err != nil {
+		utils.PrintErrorMessage(fmt.Sprintf("cannot %s: could not check whether active snaps use %s; pass --force to %s anyway",
+			operation, tool, strings.Fields(operation)[0]), err)
+		os.Exit(1)
+	}
+	if len(usage) == 0 {
+		return usage
+	}
+
+	print.ActiveSnapUsageTable(fmt.Sprintf("Active snaps that use %s", tool), usage)
+	utils.PrintError(fmt.Sprintf("cannot %s: it is used by %s; pass --force to %s anyway",
+		operation, plural(len(activeSnapIDs(usage)), "active snap"), strings.Fields(operation)[0]))
+	os.Exit(1)
+	return nil
+}
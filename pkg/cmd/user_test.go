@@ -0,0 +1,24 @@
+package cmd
+
+import "testing"
+
+func TestUser(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	runCommandTests(t, server, []commandTest{
+		{
+			name:     "logged in",
+			env:      []string{"SNAP_NAME=Ada Lovelace", "SNAP_EMAIL=ada@example.com"},
+			args:     []string{"user"},
+			contains: []string{"current user is Ada Lovelace <ada@example.com>"},
+		},
+		{
+			name:     "logged out",
+			env:      []string{"SNAP_ACCESSTOKEN="},
+			args:     []string{"user"},
+			status:   1,
+			contains: []string{"no logged in user.  To login, use the command 'snap login'."},
+		},
+	})
+}
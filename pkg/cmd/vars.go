@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/snapmaster-io/snap/pkg/definition"
+	"github.com/snapmaster-io/snap/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// varsHelp describes the variables of definition files, for the help of the commands that read them
+const varsHelp = `
+Variables set with --var name=value and --var-file file.yaml are substituted into the
+definition file: ${name} is replaced with the variable's value (unless name is a declared
+snap parameter, which ${name} also refers to), and a file containing {{ is rendered as a
+Go template first, e.g. {{.name}}.  Undefined variables are errors.  Without --var or
+--var-file, the file is used as it is: nothing is substituted or rendered.`
+
+// addVarFlags adds the flags that set the variables substituted into a definition file
+func addVarFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayP("var", "", nil, "set a variable substituted into the definition, as name=value (repeatable)")
+	cmd.Flags().StringArrayP("var-file", "", nil, "read variables from a yaml file (repeatable; later files and --var take precedence)")
+}
+
+// getVars returns the variables set by the --var-file and --var flags
+func getVars(cmd *cobra.Command) (map[string]interface{}, error) {
+	vars := make(map[string]interface{})
+
+	files, _ := cmd.Flags().GetStringArray("var-file")
+	for _, file := range files {
+		fileVars, err := definition.ReadVars(file)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range fileVars {
+			vars[name] = value
+		}
+	}
+
+	values, _ := cmd.Flags().GetStringArray("var")
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid --var %q (must be name=value)", value)
+		}
+		vars[parts[0]] = parts[1]
+	}
+
+	return vars, nil
+}
+
+// readSnapFile reads a definition file ("-" reads stdin), and returns its text with the
+// variables of the --var-file and --var flags substituted; without those flags, the text is
+// returned as it is, so that ${...} and {{ ... }} meant for the service are kept
+func readSnapFile(cmd *cobra.Command, snapFile string) string {
+	var contents []byte
+	var err error
+	if snapFile == "-" {
+		contents, err = ioutil.ReadAll(stdinReader)
+	} else {
+		contents, err = ioutil.ReadFile(snapFile)
+	}
+	if err != nil {
+		utils.PrintErrorMessage(fmt.Sprintf("could not read snap definition file %s", snapFile), err)
+		os.Exit(1)
+	}
+
+	if !cmd.Flags().Changed("var") && !cmd.Flags().Changed("var-file") {
+		return string(contents)
+	}
+
+	vars, err := getVars(cmd)
+	if err != nil {
+		utils.PrintErrorMessage("could not read variables", err)
+		os.Exit(1)
+	}
+	text, err := definition.Render(string(contents), vars)
+	if err != nil {
+		utils.PrintErrorMessage(fmt.Sprintf("could not render snap definition file %s", snapFile), err)
+		os.Exit(1)
+	}
+
+	return text
+}
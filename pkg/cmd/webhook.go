@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/snapmaster-io/snap/pkg/utils"
+	"github.com/snapmaster-io/snap/pkg/webhook"
+	"github.com/spf13/cobra"
+)
+
+// webhookCmd represents the webhook command
+var webhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Capture and replay webhook payloads",
+	Long: `Capture the webhook requests that tools send, to see the shape of their events, and
+replay them.`,
+}
+
+// listenWebhookCmd represents the webhook listen subcommand
+var listenWebhookCmd = &cobra.Command{
+	Use:   "listen",
+	Short: "Start a local server that captures incoming webhook requests",
+	Long: `Start a local HTTP server that captures incoming webhook requests, on any path.
+
+Each request's method, path, headers, and body are appended to a JSON Lines file (--out)
+and printed, and the request is answered with 200 OK.  Requests with a body larger than
+--max-body bytes are answered with 413 Request Entity Too Large, and aren't captured.
+Press Ctrl-C to stop.  To receive webhooks from a hosted tool, expose the port with a
+tunnel.
+
+The captured bodies can be replayed with snap webhook send, or extracted as the --event of
+snap run --local, e.g. tail -1 webhooks.jsonl | jq -r .body > event.json.`,
+	Example: `  snap webhook listen --port 9000 --out github.jsonl`,
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		host, _ := cmd.Flags().GetString("host")
+		port, _ := cmd.Flags().GetInt("port")
+		out, _ := cmd.Flags().GetString("out")
+		maxBody, _ := cmd.Flags().GetInt64("max-body")
+
+		var mu sync.Mutex
+		captured := 0
+		server := &http.Server{
+			Addr: net.JoinHostPort(host, strconv.Itoa(port)),
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				event, err := webhook.Capture(w, r, maxBody)
+				if err == webhook.ErrBodyTooLarge {
+					utils.PrintError(fmt.Sprintf("refused %s %s: the body is larger than %d bytes", r.Method, r.URL.RequestURI(), maxBody))
+					http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+					return
+				}
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+
+				// write and print one event at a time
+				mu.Lock()
+				defer mu.Unlock()
+				if err := webhook.Append(out, event); err != nil {
+					utils.PrintErrorMessage(fmt.Sprintf("could not save the request to %s", out), err)
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				captured++
+				utils.PrintMessage(fmt.Sprintf("%s %s (event %d in %s)", event.Method, event.Path, captured, out))
+				utils.PrintJSON(event.Display())
+
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintln(w, `{"status":"success"}`)
+			}),
+		}
+
+		listener, err := net.Listen("tcp", server.Addr)
+		if err != nil {
+			utils.PrintErrorMessage(fmt.Sprintf("could not listen on %s", server.Addr), err)
+			os.Exit(1)
+		}
+		utils.PrintMessage(fmt.Sprintf("listening on http://%s, saving requests to %s (Ctrl-C to stop)", listener.Addr(), out))
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signals
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			server.Shutdown(ctx)
+		}()
+
+		if err := server.Serve(listener); err != http.ErrServerClosed {
+			utils.PrintErrorMessage("the server stopped", err)
+			os.Exit(1)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		utils.PrintMessage(fmt.Sprintf("captured %s in %s", plural(captured, "request"), out))
+	},
+}
+
+// sendWebhookCmd represents the webhook send subcommand
+var sendWebhookCmd = &cobra.Command{
+	Use:   "send [file]",
+	Short: "Send captured or hand-written webhook payloads to a URL",
+	Long: `Send webhook payloads to a URL.
+
+A JSON Lines file of requests captured by snap webhook listen (*.jsonl) replays each
+request in order - or the one selected with --index - with its method, headers, and body.
+Any other file is sent as the body of a POST, as application/json if it is valid JSON.`,
+	Example: `  snap webhook send github.jsonl --to https://dev.snapmaster.io/github/webhooks --index 2`,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		file := args[0]
+		to, _ := cmd.Flags().GetString("to")
+		index, _ := cmd.Flags().GetInt("index")
+		headers, _ := cmd.Flags().GetStringArray("header")
+
+		events, err := webhook.ReadEvents(file)
+		if err != nil {
+			utils.PrintErrorMessage(fmt.Sprintf("could not read %s", file), err)
+			os.Exit(1)
+		}
+		if index < 0 || index > len(events) {
+			utils.PrintError(fmt.Sprintf("%s has %s; --index must be between 1 and %d", file, plural(len(events), "request"), len(events)))
+			os.Exit(1)
+		}
+		if index > 0 {
+			events = events[index-1 : index]
+		}
+
+		dryRun, _ := rootCmd.PersistentFlags().GetBool("dry-run")
+		client := &http.Client{Timeout: 30 * time.Second}
+		failed := 0
+		for i, event := range events {
+			req, err := event.Request(to)
+			if err == nil {
+				err = setHeaders(req, headers)
+			}
+			if err != nil {
+				utils.PrintErrorMessage(fmt.Sprintf("could not build request %d", i+1), err)
+				os.Exit(1)
+			}
+
+			if dryRun {
+				body := event.Body
+				if event.Encoding != "" {
+					body = fmt.Sprintf("%s (%s)", body, event.Encoding)
+				}
+				fmt.Printf("dry run: %s %s\n%s\n", req.Method, to, body)
+				continue
+			}
+
+			resp, err := client.Do(req)
+			if err != nil {
+				utils.PrintErrorMessage(fmt.Sprintf("could not send request %d", i+1), err)
+				failed++
+				continue
+			}
+			body, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if resp.StatusCode >= 400 {
+				utils.PrintError(fmt.Sprintf("%s %s: %s\n%s", req.Method, to, resp.Status, strings.TrimSpace(string(body))))
+				failed++
+				continue
+			}
+			utils.PrintMessage(fmt.Sprintf("%s %s: %s", req.Method, to, resp.Status))
+		}
+
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(webhookCmd)
+	webhookCmd.AddCommand(listenWebhookCmd)
+	listenWebhookCmd.Flags().StringP("host", "", "localhost", "the address to listen on")
+	listenWebhookCmd.Flags().IntP("port", "", 9000, "the port to listen on")
+	listenWebhookCmd.Flags().StringP("out", "", "webhooks.jsonl", "the JSON Lines file the requests are appended to")
+	listenWebhookCmd.Flags().Int64P("max-body", "", webhook.MaxBodySize, "the largest request body to capture, in bytes")
+	webhookCmd.AddCommand(sendWebhookCmd)
+	sendWebhookCmd.Flags().StringP("to", "", "", "the URL to send the requests to")
+	sendWebhookCmd.MarkFlagRequired("to")
+	sendWebhookCmd.Flags().IntP("index", "", 0, "send only the request at this position in a JSON Lines file (from 1)")
+	sendWebhookCmd.Flags().StringArrayP("header", "", nil, "set a header, as Name: value (repeatable)")
+}
+
+// setHeaders sets the "Name: value" headers on the request
+func setHeaders(req *http.Request, headers []string) error {
+	for _, header := range headers {
+		parts := strings.SplitN(header, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return fmt.Errorf("invalid --header %q (must be Name: value)", header)
+		}
+		req.Header.Set(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// receivedWebhook is a request received by a webhook receiver
+type receivedWebhook struct {
+	method  string
+	path    string
+	headers http.Header
+	body    string
+}
+
+// newWebhookReceiver starts a server that records the requests it receives, and replies
+// 500 to those on /fail
+func newWebhookReceiver(t *testing.T) (*httptest.Server, func() []receivedWebhook) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var received []receivedWebhook
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, receivedWebhook{method: r.Method, path: r.URL.Path, headers: r.Header, body: string(body)})
+		mu.Unlock()
+
+		if r.URL.Path == "/fail" {
+			http.Error(w, "no handler for this event", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"status":"success"}`))
+	}))
+	t.Cleanup(receiver.Close)
+
+	return receiver, func() []receivedWebhook {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]receivedWebhook(nil), received...)
+	}
+}
+
+// the events of a captured JSON Lines file: a push with a JSON body, and a binary body
+const testWebhooks = `{"timestamp": 1, "method": "POST", "path": "/hooks/github", "headers": {"Content-Type": "application/json", "X-Github-Event": "push", "Content-Length": "15", "Host": "localhost:9000"}, "body": "{\"ref\":\"main\"}"}
+
+{"timestamp": 2, "method": "PUT", "path": "/hooks/other", "headers": {"Content-Type": "application/octet-stream"}, "body": "//4=", "encoding": "base64"}
+`
+
+func TestWebhookSend(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+	receiver, received := newWebhookReceiver(t)
+	captured := writeTestFile(t, "github.jsonl", testWebhooks)
+
+	r := runSnap(t, server, "", "webhook", "send", captured, "--to", receiver.URL+"/github/webhooks", "--header", "X-Hub-Signature: sha1=1234")
+	r.expect(t, 0, "POST "+receiver.URL+"/github/webhooks: 200 OK", "PUT "+receiver.URL+"/github/webhooks: 200 OK")
+
+	requests := received()
+	if len(requests) != 2 {
+		t.Fatalf("received %d requests, want 2: %v", len(requests), requests)
+	}
+	push, binary := requests[0], requests[1]
+	// the requests are sent to the URL, rather than the path they were captured on
+	if push.method != "POST" || push.path != "/github/webhooks" || push.body != `{"ref":"main"}` {
+		t.Errorf("received %+v for the push", push)
+	}
+	if push.headers.Get("Content-Type") != "application/json" || push.headers.Get("X-Github-Event") != "push" || push.headers.Get("X-Hub-Signature") != "sha1=1234" {
+		t.Errorf("received the headers %v for the push", push.headers)
+	}
+	// connection headers aren't replayed
+	if push.headers.Get("Content-Length") != "14" {
+		t.Errorf("received Content-Length %q, want the length of the body", push.headers.Get("Content-Length"))
+	}
+	if binary.method != "PUT" || binary.body != "\xff\xfe" || binary.headers.Get("Content-Type") != "application/octet-stream" {
+		t.Errorf("received %+v for the binary body", binary)
+	}
+
+	// --index selects a single request
+	runSnap(t, server, "", "webhook", "send", captured, "--to", receiver.URL, "--index", "2").expect(t, 0, "PUT")
+	if requests := received(); len(requests) != 3 || requests[2].method != "PUT" {
+		t.Errorf("received %v for --index 2", requests)
+	}
+}
+
+func TestWebhookSendFile(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+	receiver, received := newWebhookReceiver(t)
+
+	runSnap(t, server, "", "webhook", "send", writeTestFile(t, "push.json", `{"ref": "main"}`), "--to", receiver.URL).expect(t, 0, "200 OK")
+	runSnap(t, server, "", "webhook", "send", writeTestFile(t, "push.txt", "ref=main"), "--to", receiver.URL).expect(t, 0, "200 OK")
+
+	var got []receivedWebhook
+	for _, request := range received() {
+		got = append(got, receivedWebhook{method: request.method, body: request.body, headers: http.Header{"Content-Type": request.headers["Content-Type"]}})
+	}
+	want := []receivedWebhook{
+		{method: "POST", body: `{"ref": "main"}`, headers: http.Header{"Content-Type": []string{"application/json"}}},
+		{method: "POST", body: "ref=main", headers: http.Header{"Content-Type": nil}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("received %+v, want %+v", got, want)
+	}
+}
+
+func TestWebhookSendErrors(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+	receiver, received := newWebhookReceiver(t)
+	captured := writeTestFile(t, "github.jsonl", testWebhooks)
+
+	// every request is sent, and the command fails if any of them is refused
+	runSnap(t, server, "", "webhook", "send", captured, "--to", receiver.URL+"/fail").
+		expect(t, 1, "POST "+receiver.URL+"/fail: 500 Internal Server Error", "no handler for this event", "PUT "+receiver.URL+"/fail: 500")
+	if requests := received(); len(requests) != 2 {
+		t.Errorf("received %d requests, want 2", len(requests))
+	}
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	runCommandTests(t, server, []commandTest{
+		{name: "unreachable", args: []string{"webhook", "send", captured, "--to", closed.URL, "--index", "1"}, status: 1, contains: []string{"could not send request 1"}},
+		{name: "index out of range", args: []string{"webhook", "send", captured, "--to", receiver.URL, "--index", "3"}, status: 1, contains: []string{"has 2 requests; --index must be between 1 and 2"}},
+		{name: "invalid header", args: []string{"webhook", "send", captured, "--to", receiver.URL, "--header", "X-Hub-Signature"}, status: 1, contains: []string{`invalid --header "X-Hub-Signature"`}},
+		{name: "malformed file", args: []string{"webhook", "send", writeTestFile(t, "bad.jsonl", "{\n"), "--to", receiver.URL}, status: 1, contains: []string{"could not parse line 1"}},
+		{name: "missing file", args: []string{"webhook", "send", captured + ".missing", "--to", receiver.URL}, status: 1, contains: []string{"could not read"}},
+		{name: "dry run", args: []string{"webhook", "send", captured, "--to", receiver.URL, "--dry-run"}, contains: []string{"dry run: POST " + receiver.URL, `{"ref":"main"}`, "//4= (base64)"}},
+	})
+	if requests := received(); len(requests) != 2 {
+		t.Errorf("received %d requests, want 2: %v", len(requests), requests)
+	}
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// ReadCacheFile reads data cached in filename in the cache directory, as long as
+// it was written less than ttl ago
+func ReadCacheFile(filename string, ttl time.Duration) ([]byte, bool) {
+	cachePath, err := cacheDir()
+	if err != nil {
+		return nil, false
+	}
+
+	// check the age of the cache entry
+	cacheFile := cachePath + "/" + filename
+	info, err := os.Stat(cacheFile)
+	if err != nil || time.Since(info.ModTime()) > ttl {
+		return nil, false
+	}
+
+	contents, err := ioutil.ReadFile(cacheFile)
+	if err != nil {
+		return nil, false
+	}
+
+	return contents, true
+}
+
+// WriteCacheFile writes data into filename in the cache directory
+func WriteCacheFile(filename string, data []byte) error {
+	cachePath, err := cacheDir()
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(cachePath, 0700)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(cachePath+"/"+filename, data, 0600)
+}
+
+// cacheDir returns the cache directory path as $HOME/.config/snap/cache
+func cacheDir() (string, error) {
+	homedir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return homedir + "/.config/snap/cache", nil
+}
@@ -0,0 +1,331 @@
+package dashboard
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/snapmaster-io/snap/pkg/print"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// Source retrieves the active snaps and their logs, and executes actions on active snaps
+type Source interface {
+	ActiveSnaps() ([]print.ActiveSnap, error)
+	Logs(activeSnapID string) ([]print.ActiveSnapLog, error)
+	Execute(activeSnapID string, action string) error
+}
+
+// Options controls the refresh interval and the initial state filter of the dashboard
+type Options struct {
+	Interval time.Duration
+	State    string
+}
+
+// the pane that has the keyboard focus
+const (
+	snapsPane = iota
+	logsPane
+)
+
+// action waiting for the user's confirmation
+type pendingAction struct {
+	activeSnapID string
+	action       string
+}
+
+// dashboard holds the state of the dashboard between renders
+type dashboard struct {
+	source   Source
+	interval time.Duration
+
+	snaps   []print.ActiveSnap
+	visible []print.ActiveSnap
+	logs    []print.ActiveSnapLog
+	state   string
+
+	focus      int
+	selected   string
+	snapCursor int
+	logCursor  int
+
+	details       *print.ActiveSnapLog
+	detailsOffset int
+
+	confirm   *pendingAction
+	message   string
+	isError   bool
+	refreshed time.Time
+
+	// API calls run in the background, and send the functions that apply their results to
+	// updates, so that the screen keeps responding to keys while they run
+	updates    chan func()
+	pending    int
+	refreshing bool
+	stale      bool
+
+	width  int
+	height int
+	out    *bufio.Writer
+}
+
+// Run shows the dashboard until the user quits.  It requires stdin and stdout to be terminals.
+func Run(source Source, options Options) error {
+	in := int(os.Stdin.Fd())
+	out := int(os.Stdout.Fd())
+	if !terminal.IsTerminal(in) || !terminal.IsTerminal(out) {
+		return errors.New("the dashboard requires a terminal")
+	}
+
+	state, err := terminal.MakeRaw(in)
+	if err != nil {
+		return err
+	}
+	defer terminal.Restore(in, state)
+
+	d := &dashboard{
+		source:   source,
+		interval: options.Interval,
+		state:    options.State,
+		updates:  make(chan func()),
+		out:      bufio.NewWriter(os.Stdout),
+	}
+
+	// switch to the alternate screen and hide the cursor, and undo both on the way out
+	fmt.Fprint(d.out, "\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Fprint(d.out, "\x1b[?25h\x1b[?1049l")
+		d.out.Flush()
+	}()
+
+	keys := make(chan []byte)
+	go readKeys(keys)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	d.refresh()
+	for {
+		d.render()
+
+		select {
+		case input, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			if quit := d.handleKeys(input); quit {
+				return nil
+			}
+		case apply := <-d.updates:
+			d.pending--
+			apply()
+		case <-ticker.C:
+			d.refresh()
+		case <-signals:
+			return nil
+		}
+	}
+}
+
+// readKeys sends chunks of input from stdin to the channel, and closes it when stdin closes
+func readKeys(keys chan<- []byte) {
+	buf := make([]byte, 32)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			close(keys)
+			return
+		}
+		input := make([]byte, n)
+		copy(input, buf[:n])
+		keys <- input
+	}
+}
+
+// background runs fetch in another goroutine, and sends the function that applies its
+// result to the updates channel
+func (d *dashboard) background(fetch func() func()) {
+	d.pending++
+	go func() {
+		d.updates <- fetch()
+	}()
+}
+
+// refresh retrieves the active snaps, and then the logs of the selected active snap.  A
+// refresh requested while one is running runs when it finishes.
+func (d *dashboard) refresh() {
+	if d.refreshing {
+		d.stale = true
+		return
+	}
+	d.refreshing = true
+	d.stale = false
+
+	source := d.source
+	d.background(func() func() {
+		snaps, err := source.ActiveSnaps()
+		return func() {
+			d.refreshing = false
+			if err != nil {
+				d.setError(err)
+			} else {
+				d.applySnaps(snaps)
+			}
+			if d.stale {
+				d.refresh()
+			}
+		}
+	})
+}
+
+// applySnaps shows the retrieved active snaps, and refreshes the logs of the selected one
+func (d *dashboard) applySnaps(snaps []print.ActiveSnap) {
+	sort.SliceStable(snaps, func(i, j int) bool {
+		return snaps[i].ActiveSnapID < snaps[j].ActiveSnapID
+	})
+	d.snaps = snaps
+	d.refreshed = time.Now()
+
+	// a change of selection already refreshes the logs
+	previous := d.selected
+	d.applyFilter()
+	if d.selected == previous {
+		d.refreshLogs()
+	}
+}
+
+// refreshLogs retrieves the logs of the selected active snap, most recent first.  Logs
+// that arrive after the selection moved to another active snap are dropped.
+func (d *dashboard) refreshLogs() {
+	if d.selected == "" {
+		d.logs = nil
+		return
+	}
+
+	source := d.source
+	activeSnapID := d.selected
+	d.background(func() func() {
+		logs, err := source.Logs(activeSnapID)
+		return func() {
+			if activeSnapID != d.selected {
+				return
+			}
+			if err != nil {
+				d.setError(err)
+				return
+			}
+			d.applyLogs(logs)
+		}
+	})
+}
+
+// applyLogs shows the retrieved logs, keeping the cursor within them
+func (d *dashboard) applyLogs(logs []print.ActiveSnapLog) {
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].LogID > logs[j].LogID
+	})
+	d.logs = logs
+	if d.logCursor >= len(d.logs) {
+		d.logCursor = len(d.logs) - 1
+	}
+	if d.logCursor < 0 {
+		d.logCursor = 0
+	}
+}
+
+// applyFilter selects the active snaps in the filtered state, keeping the same active
+// snap selected if it is still visible
+func (d *dashboard) applyFilter() {
+	d.visible = nil
+	for _, snap := range d.snaps {
+		if d.state == "" || snap.State == d.state {
+			d.visible = append(d.visible, snap)
+		}
+	}
+
+	d.snapCursor = 0
+	for i, snap := range d.visible {
+		if snap.ActiveSnapID == d.selected {
+			d.snapCursor = i
+		}
+	}
+	d.selectSnap(d.snapCursor)
+}
+
+// selectSnap moves the cursor to the active snap at index i
+func (d *dashboard) selectSnap(i int) {
+	previous := d.selected
+	if len(d.visible) == 0 {
+		d.snapCursor = 0
+		d.selected = ""
+	} else {
+		d.snapCursor = (i + len(d.visible)) % len(d.visible)
+		d.selected = d.visible[d.snapCursor].ActiveSnapID
+	}
+
+	if d.selected != previous {
+		d.logs = nil
+		d.logCursor = 0
+		d.refreshLogs()
+	}
+}
+
+// nextState cycles the state filter through all states, and each state that appears
+// in the active snaps
+func (d *dashboard) nextState() {
+	states := []string{""}
+	seen := map[string]bool{"": true}
+	for _, snap := range d.snaps {
+		if !seen[snap.State] {
+			seen[snap.State] = true
+			states = append(states, snap.State)
+		}
+	}
+	sort.Strings(states[1:])
+
+	next := 0
+	for i, state := range states {
+		if state == d.state {
+			next = (i + 1) % len(states)
+		}
+	}
+	d.state = states[next]
+	d.applyFilter()
+}
+
+// execute runs the confirmed action, and then refreshes the active snaps
+func (d *dashboard) execute(p *pendingAction) {
+	d.setMessage(fmt.Sprintf("%s: %s...", p.activeSnapID, p.action))
+
+	source := d.source
+	d.background(func() func() {
+		err := source.Execute(p.activeSnapID, p.action)
+		return func() {
+			if err != nil {
+				d.setError(err)
+				return
+			}
+			d.setMessage(fmt.Sprintf("%s: %s succeeded", p.activeSnapID, p.action))
+			d.refresh()
+		}
+	})
+}
+
+func (d *dashboard) setMessage(message string) {
+	d.message = message
+	d.isError = false
+}
+
+func (d *dashboard) setError(err error) {
+	d.message = err.Error()
+	d.isError = true
+}
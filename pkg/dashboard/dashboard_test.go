@@ -0,0 +1,458 @@
+package dashboard
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/snapmaster-io/snap/pkg/print"
+)
+
+// fakeSource serves fixed active snaps and logs, and records the calls.  If gate isn't
+// nil, each call waits until it is closed.
+type fakeSource struct {
+	mu       sync.Mutex
+	snaps    []print.ActiveSnap
+	logs     map[string][]print.ActiveSnapLog
+	err      error
+	calls    []string
+	executed []string
+	gate     chan struct{}
+}
+
+func (s *fakeSource) call(name string) error {
+	if s.gate != nil {
+		<-s.gate
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, name)
+	return s.err
+}
+
+func (s *fakeSource) ActiveSnaps() ([]print.ActiveSnap, error) {
+	if err := s.call("snaps"); err != nil {
+		return nil, err
+	}
+	return append([]print.ActiveSnap(nil), s.snaps...), nil
+}
+
+func (s *fakeSource) Logs(activeSnapID string) ([]print.ActiveSnapLog, error) {
+	if err := s.call("logs " + activeSnapID); err != nil {
+		return nil, err
+	}
+	return append([]print.ActiveSnapLog(nil), s.logs[activeSnapID]...), nil
+}
+
+func (s *fakeSource) Execute(activeSnapID string, action string) error {
+	if err := s.call(action + " " + activeSnapID); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.executed = append(s.executed, action+" "+activeSnapID)
+	return nil
+}
+
+func (s *fakeSource) callCount(name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, call := range s.calls {
+		if call == name {
+			n++
+		}
+	}
+	return n
+}
+
+func newFakeSource() *fakeSource {
+	return &fakeSource{
+		snaps: []print.ActiveSnap{
+			{ActiveSnapID: "c", SnapID: "u/deploy", State: "active", Provider: "github"},
+			{ActiveSnapID: "a", SnapID: "u/build", State: "active", Provider: "github", ExecutionCounter: 3},
+			{ActiveSnapID: "b", SnapID: "u/build", State: "paused", Provider: "docker"},
+		},
+		logs: map[string][]print.ActiveSnapLog{
+			"a": {
+				{LogID: 1, ActiveSnapID: "a", State: "success", Trigger: "github", Event: "push"},
+				{LogID: 3, ActiveSnapID: "a", State: "error", Trigger: "github", Event: "push"},
+				{LogID: 2, ActiveSnapID: "a", State: "success", Trigger: "github"},
+			},
+			"b": {{LogID: 5, ActiveSnapID: "b", State: "success", Trigger: "docker"}},
+		},
+	}
+}
+
+func newTestDashboard(source Source, state string) *dashboard {
+	return &dashboard{
+		source:  source,
+		state:   state,
+		updates: make(chan func()),
+		width:   80,
+		height:  24,
+		out:     bufio.NewWriter(&bytes.Buffer{}),
+	}
+}
+
+// settle applies the results of the background calls until none are running
+func (d *dashboard) settle(t *testing.T) {
+	t.Helper()
+	for d.pending > 0 {
+		select {
+		case apply := <-d.updates:
+			d.pending--
+			apply()
+		case <-time.After(5 * time.Second):
+			t.Fatal("a background call didn't finish")
+		}
+	}
+}
+
+func visibleIDs(d *dashboard) []string {
+	var ids []string
+	for _, snap := range d.visible {
+		ids = append(ids, snap.ActiveSnapID)
+	}
+	return ids
+}
+
+func logIDs(d *dashboard) []int64 {
+	var ids []int64
+	for _, l := range d.logs {
+		ids = append(ids, l.LogID)
+	}
+	return ids
+}
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"q", []string{"q"}},
+		{"jjk", []string{"j", "j", "k"}},
+		{"\x1b[A\x1b[B", []string{keyUp, keyDown}},
+		{"\x1bOA", []string{keyUp}},
+		{"\x1b[5~\x1b[6~", []string{keyPageUp, keyPageDown}},
+		{"\x1b", []string{keyEscape}},
+		{"\r\n\t", []string{keyEnter, keyEnter, keyTab}},
+		{"\x03", []string{keyCtrlC}},
+		// unknown sequences and control characters are dropped
+		{"\x1b[Cx\x01", []string{"x"}},
+	}
+	for _, test := range tests {
+		if got := parseKeys([]byte(test.input)); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseKeys(%q) = %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestPad(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		want  string
+	}{
+		{"abc", 5, "abc  "},
+		{"abcdef", 3, "abc"},
+		{"a\tb", 3, "a b"},
+		{"", 0, ""},
+		{"abc", -1, ""},
+		// escape sequences don't take up any width
+		{"\x1b[91mab\x1b[0m", 3, "\x1b[91mab\x1b[0m "},
+		{"\x1b[91mabcd", 2, "\x1b[91mab"},
+		{"héllo", 3, "hél"},
+	}
+	for _, test := range tests {
+		if got := pad(test.text, test.width); got != test.want {
+			t.Errorf("pad(%q, %d) = %q, want %q", test.text, test.width, got, test.want)
+		}
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    string
+		visible  []string
+		selected string
+		logs     []int64
+	}{
+		{name: "all states", visible: []string{"a", "b", "c"}, selected: "a", logs: []int64{3, 2, 1}},
+		{name: "filtered", state: "paused", visible: []string{"b"}, selected: "b", logs: []int64{5}},
+		{name: "no match", state: "stopped", selected: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := newTestDashboard(newFakeSource(), test.state)
+			d.refresh()
+			d.settle(t)
+
+			if ids := visibleIDs(d); !reflect.DeepEqual(ids, test.visible) {
+				t.Errorf("visible = %v, want %v", ids, test.visible)
+			}
+			if d.selected != test.selected {
+				t.Errorf("selected = %q, want %q", d.selected, test.selected)
+			}
+			if ids := logIDs(d); !reflect.DeepEqual(ids, test.logs) {
+				t.Errorf("logs = %v, want %v", ids, test.logs)
+			}
+			if d.refreshed.IsZero() || d.refreshing {
+				t.Errorf("refreshed = %v, refreshing = %v", d.refreshed, d.refreshing)
+			}
+		})
+	}
+}
+
+func TestRefreshError(t *testing.T) {
+	source := newFakeSource()
+	source.err = errors.New("token expired")
+	d := newTestDashboard(source, "")
+	d.refresh()
+	d.settle(t)
+
+	if !d.isError || d.message != "token expired" {
+		t.Errorf("message = %q, isError = %v", d.message, d.isError)
+	}
+	if !strings.Contains(d.statusLine(), "error: token expired") {
+		t.Errorf("status line = %q", d.statusLine())
+	}
+}
+
+func TestNextState(t *testing.T) {
+	d := newTestDashboard(newFakeSource(), "")
+	d.refresh()
+	d.settle(t)
+
+	// the filter cycles through all states and then each state, in order
+	for _, want := range []string{"active", "paused", "", "active"} {
+		d.handleKeys([]byte("f"))
+		d.settle(t)
+		if d.state != want {
+			t.Fatalf("state = %q, want %q", d.state, want)
+		}
+	}
+	if ids := visibleIDs(d); !reflect.DeepEqual(ids, []string{"a", "c"}) {
+		t.Errorf("visible = %v", ids)
+	}
+}
+
+func TestMoveCursor(t *testing.T) {
+	d := newTestDashboard(newFakeSource(), "")
+	d.refresh()
+	d.settle(t)
+
+	// moving the snaps cursor selects another active snap and shows its logs
+	d.handleKeys([]byte("j"))
+	if d.selected != "b" || d.logs != nil {
+		t.Errorf("selected = %q, logs = %v before the logs arrived", d.selected, logIDs(d))
+	}
+	d.settle(t)
+	if ids := logIDs(d); !reflect.DeepEqual(ids, []int64{5}) {
+		t.Errorf("logs = %v", ids)
+	}
+
+	// the cursor stops at the ends
+	d.handleKeys([]byte("jjjj"))
+	d.settle(t)
+	if d.selected != "c" {
+		t.Errorf("selected = %q, want c", d.selected)
+	}
+
+	// tab moves the focus to the logs, and enter opens and closes the details
+	d.handleKeys([]byte("kk\t"))
+	d.settle(t)
+	d.handleKeys([]byte("j\r"))
+	if d.details == nil || d.details.LogID != 2 {
+		t.Fatalf("details = %+v, want log 2", d.details)
+	}
+	d.handleKeys([]byte("\x1b"))
+	if d.details != nil {
+		t.Error("escape didn't close the details")
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     string
+		err      error
+		executed []string
+		message  string
+		isError  bool
+	}{
+		{name: "pause", keys: "py", executed: []string{"pause a"}, message: "a: pause succeeded"},
+		{name: "deactivate", keys: "dY", executed: []string{"deactivate a"}, message: "a: deactivate succeeded"},
+		{name: "cancelled", keys: "rn", message: "a: resume cancelled"},
+		{name: "any other key cancels", keys: "pq", message: "a: pause cancelled"},
+		{name: "failure", keys: "py", err: errors.New("not allowed"), message: "not allowed", isError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source := newFakeSource()
+			d := newTestDashboard(source, "")
+			d.refresh()
+			d.settle(t)
+			source.err = test.err
+
+			if quit := d.handleKeys([]byte(test.keys)); quit {
+				t.Fatal("the answer quit the dashboard")
+			}
+			d.settle(t)
+
+			if !reflect.DeepEqual(source.executed, test.executed) {
+				t.Errorf("executed = %v, want %v", source.executed, test.executed)
+			}
+			if d.message != test.message || d.isError != test.isError {
+				t.Errorf("message = %q (error %v), want %q (error %v)", d.message, d.isError, test.message, test.isError)
+			}
+			// a successful action refreshes the active snaps
+			if want := 1 + len(test.executed); source.callCount("snaps") != want {
+				t.Errorf("refreshed %d times, want %d", source.callCount("snaps"), want)
+			}
+		})
+	}
+}
+
+func TestConfirmation(t *testing.T) {
+	d := newTestDashboard(newFakeSource(), "")
+	d.confirm = &pendingAction{activeSnapID: "a", action: "pause"}
+	if got := d.confirmation(); got != "Pause active snap a? [y/N]" {
+		t.Errorf("confirmation = %q", got)
+	}
+	d.confirm = &pendingAction{activeSnapID: "a", action: "deactivate"}
+	if got := d.confirmation(); !strings.Contains(got, "ALL OF ITS LOGS WILL BE DELETED") {
+		t.Errorf("confirmation = %q", got)
+	}
+}
+
+func TestKeysDuringRefresh(t *testing.T) {
+	source := newFakeSource()
+	source.gate = make(chan struct{})
+	d := newTestDashboard(source, "")
+	d.refresh()
+
+	// the keys are handled while the refresh waits, and a second refresh is deferred
+	done := make(chan bool)
+	go func() {
+		done <- d.handleKeys([]byte("Rf"))
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handling keys waited for the refresh")
+	}
+	if !d.refreshing || !d.stale {
+		t.Errorf("refreshing = %v, stale = %v", d.refreshing, d.stale)
+	}
+	if lines := d.renderTables(); !strings.Contains(lines[0], "refreshing...") {
+		t.Errorf("title = %q", lines[0])
+	}
+
+	close(source.gate)
+	d.settle(t)
+	if n := source.callCount("snaps"); n != 2 {
+		t.Errorf("refreshed %d times, want 2", n)
+	}
+}
+
+func TestStaleLogs(t *testing.T) {
+	source := newFakeSource()
+	d := newTestDashboard(source, "")
+	d.refresh()
+	d.settle(t)
+
+	// logs of an active snap that is no longer selected are dropped
+	source.gate = make(chan struct{})
+	d.refreshLogs()
+	d.selected = "b"
+	d.logs = nil
+	close(source.gate)
+	d.settle(t)
+	if d.logs != nil {
+		t.Errorf("logs = %v, want none", logIDs(d))
+	}
+}
+
+func TestRenderTables(t *testing.T) {
+	source := newFakeSource()
+	d := newTestDashboard(source, "")
+	d.refresh()
+	d.settle(t)
+
+	text := strings.Join(d.renderTables(), "\n")
+	for _, s := range []string{
+		"Active Snaps (3 of 3, all states) - refreshed",
+		"ACTIVE SNAP ID",
+		"u/deploy",
+		"Logs for active snap a (3)",
+		"github:push",
+	} {
+		if !strings.Contains(text, s) {
+			t.Errorf("tables don't contain %q:\n%s", s, text)
+		}
+	}
+
+	d.state = "stopped"
+	d.applyFilter()
+	text = strings.Join(d.renderTables(), "\n")
+	for _, s := range []string{"Active Snaps (0 of 3, state stopped)", "no active snaps", "no logs"} {
+		if !strings.Contains(text, s) {
+			t.Errorf("tables don't contain %q:\n%s", s, text)
+		}
+	}
+}
+
+func TestRenderDetails(t *testing.T) {
+	d := newTestDashboard(newFakeSource(), "")
+	d.details = &print.ActiveSnapLog{
+		LogID: 1, ActiveSnapID: "a", SnapID: "u/build", State: "error", Trigger: "github", Event: "push",
+		Actions: []print.ActiveSnapActionsLog{
+			{Provider: "docker", Action: "build", State: "success", Output: print.ActiveSnapActionsLogOutput{
+				Status: "success", Data: map[string]interface{}{"stdout": "line 1\nline 2\n"},
+			}},
+			{Provider: "slack", Action: "send", State: "error", Output: print.ActiveSnapActionsLogOutput{
+				Status: "error", Message: "channel not found",
+			}},
+		},
+	}
+
+	text := strings.Join(d.renderDetails(), "\n")
+	for _, s := range []string{"Log 1 of active snap a (u/build) - error", "docker:build - success", "stdout:", "line 2", "channel not found"} {
+		if !strings.Contains(text, s) {
+			t.Errorf("details don't contain %q:\n%s", s, text)
+		}
+	}
+
+	// the details can't scroll past their end
+	d.handleKeys([]byte("jjjjjjjjjj"))
+	d.renderDetails()
+	if d.detailsOffset != 0 {
+		t.Errorf("offset = %d, want 0", d.detailsOffset)
+	}
+}
+
+func TestRender(t *testing.T) {
+	var out bytes.Buffer
+	d := newTestDashboard(newFakeSource(), "")
+	d.out = bufio.NewWriter(&out)
+	d.refresh()
+	d.settle(t)
+	d.setMessage("hello")
+	d.render()
+
+	// the screen is redrawn from the top, with the status and help lines at the bottom
+	lines := strings.Split(out.String(), "\r\n")
+	if !strings.HasPrefix(lines[0], "\x1b[H") || len(lines) != d.height {
+		t.Fatalf("rendered %d lines, want %d", len(lines), d.height)
+	}
+	if !strings.Contains(lines[len(lines)-2], "hello") || !strings.Contains(lines[len(lines)-1], "p pause") {
+		t.Errorf("status and help lines = %q", lines[len(lines)-2:])
+	}
+}
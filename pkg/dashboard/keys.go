@@ -0,0 +1,186 @@
+package dashboard
+
+import (
+	"fmt"
+	"strings"
+)
+
+// names of the keys that don't produce a printable character
+const (
+	keyUp       = "up"
+	keyDown     = "down"
+	keyPageUp   = "pgup"
+	keyPageDown = "pgdn"
+	keyEnter    = "enter"
+	keyEscape   = "esc"
+	keyTab      = "tab"
+	keyCtrlC    = "ctrl-c"
+)
+
+// parseKeys splits a chunk of input into key names and printable characters
+func parseKeys(input []byte) []string {
+	var keys []string
+	for len(input) > 0 {
+		switch {
+		case input[0] == 27 && len(input) >= 3 && (input[1] == '[' || input[1] == 'O'):
+			switch input[2] {
+			case 'A':
+				keys = append(keys, keyUp)
+			case 'B':
+				keys = append(keys, keyDown)
+			case '5', '6':
+				// page up and page down are sent as ESC [ 5 ~ and ESC [ 6 ~
+				if len(input) >= 4 && input[3] == '~' {
+					if input[2] == '5' {
+						keys = append(keys, keyPageUp)
+					} else {
+						keys = append(keys, keyPageDown)
+					}
+					input = input[1:]
+				}
+			}
+			input = input[3:]
+			continue
+		case input[0] == 27:
+			keys = append(keys, keyEscape)
+		case input[0] == '\r' || input[0] == '\n':
+			keys = append(keys, keyEnter)
+		case input[0] == '\t':
+			keys = append(keys, keyTab)
+		case input[0] == 3:
+			keys = append(keys, keyCtrlC)
+		case input[0] >= 32 && input[0] < 127:
+			keys = append(keys, string(input[0]))
+		}
+		input = input[1:]
+	}
+
+	return keys
+}
+
+// handleKeys processes a chunk of input, and returns true if the user quit
+func (d *dashboard) handleKeys(input []byte) bool {
+	for _, key := range parseKeys(input) {
+		if key == keyCtrlC {
+			return true
+		}
+
+		// a pending confirmation takes any key as the answer
+		if d.confirm != nil {
+			p := d.confirm
+			d.confirm = nil
+			if key == "y" || key == "Y" {
+				d.execute(p)
+			} else {
+				d.setMessage(fmt.Sprintf("%s: %s cancelled", p.activeSnapID, p.action))
+			}
+			continue
+		}
+
+		if d.details != nil {
+			d.handleDetailsKey(key)
+			continue
+		}
+
+		switch key {
+		case "q", keyEscape:
+			return true
+		case keyUp, "k":
+			d.moveCursor(-1)
+		case keyDown, "j":
+			d.moveCursor(1)
+		case keyPageUp:
+			d.moveCursor(-d.pageSize())
+		case keyPageDown:
+			d.moveCursor(d.pageSize())
+		case keyTab:
+			if d.focus == snapsPane {
+				d.focus = logsPane
+			} else {
+				d.focus = snapsPane
+			}
+		case keyEnter:
+			if d.focus == snapsPane {
+				d.focus = logsPane
+			} else if d.logCursor < len(d.logs) {
+				entry := d.logs[d.logCursor]
+				d.details = &entry
+				d.detailsOffset = 0
+			}
+		case "p":
+			d.requestAction("pause")
+		case "r":
+			d.requestAction("resume")
+		case "d":
+			d.requestAction("deactivate")
+		case "f":
+			d.nextState()
+		case "R":
+			d.refresh()
+		}
+	}
+
+	return false
+}
+
+// handleDetailsKey scrolls or closes the log details view
+func (d *dashboard) handleDetailsKey(key string) {
+	switch key {
+	case "q", keyEscape, keyEnter:
+		d.details = nil
+	case keyUp, "k":
+		d.detailsOffset--
+	case keyDown, "j":
+		d.detailsOffset++
+	case keyPageUp:
+		d.detailsOffset -= d.pageSize()
+	case keyPageDown:
+		d.detailsOffset += d.pageSize()
+	}
+	if d.detailsOffset < 0 {
+		d.detailsOffset = 0
+	}
+}
+
+// moveCursor moves the cursor of the focused pane
+func (d *dashboard) moveCursor(delta int) {
+	if d.focus == snapsPane {
+		i := d.snapCursor + delta
+		if i < 0 {
+			i = 0
+		}
+		if i >= len(d.visible) {
+			i = len(d.visible) - 1
+		}
+		d.selectSnap(i)
+		return
+	}
+
+	d.logCursor += delta
+	if d.logCursor >= len(d.logs) {
+		d.logCursor = len(d.logs) - 1
+	}
+	if d.logCursor < 0 {
+		d.logCursor = 0
+	}
+}
+
+// requestAction asks for confirmation before executing an action on the selected active snap
+func (d *dashboard) requestAction(action string) {
+	if d.selected == "" {
+		d.setMessage("no active snap selected")
+		return
+	}
+	d.confirm = &pendingAction{activeSnapID: d.selected, action: action}
+}
+
+// confirmation returns the question to ask for the pending action
+func (d *dashboard) confirmation() string {
+	p := d.confirm
+	question := fmt.Sprintf("%s active snap %s?", strings.Title(p.action), p.activeSnapID)
+	if p.action == "deactivate" {
+		question = fmt.Sprintf("Deactivate active snap %s? ALL OF ITS LOGS WILL BE DELETED.", p.activeSnapID)
+	}
+
+	return question + " [y/N]"
+}
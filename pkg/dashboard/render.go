@@ -0,0 +1,296 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/snapmaster-io/snap/pkg/print"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// escape sequences used to style the dashboard
+const (
+	styleReset    = "\x1b[0m"
+	styleTitle    = "\x1b[106;30;1m"
+	styleHeader   = "\x1b[96;100m"
+	styleSelected = "\x1b[30;47m"
+	styleFocused  = "\x1b[30;46m"
+	styleDim      = "\x1b[2m"
+	styleError    = "\x1b[91m"
+	styleMessage  = "\x1b[92m"
+	styleConfirm  = "\x1b[93;1m"
+)
+
+// column widths of the active snaps table
+var snapColumns = []int{16, 24, 8, 9, 11, 7, 30}
+
+// column widths of the logs table
+var logColumns = []int{16, 30, 10, 30}
+
+// render draws the whole screen
+func (d *dashboard) render() {
+	d.width, d.height = 80, 24
+	if width, height, err := terminal.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 && height > 0 {
+		d.width, d.height = width, height
+	}
+	if d.height < 10 {
+		d.height = 10
+	}
+
+	var lines []string
+	if d.details != nil {
+		lines = d.renderDetails()
+	} else {
+		lines = d.renderTables()
+	}
+
+	// pad the body so that the status and help lines are at the bottom of the screen
+	for len(lines) < d.height-2 {
+		lines = append(lines, "")
+	}
+	lines = lines[:d.height-2]
+	lines = append(lines, d.statusLine(), d.helpLine())
+
+	fmt.Fprint(d.out, "\x1b[H")
+	for i, line := range lines {
+		fmt.Fprint(d.out, line, "\x1b[K", styleReset)
+		if i < len(lines)-1 {
+			fmt.Fprint(d.out, "\r\n")
+		}
+	}
+	d.out.Flush()
+}
+
+// renderTables draws the active snaps table, followed by the logs of the selected active snap
+func (d *dashboard) renderTables() []string {
+	filter := "all states"
+	if d.state != "" {
+		filter = fmt.Sprintf("state %s", d.state)
+	}
+	title := fmt.Sprintf("Active Snaps (%d of %d, %s)", len(d.visible), len(d.snaps), filter)
+	if !d.refreshed.IsZero() {
+		title = fmt.Sprintf("%s - refreshed %s", title, d.refreshed.Format("15:04:05"))
+	}
+	if d.refreshing {
+		title += " - refreshing..."
+	}
+	lines := []string{styleTitle + pad(" "+title, d.width)}
+
+	// split the space between the two tables, giving the active snaps at most half
+	body := d.height - 2 - 4
+	snapRows := len(d.visible)
+	if snapRows > body/2 {
+		snapRows = body / 2
+	}
+	if snapRows < 1 {
+		snapRows = 1
+	}
+	logRows := body - snapRows
+
+	lines = append(lines, styleHeader+d.row(snapColumns, "ACTIVE SNAP ID", "SNAP ID", "STATE", "TRIGGER", "EXECUTIONS", "ERRORS", "ACTIVATED"))
+	if len(d.visible) == 0 {
+		lines = append(lines, styleDim+" no active snaps")
+	}
+	start := scrollStart(d.snapCursor, snapRows)
+	for i := start; i < len(d.visible) && i < start+snapRows; i++ {
+		a := d.visible[i]
+		line := d.row(snapColumns, a.ActiveSnapID, a.SnapID, a.State, a.Provider,
+			strconv.Itoa(a.ExecutionCounter), strconv.Itoa(a.ErrorCounter), print.FormatTime(a.Activated))
+		lines = append(lines, d.highlight(line, i == d.snapCursor, d.focus == snapsPane))
+	}
+	for len(lines) < 2+snapRows {
+		lines = append(lines, "")
+	}
+
+	logsTitle := "Logs"
+	if d.selected != "" {
+		logsTitle = fmt.Sprintf("Logs for active snap %s (%d)", d.selected, len(d.logs))
+	}
+	lines = append(lines, styleTitle+pad(" "+logsTitle, d.width))
+	lines = append(lines, styleHeader+d.row(logColumns, "LOG ID", "TIMESTAMP", "STATE", "TRIGGER"))
+	if len(d.logs) == 0 {
+		lines = append(lines, styleDim+" no logs")
+	}
+	start = scrollStart(d.logCursor, logRows)
+	for i := start; i < len(d.logs) && i < start+logRows; i++ {
+		l := d.logs[i]
+		trigger := l.Trigger
+		if l.Event != "" {
+			trigger = fmt.Sprintf("%s:%s", l.Trigger, l.Event)
+		}
+		line := d.row(logColumns, strconv.FormatInt(l.LogID, 10), print.FormatTime(l.LogID), l.State, trigger)
+		lines = append(lines, d.highlight(line, i == d.logCursor, d.focus == logsPane))
+	}
+
+	return lines
+}
+
+// renderDetails draws the actions of a log entry and their output, like
+// "snap active logs [active snap ID] details [log ID]"
+func (d *dashboard) renderDetails() []string {
+	l := d.details
+	title := fmt.Sprintf("Log %d of active snap %s (%s) - %s", l.LogID, l.ActiveSnapID, l.SnapID, l.State)
+	header := []string{
+		styleTitle + pad(" "+title, d.width),
+		pad(fmt.Sprintf(" triggered by %s:%s at %s", l.Trigger, l.Event, print.FormatTime(l.LogID)), d.width),
+		"",
+	}
+
+	var body []string
+	for _, action := range l.Actions {
+		body = append(body, styleHeader+pad(fmt.Sprintf(" %s:%s - %s", action.Provider, action.Action, action.State), d.width))
+		output := action.Output
+		if output.Status != "success" {
+			body = append(body, styleError+" "+output.Message)
+		} else if output.Data["stdout"] != nil || output.Data["stderr"] != nil {
+			for _, stream := range []string{"stdout", "stderr"} {
+				if output.Data[stream] == nil {
+					continue
+				}
+				body = append(body, styleDim+" "+stream+":")
+				body = append(body, indent(fmt.Sprintf("%v", output.Data[stream]))...)
+			}
+		} else {
+			payload, _ := json.MarshalIndent(output.Data, "", "  ")
+			body = append(body, indent(string(payload))...)
+		}
+		body = append(body, "")
+	}
+	if len(l.Actions) == 0 {
+		body = append(body, styleDim+" no actions were executed")
+	}
+
+	// scroll the body, without scrolling past its end
+	rows := d.height - 2 - len(header)
+	if d.detailsOffset > len(body)-rows {
+		d.detailsOffset = len(body) - rows
+	}
+	if d.detailsOffset < 0 {
+		d.detailsOffset = 0
+	}
+	end := d.detailsOffset + rows
+	if end > len(body) {
+		end = len(body)
+	}
+
+	lines := header
+	for _, line := range body[d.detailsOffset:end] {
+		lines = append(lines, pad(line, d.width))
+	}
+	return lines
+}
+
+// statusLine shows the pending confirmation, or the result of the last action or refresh
+func (d *dashboard) statusLine() string {
+	switch {
+	case d.confirm != nil:
+		return styleConfirm + pad(" "+d.confirmation(), d.width)
+	case d.isError:
+		return styleError + pad(" error: "+d.message, d.width)
+	default:
+		return styleMessage + pad(" "+d.message, d.width)
+	}
+}
+
+// helpLine lists the keybindings of the current view
+func (d *dashboard) helpLine() string {
+	help := " ↑/↓ move  tab switch pane  enter logs/details  p pause  r resume  d deactivate  f filter state  R refresh  q quit"
+	if d.details != nil {
+		help = " ↑/↓ scroll  esc back  q back"
+	}
+	return styleDim + pad(help, d.width)
+}
+
+// pageSize returns how far page up and page down move
+func (d *dashboard) pageSize() int {
+	if d.height > 12 {
+		return d.height / 2
+	}
+	return 5
+}
+
+// row lays out the values in columns of the given widths, giving the last column the rest of the line
+func (d *dashboard) row(widths []int, values ...string) string {
+	var b strings.Builder
+	for i, value := range values {
+		if i == len(values)-1 {
+			b.WriteString(" " + value)
+			break
+		}
+		b.WriteString(" " + pad(value, widths[i]-1))
+	}
+	return pad(b.String(), d.width)
+}
+
+// highlight styles the row under the cursor, more prominently when its pane has the focus
+func (d *dashboard) highlight(line string, selected bool, focused bool) string {
+	switch {
+	case selected && focused:
+		return styleFocused + line
+	case selected:
+		return styleSelected + line
+	default:
+		return line
+	}
+}
+
+// scrollStart returns the first row to show so that the cursor is visible
+func scrollStart(cursor int, rows int) int {
+	if cursor >= rows {
+		return cursor - rows + 1
+	}
+	return 0
+}
+
+// pad truncates or pads the text to exactly width characters, ignoring escape sequences
+func pad(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	visible := 0
+	escape := false
+	for _, r := range text {
+		switch {
+		case r == '\x1b':
+			escape = true
+		case escape:
+			if r == 'm' {
+				escape = false
+			}
+		case r == '\t':
+			r = ' '
+			fallthrough
+		default:
+			if visible == width {
+				return b.String()
+			}
+			visible++
+		}
+		b.WriteRune(r)
+	}
+
+	if visible < width {
+		b.WriteString(strings.Repeat(" ", width-visible))
+	}
+	return b.String()
+}
+
+// indent splits the text into lines, indenting each one
+func indent(text string) []string {
+	text = strings.TrimRight(text, "\n")
+	if !utf8.ValidString(text) {
+		text = strings.ToValidUTF8(text, "?")
+	}
+
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		lines = append(lines, "   "+strings.TrimRight(line, "\r"))
+	}
+	return lines
+}
@@ -0,0 +1,119 @@
+package definition
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+// matches ${var} references to variables (and to snap parameters), and $${...} escapes;
+// variables in maps are referenced as ${map.key}
+var varReference = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_.-]*)\}`)
+
+// Render substitutes variables into the text of a snap definition, before it is parsed.
+// If the text contains "{{", it is first executed as a Go template with the variables as
+// its data.  Then each ${name} is replaced with the variable's value, unless name is a
+// parameter declared by the definition, in which case it is left as a reference to the
+// snap parameter; $${name} is replaced with a literal ${name}.  Undefined variables, and
+// names that are both a variable and a snap parameter, are errors.
+func Render(text string, vars map[string]interface{}) (string, error) {
+	if strings.Contains(text, "{{") {
+		t, err := template.New("definition").Option("missingkey=error").Parse(text)
+		if err != nil {
+			return "", err
+		}
+		var b bytes.Buffer
+		if err := t.Execute(&b, vars); err != nil {
+			return "", err
+		}
+		text = b.String()
+	}
+
+	// ${name} also refers to a snap parameter, so find the declared parameters; a definition
+	// that can't be parsed yet has its ${...} left for the parse error to report
+	parameters := make(map[string]bool)
+	var declared struct {
+		Parameters []Parameter `yaml:"parameters"`
+	}
+	if yaml.Unmarshal([]byte(text), &declared) == nil {
+		for _, p := range declared.Parameters {
+			parameters[p.Name] = true
+		}
+	}
+
+	var undefined, ambiguous []string
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		lines[i] = varReference.ReplaceAllStringFunc(line, func(reference string) string {
+			if strings.HasPrefix(reference, "$$") {
+				return reference[1:]
+			}
+
+			name := varReference.FindStringSubmatch(reference)[1]
+			value, isVar := lookupVariable(vars, name)
+			switch {
+			case isVar && parameters[name]:
+				ambiguous = append(ambiguous, fmt.Sprintf("%s (line %d)", name, i+1))
+			case isVar:
+				return fmt.Sprintf("%v", value)
+			case !parameters[name]:
+				undefined = append(undefined, fmt.Sprintf("%s (line %d)", name, i+1))
+			}
+			return reference
+		})
+	}
+
+	var problems []string
+	if len(undefined) > 0 {
+		problems = append(problems, fmt.Sprintf("undefined variables: %s", strings.Join(undefined, ", ")))
+	}
+	if len(ambiguous) > 0 {
+		problems = append(problems, fmt.Sprintf("both a variable and a snap parameter (use {{.name}} for the variable): %s", strings.Join(ambiguous, ", ")))
+	}
+	if len(problems) > 0 {
+		return "", errors.New(strings.Join(problems, "\n"))
+	}
+
+	return strings.Join(lines, ""), nil
+}
+
+// ReadVars reads the variables in a yaml file, which maps their names to their values
+func ReadVars(path string) (map[string]interface{}, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var vars map[string]interface{}
+	if err := yaml.Unmarshal(contents, &vars); err != nil {
+		return nil, fmt.Errorf("could not parse variables file %s: %s", path, err)
+	}
+	return vars, nil
+}
+
+// lookupVariable returns the value of a variable, where a.b is the b entry of the map in a
+func lookupVariable(vars map[string]interface{}, name string) (interface{}, bool) {
+	parts := strings.Split(name, ".")
+	value, ok := vars[parts[0]]
+	for _, part := range parts[1:] {
+		if !ok {
+			break
+		}
+		switch m := value.(type) {
+		case map[string]interface{}:
+			value, ok = m[part]
+		case map[interface{}]interface{}:
+			value, ok = m[part]
+		default:
+			ok = false
+		}
+	}
+
+	return value, ok
+}
@@ -0,0 +1,122 @@
+package definition
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SkeletonStep is a tool trigger or action used by a step of a snap skeleton.  A nil
+// Operation stands for a step whose tool hasn't been chosen yet.
+type SkeletonStep struct {
+	Provider  string
+	Operation *Operation
+}
+
+// Skeleton returns a commented snap definition with the trigger and action steps.  The
+// required parameters of the steps are declared as snap parameters and referenced as
+// $name, so that they are supplied on activation; the other parameters get placeholders.
+func Skeleton(name string, description string, trigger SkeletonStep, actions []SkeletonStep) string {
+	// the required parameters of all the steps, declared once each
+	var parameters []Parameter
+	references := make(map[string]bool)
+	for _, step := range append([]SkeletonStep{trigger}, actions...) {
+		if step.Operation == nil {
+			continue
+		}
+		for _, p := range step.Operation.Parameters {
+			if p.Required && !references[p.Name] {
+				references[p.Name] = true
+				parameters = append(parameters, Parameter{Name: p.Name, Description: p.Description, Type: p.Type, Required: true})
+			}
+		}
+	}
+
+	// config entry names must be unique: the trigger is named after its tool, and the
+	// actions after their operation
+	used := make(map[string]bool)
+	entryName := func(name string) string {
+		unique := name
+		for i := 2; used[unique]; i++ {
+			unique = fmt.Sprintf("%s-%d", name, i)
+		}
+		used[unique] = true
+		return unique
+	}
+	triggerName := entryName(orDefault(trigger.Provider, "trigger"))
+	var actionNames []string
+	for _, step := range actions {
+		if step.Operation != nil {
+			actionNames = append(actionNames, entryName(step.Operation.Name))
+		} else {
+			actionNames = append(actionNames, entryName("action"))
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("# Generated by snap snaps init.  Replace the <placeholders>, then create the snap\n")
+	b.WriteString("# with: snap snaps create <file>\n")
+	b.WriteString("version: snap-v1alpha1\n")
+	fmt.Fprintf(&b, "name: %s\n", scalar(name))
+	fmt.Fprintf(&b, "description: %s\n", scalar(orDefault(description, "<what the snap does>")))
+
+	b.WriteString("\n# the config entry that triggers the snap\n")
+	fmt.Fprintf(&b, "trigger: %s\n", triggerName)
+
+	b.WriteString("\n# the config entries that run, in order, each time the snap is triggered\n")
+	b.WriteString("actions:\n")
+	for _, name := range actionNames {
+		fmt.Fprintf(&b, "  - %s\n", name)
+	}
+
+	b.WriteString("\n# the values supplied when the snap is activated, referenced in the config as $name\n")
+	if len(parameters) == 0 {
+		b.WriteString("# parameters:\n#   - name: <name>\n#     description: <description>\n")
+	} else {
+		b.WriteString("parameters:\n")
+		for _, p := range parameters {
+			fmt.Fprintf(&b, "  - name: %s\n", scalar(p.Name))
+			if p.Description != "" {
+				fmt.Fprintf(&b, "    description: %s\n", scalar(p.Description))
+			}
+			if p.Type != "" {
+				fmt.Fprintf(&b, "    type: %s\n", scalar(p.Type))
+			}
+			b.WriteString("    required: true\n")
+		}
+	}
+
+	b.WriteString("\n# the tool, operation, and parameter values of each step\n")
+	b.WriteString("config:\n")
+	writeSkeletonStep(&b, triggerName, trigger, true, references)
+	for i, step := range actions {
+		writeSkeletonStep(&b, actionNames[i], step, false, references)
+	}
+
+	return b.String()
+}
+
+// writeSkeletonStep writes the config entry of a skeleton step, with placeholders for a
+// tool that hasn't been chosen
+func writeSkeletonStep(b *strings.Builder, name string, step SkeletonStep, trigger bool, references map[string]bool) {
+	if step.Operation != nil {
+		writeStep(b, name, step.Provider, step.Operation, trigger, references)
+		return
+	}
+
+	fmt.Fprintf(b, "  - name: %s\n", name)
+	fmt.Fprintf(b, "    provider: %s  # a tool from: snap tools list\n", orDefault(step.Provider, "<tool>"))
+	if trigger {
+		fmt.Fprintf(b, "    event: <event>  # see: snap tools triggers %s\n", orDefault(step.Provider, "<tool>"))
+	} else {
+		fmt.Fprintf(b, "    action: <action>  # see: snap tools actions %s\n", orDefault(step.Provider, "<tool>"))
+	}
+}
+
+// orDefault returns the value, or the default if it is empty
+func orDefault(value string, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+
+	return value
+}
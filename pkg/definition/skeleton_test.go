@@ -0,0 +1,102 @@
+package definition
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSkeleton(t *testing.T) {
+	push := &Operation{
+		Name:   "push",
+		Events: []string{"push"},
+		Parameters: []Parameter{
+			{Name: "repo", Description: "the repo: owner/name", Required: true},
+			{Name: "branch", Description: "#branch to watch", Default: "a: b"},
+		},
+	}
+	deploy := &Operation{
+		Name: "deploy",
+		Parameters: []Parameter{
+			{Name: "url", Description: "the URL: where to deploy", Type: "string", Required: true},
+			{Name: "channel", Description: "notify\nthis channel", Default: "#x"},
+			{Name: "replicas", Default: "3"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		description string
+		trigger     SkeletonStep
+		actions     []SkeletonStep
+		parameters  []Parameter
+	}{
+		{
+			name:        "deploy-on-push",
+			description: "deploy: on every push",
+			trigger:     SkeletonStep{Provider: "github", Operation: push},
+			actions:     []SkeletonStep{{Provider: "gke", Operation: deploy}},
+			parameters: []Parameter{
+				{Name: "repo", Description: "the repo: owner/name", Required: true},
+				{Name: "url", Description: "the URL: where to deploy", Type: "string", Required: true},
+			},
+		},
+		{
+			name:    "two-deploys",
+			trigger: SkeletonStep{Provider: "github", Operation: push},
+			actions: []SkeletonStep{{Provider: "gke", Operation: deploy}, {Provider: "gke", Operation: deploy}},
+			parameters: []Parameter{
+				{Name: "repo", Description: "the repo: owner/name", Required: true},
+				{Name: "url", Description: "the URL: where to deploy", Type: "string", Required: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text := Skeleton(tt.name, tt.description, tt.trigger, tt.actions)
+			snap, err := Parse([]byte(text))
+			if err != nil {
+				t.Fatalf("could not parse the skeleton: %s\n%s", err, text)
+			}
+			if err := snap.Validate(); err != nil {
+				t.Fatalf("the skeleton isn't valid: %s\n%s", err, text)
+			}
+
+			if snap.Name != tt.name {
+				t.Errorf("name %q, want %q", snap.Name, tt.name)
+			}
+			if tt.description != "" && snap.Description != tt.description {
+				t.Errorf("description %q, want %q", snap.Description, tt.description)
+			}
+			if len(snap.Parameters) != len(tt.parameters) {
+				t.Fatalf("parameters %+v, want %+v", snap.Parameters, tt.parameters)
+			}
+			for i, p := range tt.parameters {
+				if snap.Parameters[i] != p {
+					t.Errorf("parameter %+v, want %+v", snap.Parameters[i], p)
+				}
+			}
+
+			// the defaults of the parameters that aren't snap parameters read back as is
+			steps := append([]Step{snap.TriggerStep()}, snap.ActionSteps()...)
+			if len(steps) != 1+len(tt.actions) {
+				t.Fatalf("steps %+v, want a trigger and %d actions", steps, len(tt.actions))
+			}
+			for _, step := range steps {
+				values := make(map[string]string)
+				for _, v := range step.Values {
+					values[v.Name] = fmt.Sprint(v.Value)
+				}
+				want := map[string]string{"repo": "$repo", "branch": "a: b"}
+				if step.Provider == "gke" {
+					want = map[string]string{"url": "$url", "channel": "#x", "replicas": "3"}
+				}
+				for name, value := range want {
+					if values[name] != value {
+						t.Errorf("step %s has %s: %q, want %q", step.Name, name, values[name], value)
+					}
+				}
+			}
+		})
+	}
+}
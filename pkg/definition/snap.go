@@ -0,0 +1,338 @@
+package definition
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Snap defines the fields of a snap definition
+type Snap struct {
+	Version     string      `yaml:"version,omitempty"`
+	Name        string      `yaml:"name"`
+	Description string      `yaml:"description,omitempty"`
+	Trigger     string      `yaml:"trigger"`
+	Actions     []Action    `yaml:"actions"`
+	Parameters  []Parameter `yaml:"parameters,omitempty"`
+	Config      []Config    `yaml:"config,omitempty"`
+}
+
+// Action defines an action entry, which is either the name of a config entry
+// or an inline step with its own provider, action and parameters
+type Action struct {
+	Name   string
+	Inline *Config
+}
+
+// Parameter defines a snap parameter (or a tool parameter)
+type Parameter struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Type        string `yaml:"type,omitempty" json:"type,omitempty"`
+	Required    bool   `yaml:"required,omitempty" json:"required,omitempty"`
+	Default     string `yaml:"default,omitempty" json:"default,omitempty"`
+	Entity      string `yaml:"entity,omitempty" json:"entity,omitempty"`
+}
+
+// secretNames matches the names of parameters whose values are treated as secrets
+var secretNames = regexp.MustCompile(`(?i)secret|passw(or)?d|token|key|credential`)
+
+// IsSecret returns whether the value of a parameter is a secret: if its type is secret or
+// password, or else if it is named like one
+func IsSecret(name string, paramType string) bool {
+	return paramType == "secret" || paramType == "password" || secretNames.MatchString(name)
+}
+
+// Config defines a config entry, which configures the trigger or an action
+type Config struct {
+	Name     string
+	Provider string
+	Action   string
+	Event    string
+	Values   []Value
+}
+
+// Value defines a named value in a config entry, in the order it appears in the definition
+type Value struct {
+	Name  string
+	Value interface{}
+}
+
+// Step is a resolved trigger or action: the provider and action it runs, and its parameter values
+type Step struct {
+	Name     string
+	Provider string
+	Action   string
+	Event    string
+	Values   []Value
+}
+
+// matches $param and ${param} references to snap parameters
+var paramReference = regexp.MustCompile(`\$\{?([A-Za-z_][A-Za-z0-9_-]*)\}?`)
+
+// Parse parses the text of a snap definition
+func Parse(text []byte) (*Snap, error) {
+	var snap Snap
+	if err := yaml.Unmarshal(text, &snap); err != nil {
+		return nil, err
+	}
+
+	return &snap, nil
+}
+
+// UnmarshalYAML unmarshals an action as either a config reference or an inline step
+func (a *Action) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var name string
+	if err := unmarshal(&name); err == nil {
+		a.Name = name
+		return nil
+	}
+
+	var config Config
+	if err := unmarshal(&config); err != nil {
+		return err
+	}
+	a.Name = config.Name
+	a.Inline = &config
+	return nil
+}
+
+// MarshalYAML marshals an action back into its original form
+func (a Action) MarshalYAML() (interface{}, error) {
+	if a.Inline != nil {
+		return a.Inline, nil
+	}
+	return a.Name, nil
+}
+
+// UnmarshalYAML unmarshals a config entry, keeping any other keys as ordered values
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var entries yaml.MapSlice
+	if err := unmarshal(&entries); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		key := fmt.Sprintf("%v", entry.Key)
+		switch key {
+		case "name":
+			c.Name = fmt.Sprintf("%v", entry.Value)
+		case "provider":
+			c.Provider = fmt.Sprintf("%v", entry.Value)
+		case "action":
+			c.Action = fmt.Sprintf("%v", entry.Value)
+		case "event":
+			c.Event = fmt.Sprintf("%v", entry.Value)
+		default:
+			c.Values = append(c.Values, Value{Name: key, Value: entry.Value})
+		}
+	}
+
+	return nil
+}
+
+// MarshalYAML marshals a config entry with its well-known keys first
+func (c Config) MarshalYAML() (interface{}, error) {
+	entries := yaml.MapSlice{{Key: "name", Value: c.Name}}
+	if c.Provider != "" {
+		entries = append(entries, yaml.MapItem{Key: "provider", Value: c.Provider})
+	}
+	if c.Action != "" {
+		entries = append(entries, yaml.MapItem{Key: "action", Value: c.Action})
+	}
+	if c.Event != "" {
+		entries = append(entries, yaml.MapItem{Key: "event", Value: c.Event})
+	}
+	for _, v := range c.Values {
+		entries = append(entries, yaml.MapItem{Key: v.Name, Value: v.Value})
+	}
+
+	return entries, nil
+}
+
+// FindConfig returns the config entry with the given name, or nil if there isn't one
+func (s *Snap) FindConfig(name string) *Config {
+	for i := range s.Config {
+		if s.Config[i].Name == name {
+			return &s.Config[i]
+		}
+	}
+
+	return nil
+}
+
+// TriggerStep resolves the trigger into a step.  If there is no config entry named
+// after the trigger, the trigger is taken to be the provider name.
+func (s *Snap) TriggerStep() Step {
+	config := s.FindConfig(s.Trigger)
+	if config == nil {
+		return Step{Name: s.Trigger, Provider: s.Trigger}
+	}
+
+	return Step{
+		Name:     config.Name,
+		Provider: config.Provider,
+		Event:    config.Event,
+		Values:   config.Values,
+	}
+}
+
+// ActionSteps resolves each of the actions into a step, in order
+func (s *Snap) ActionSteps() []Step {
+	steps := make([]Step, 0, len(s.Actions))
+	for _, action := range s.Actions {
+		config := action.Inline
+		if config == nil {
+			config = s.FindConfig(action.Name)
+		}
+		if config == nil {
+			steps = append(steps, Step{Name: action.Name})
+			continue
+		}
+
+		steps = append(steps, Step{
+			Name:     action.Name,
+			Provider: config.Provider,
+			Action:   config.Action,
+			Event:    config.Event,
+			Values:   config.Values,
+		})
+	}
+
+	return steps
+}
+
+// TriggerProvider returns the provider of the snap's trigger
+func (s *Snap) TriggerProvider() string {
+	return s.TriggerStep().Provider
+}
+
+// ActionProviders returns the sorted, unique providers used by the snap's actions
+func (s *Snap) ActionProviders() []string {
+	var providers []string
+	for _, step := range s.ActionSteps() {
+		if step.Provider != "" {
+			providers = append(providers, step.Provider)
+		}
+	}
+
+	return unique(providers)
+}
+
+// Tools returns the sorted, unique providers used by the snap's trigger and actions
+func (s *Snap) Tools() []string {
+	providers := s.ActionProviders()
+	if trigger := s.TriggerProvider(); trigger != "" {
+		providers = append(providers, trigger)
+	}
+
+	return unique(providers)
+}
+
+// FindParameter returns the snap parameter with the given name, or nil if there isn't one
+func (s *Snap) FindParameter(name string) *Parameter {
+	for i := range s.Parameters {
+		if s.Parameters[i].Name == name {
+			return &s.Parameters[i]
+		}
+	}
+
+	return nil
+}
+
+// References returns the sorted, unique names of the snap parameters referenced by the values
+func References(values []Value) []string {
+	var names []string
+	for _, v := range values {
+		for _, match := range paramReference.FindAllStringSubmatch(fmt.Sprintf("%v", v.Value), -1) {
+			names = append(names, match[1])
+		}
+	}
+
+	return unique(names)
+}
+
+// Expand replaces the references to snap parameters in the text with their values,
+// leaving references to parameters without a value unchanged
+func Expand(text string, values map[string]string) string {
+	return paramReference.ReplaceAllStringFunc(text, func(reference string) string {
+		name := paramReference.FindStringSubmatch(reference)[1]
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return reference
+	})
+}
+
+// Connection returns the name of the credential set the step uses, from its "connection"
+// value, or "" if the step doesn't name one (and so uses the tool's default)
+func (s Step) Connection() string {
+	for _, v := range s.Values {
+		if v.Name == "connection" {
+			return fmt.Sprintf("%v", v.Value)
+		}
+	}
+
+	return ""
+}
+
+// Validate checks that the definition is complete and internally consistent
+func (s *Snap) Validate() error {
+	var problems []string
+
+	if s.Name == "" {
+		problems = append(problems, "name is required")
+	}
+	if s.Trigger == "" {
+		problems = append(problems, "trigger is required")
+	} else if s.TriggerProvider() == "" {
+		problems = append(problems, fmt.Sprintf("trigger '%s' does not specify a provider", s.Trigger))
+	}
+	if len(s.Actions) == 0 {
+		problems = append(problems, "at least one action is required")
+	}
+
+	// every action must resolve to a provider and an action
+	for _, step := range s.ActionSteps() {
+		if step.Provider == "" {
+			problems = append(problems, fmt.Sprintf("action '%s' has no config entry with a provider", step.Name))
+		} else if step.Action == "" {
+			problems = append(problems, fmt.Sprintf("action '%s' does not specify an action", step.Name))
+		}
+	}
+
+	// every parameter reference must be declared
+	steps := append([]Step{s.TriggerStep()}, s.ActionSteps()...)
+	for _, step := range steps {
+		for _, name := range References(step.Values) {
+			if s.FindParameter(name) == nil {
+				problems = append(problems, fmt.Sprintf("'%s' references undeclared parameter '%s'", step.Name, name))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+
+	return nil
+}
+
+// unique sorts the strings and removes duplicates
+func unique(values []string) []string {
+	seen := make(map[string]bool)
+	result := []string{}
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	sort.Strings(result)
+
+	return result
+}
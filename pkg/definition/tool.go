@@ -0,0 +1,164 @@
+package definition
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Tool defines the fields of a tool definition
+type Tool struct {
+	Name        string      `yaml:"name" json:"name"`
+	Provider    string      `yaml:"provider,omitempty" json:"provider,omitempty"`
+	Description string      `yaml:"description,omitempty" json:"description,omitempty"`
+	Type        string      `yaml:"type,omitempty" json:"type,omitempty"`
+	Connection  Connection  `yaml:"connection,omitempty" json:"connection,omitempty"`
+	Triggers    []Operation `yaml:"triggers,omitempty" json:"triggers,omitempty"`
+	Actions     []Operation `yaml:"actions,omitempty" json:"actions,omitempty"`
+}
+
+// Connection defines the connection information a tool requires
+type Connection struct {
+	Type           string      `yaml:"type,omitempty" json:"type,omitempty"`
+	ConnectionInfo []Parameter `yaml:"connectionInfo,omitempty" json:"connectionInfo,omitempty"`
+}
+
+// Operation defines a trigger or action that a tool exposes, and its parameters
+type Operation struct {
+	Name        string      `yaml:"name" json:"name"`
+	Description string      `yaml:"description,omitempty" json:"description,omitempty"`
+	Events      []string    `yaml:"events,omitempty" json:"events,omitempty"`
+	Parameters  []Parameter `yaml:"parameters,omitempty" json:"parameters,omitempty"`
+}
+
+// ParseTool parses the text of a tool definition
+func ParseTool(text []byte) (*Tool, error) {
+	var tool Tool
+	if err := yaml.Unmarshal(text, &tool); err != nil {
+		return nil, err
+	}
+
+	return &tool, nil
+}
+
+// FindAction returns the action with the given name, or nil if there isn't one
+func (t *Tool) FindAction(name string) *Operation {
+	for i := range t.Actions {
+		if t.Actions[i].Name == name {
+			return &t.Actions[i]
+		}
+	}
+
+	return nil
+}
+
+// FindTrigger returns the trigger with the given name, or nil if there isn't one
+func (t *Tool) FindTrigger(name string) *Operation {
+	for i := range t.Triggers {
+		if t.Triggers[i].Name == name {
+			return &t.Triggers[i]
+		}
+	}
+
+	return nil
+}
+
+// ParameterNames returns the names of the operation's parameters, in order
+func (o *Operation) ParameterNames() []string {
+	names := make([]string, len(o.Parameters))
+	for i, p := range o.Parameters {
+		names[i] = p.Name
+	}
+
+	return names
+}
+
+// ExampleStep returns an example config entry for a snap step that uses the operation,
+// with a placeholder and a descriptive comment for each parameter.  The entry is indented
+// to fit under the "config:" key of a snap definition.
+func ExampleStep(provider string, operation *Operation, trigger bool) string {
+	return ConfigEntry(operation.Name, provider, operation, trigger)
+}
+
+// ConfigEntry returns an example config entry like ExampleStep, with the given entry name
+func ConfigEntry(name string, provider string, operation *Operation, trigger bool) string {
+	var b strings.Builder
+	writeStep(&b, name, provider, operation, trigger, nil)
+
+	return b.String()
+}
+
+// writeStep writes a config entry for a snap step that uses the operation.  Parameters in
+// references are set to a reference to the snap parameter of the same name, and the others
+// to a placeholder.
+func writeStep(b *strings.Builder, name string, provider string, operation *Operation, trigger bool, references map[string]bool) {
+	fmt.Fprintf(b, "  - name: %s\n", name)
+	fmt.Fprintf(b, "    provider: %s\n", provider)
+	if trigger {
+		if len(operation.Events) > 0 {
+			fmt.Fprintf(b, "    event: %s  # one of: %s\n", operation.Events[0], strings.Join(operation.Events, ", "))
+		}
+	} else {
+		fmt.Fprintf(b, "    action: %s\n", operation.Name)
+	}
+
+	for _, p := range operation.Parameters {
+		value := Placeholder(p)
+		if references[p.Name] {
+			value = "$" + p.Name
+		}
+		fmt.Fprintf(b, "    %s: %s", p.Name, value)
+		if comment := parameterComment(p); comment != "" {
+			fmt.Fprintf(b, "  # %s", comment)
+		}
+		b.WriteString("\n")
+	}
+}
+
+// Placeholder returns a placeholder value for a parameter: its default if it has one,
+// quoted unless it is a plain YAML scalar, and otherwise its type in angle brackets
+func Placeholder(p Parameter) string {
+	if p.Default != "" {
+		return scalar(p.Default)
+	}
+
+	paramType := p.Type
+	if paramType == "" {
+		paramType = "string"
+	}
+
+	return fmt.Sprintf("<%s>", paramType)
+}
+
+// parameterComment describes a parameter for use in a YAML comment, on a single line
+func parameterComment(p Parameter) string {
+	var parts []string
+	if p.Required {
+		parts = append(parts, "(required)")
+	}
+	if p.Description != "" {
+		parts = append(parts, strings.Join(strings.Fields(p.Description), " "))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// scalar returns the value as a YAML scalar for a single line of a definition: as is if it
+// reads back as the same value, and otherwise double-quoted (YAML escapes are a superset of
+// the ones strconv.Quote uses)
+func scalar(value string) string {
+	var parsed interface{}
+	if !strings.ContainsAny(value, "\r\n") && yaml.Unmarshal([]byte(value), &parsed) == nil {
+		switch parsed.(type) {
+		case nil, map[interface{}]interface{}, []interface{}:
+		default:
+			if fmt.Sprint(parsed) == value {
+				return value
+			}
+		}
+	}
+
+	return strconv.Quote(value)
+}
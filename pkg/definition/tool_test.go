@@ -0,0 +1,45 @@
+package definition
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestPlaceholder(t *testing.T) {
+	tests := []struct {
+		param Parameter
+		want  string
+	}{
+		{Parameter{Name: "image"}, "<string>"},
+		{Parameter{Name: "replicas", Type: "number"}, "<number>"},
+		{Parameter{Name: "branch", Default: "main"}, "main"},
+		{Parameter{Name: "port", Default: "8080"}, "8080"},
+		{Parameter{Name: "debug", Default: "false"}, "false"},
+		{Parameter{Name: "selector", Default: "a: b"}, `"a: b"`},
+		{Parameter{Name: "channel", Default: "#x"}, `"#x"`},
+		{Parameter{Name: "list", Default: "[a, b]"}, `"[a, b]"`},
+		{Parameter{Name: "answer", Default: "yes"}, `"yes"`},
+		{Parameter{Name: "padded", Default: " x "}, `" x "`},
+		{Parameter{Name: "message", Default: "line 1\nline 2"}, `"line 1\nline 2"`},
+	}
+
+	for _, tt := range tests {
+		got := Placeholder(tt.param)
+		if got != tt.want {
+			t.Errorf("Placeholder(%+v) = %s, want %s", tt.param, got, tt.want)
+			continue
+		}
+		if tt.param.Default == "" {
+			continue
+		}
+
+		// the placeholder reads back as the default
+		var entry map[string]string
+		if err := yaml.Unmarshal([]byte("value: "+got), &entry); err != nil {
+			t.Errorf("Placeholder(%+v) = %s isn't valid YAML: %s", tt.param, got, err)
+		} else if entry["value"] != tt.param.Default {
+			t.Errorf("Placeholder(%+v) = %s reads back as %q", tt.param, got, entry["value"])
+		}
+	}
+}
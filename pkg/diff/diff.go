@@ -0,0 +1,196 @@
+// Package diff computes line-based unified diffs, for showing changes to snap definitions
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// number of unchanged lines shown around each change
+const contextLines = 3
+
+// operation is a line of an edit script: kept (' '), deleted ('-'), or inserted ('+')
+type operation struct {
+	kind byte
+	text string
+}
+
+// Unified returns a unified diff from the from text to the to text, labelled with the
+// names, or "" if the texts have the same lines
+func Unified(fromName string, toName string, from string, to string) string {
+	ops := lines(splitLines(from), splitLines(to))
+
+	var b strings.Builder
+	for _, hunk := range hunks(ops) {
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		b.WriteString(hunk)
+	}
+
+	return b.String()
+}
+
+// Colorize colors the deleted lines of a unified diff in red, the inserted lines in green,
+// and the hunk headers in cyan
+func Colorize(unified string) string {
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(unified, "\n") {
+		switch {
+		case strings.HasPrefix(line, "---") || strings.HasPrefix(line, "+++"):
+			b.WriteString(color.New(color.Bold).Sprint(line))
+		case strings.HasPrefix(line, "@@"):
+			b.WriteString(color.CyanString(line))
+		case strings.HasPrefix(line, "-"):
+			b.WriteString(color.RedString(line))
+		case strings.HasPrefix(line, "+"):
+			b.WriteString(color.GreenString(line))
+		default:
+			b.WriteString(line)
+		}
+	}
+
+	return b.String()
+}
+
+// splitLines splits the text into lines, without a final empty line for a trailing newline
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// lines returns the shortest edit script from a to b, using the longest common subsequence
+// of their lines
+func lines(a []string, b []string) []operation {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []operation
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, operation{' ', a[i]})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, operation{'+', b[j]})
+			j++
+		default:
+			ops = append(ops, operation{'-', a[i]})
+			i++
+		}
+	}
+
+	return ops
+}
+
+// hunks groups the changes of the edit script with their context into unified diff hunks
+func hunks(ops []operation) []string {
+	var result []string
+	for start := 0; start < len(ops); {
+		// find the next change
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+
+		// extend the hunk until there are more than two contexts' worth of unchanged lines
+		end := start
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*contextLines {
+				break
+			}
+			end = run
+		}
+
+		from := max(start-contextLines, 0)
+		to := min(end+contextLines, len(ops))
+		result = append(result, hunk(ops, from, to))
+		start = to
+	}
+
+	return result
+}
+
+// hunk formats the operations from..to as a hunk, with its header
+func hunk(ops []operation, from int, to int) string {
+	// line numbers (1-based) of the first line of the hunk in each text
+	aLine, bLine := 1, 1
+	for _, op := range ops[:from] {
+		if op.kind != '+' {
+			aLine++
+		}
+		if op.kind != '-' {
+			bLine++
+		}
+	}
+
+	var aCount, bCount int
+	var body strings.Builder
+	for _, op := range ops[from:to] {
+		if op.kind != '+' {
+			aCount++
+		}
+		if op.kind != '-' {
+			bCount++
+		}
+		fmt.Fprintf(&body, "%c%s\n", op.kind, op.text)
+	}
+
+	return fmt.Sprintf("@@ -%s +%s @@\n%s", span(aLine, aCount), span(bLine, bCount), body.String())
+}
+
+// span formats the start and length of a hunk's lines in one of the texts
+func span(start int, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func max(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
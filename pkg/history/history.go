@@ -0,0 +1,205 @@
+// Package history keeps a local journal of the revisions of snap definitions, for servers
+// that don't keep a version history.  The journal of a snap is a directory under
+// $HOME/.config/snap/history/<account>/<snapId>/, holding the definition of each revision
+// in <revision>.yaml, an index of the revisions in revisions.json, and the revision each
+// active snap was activated with in activations.json.
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned for a revision that isn't in the journal
+var ErrNotFound = errors.New("revision not found")
+
+// Revision is a revision of a snap definition
+type Revision struct {
+	Revision int `json:"revision"`
+	// Action is the change that created the revision: create, fork, edit, rollback, or
+	// observed for a definition that was changed outside the CLI
+	Action string `json:"action"`
+	// From is the revision restored by a rollback
+	From      int    `json:"from,omitempty"`
+	Timestamp int64  `json:"timestamp"`
+	User      string `json:"user,omitempty"`
+	Text      string `json:"text,omitempty"`
+}
+
+// Describe returns a short description of the change that created the revision
+func (r Revision) Describe() string {
+	if r.Action == "rollback" && r.From > 0 {
+		return fmt.Sprintf("rollback to %d", r.From)
+	}
+
+	return r.Action
+}
+
+// Journal is the local journal of a snap
+type Journal struct {
+	dir string
+}
+
+// Open returns the journal of the snap in the account; the journal is created when the
+// first revision is appended
+func Open(account string, snapID string) (*Journal, error) {
+	homedir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	// snap IDs are account/name, which become nested directories
+	parts := append([]string{account}, strings.Split(snapID, "/")...)
+	for _, part := range parts {
+		if part == "" || part == "." || part == ".." || strings.ContainsAny(part, `\:`) {
+			return nil, fmt.Errorf("invalid history path %s/%s", account, snapID)
+		}
+	}
+
+	return &Journal{dir: filepath.Join(append([]string{homedir, ".config", "snap", "history"}, parts...)...)}, nil
+}
+
+// Dir returns the directory of the journal
+func (j *Journal) Dir() string {
+	return j.dir
+}
+
+// Revisions returns the revisions in the journal, oldest first, without their text
+func (j *Journal) Revisions() ([]Revision, error) {
+	var revisions []Revision
+	if err := j.read("revisions.json", &revisions); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// Get returns a revision, with its text
+func (j *Journal) Get(revision int) (*Revision, error) {
+	revisions, err := j.Revisions()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range revisions {
+		if r.Revision == revision {
+			text, err := ioutil.ReadFile(j.textFile(revision))
+			if err != nil {
+				return nil, err
+			}
+			r.Text = string(text)
+			return &r, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// Latest returns the latest revision, with its text, or nil if the journal is empty
+func (j *Journal) Latest() (*Revision, error) {
+	revisions, err := j.Revisions()
+	if err != nil || len(revisions) == 0 {
+		return nil, err
+	}
+
+	return j.Get(revisions[len(revisions)-1].Revision)
+}
+
+// Append adds a revision with the action, restored revision (for a rollback), user, and
+// text of the change, and returns it.  If the text is the same as the latest revision's,
+// nothing is added and the latest revision is returned.
+func (j *Journal) Append(change Revision) (*Revision, error) {
+	latest, err := j.Latest()
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && latest.Text == change.Text {
+		return latest, nil
+	}
+
+	revisions, err := j.Revisions()
+	if err != nil {
+		return nil, err
+	}
+	change.Revision = 1
+	if latest != nil {
+		change.Revision = latest.Revision + 1
+	}
+	change.Timestamp = time.Now().UnixNano() / int64(time.Millisecond)
+
+	if err := os.MkdirAll(j.dir, 0700); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(j.textFile(change.Revision), []byte(change.Text), 0600); err != nil {
+		return nil, err
+	}
+
+	// the index doesn't repeat the text
+	entry := change
+	entry.Text = ""
+	if err := j.write("revisions.json", append(revisions, entry)); err != nil {
+		return nil, err
+	}
+
+	return &change, nil
+}
+
+// Activation returns the revision an active snap was activated with, or 0 if it isn't known
+func (j *Journal) Activation(activeSnapID string) int {
+	activations := make(map[string]int)
+	j.read("activations.json", &activations)
+
+	return activations[activeSnapID]
+}
+
+// SetActivation records the revision an active snap was activated with
+func (j *Journal) SetActivation(activeSnapID string, revision int) error {
+	activations := make(map[string]int)
+	if err := j.read("activations.json", &activations); err != nil {
+		return err
+	}
+	activations[activeSnapID] = revision
+
+	if err := os.MkdirAll(j.dir, 0700); err != nil {
+		return err
+	}
+	return j.write("activations.json", activations)
+}
+
+// textFile returns the path of the file with the text of a revision
+func (j *Journal) textFile(revision int) string {
+	return filepath.Join(j.dir, fmt.Sprintf("%d.yaml", revision))
+}
+
+// read unmarshals a JSON file of the journal into value, leaving it unchanged if the file
+// doesn't exist
+func (j *Journal) read(filename string, value interface{}) error {
+	contents, err := ioutil.ReadFile(filepath.Join(j.dir, filename))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(contents, value); err != nil {
+		return fmt.Errorf("could not parse %s: %s", filepath.Join(j.dir, filename), err)
+	}
+	return nil
+}
+
+// write marshals value into a JSON file of the journal
+func (j *Journal) write(filename string, value interface{}) error {
+	contents, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(j.dir, filename), contents, 0600)
+}
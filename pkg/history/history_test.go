@@ -0,0 +1,180 @@
+package history
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// openTestJournal opens a journal in a temporary home directory that is removed when the
+// test ends
+func openTestJournal(t *testing.T, account string, snapID string) *Journal {
+	t.Helper()
+
+	home, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := os.Getenv("HOME")
+	os.Setenv("HOME", home)
+	t.Cleanup(func() {
+		os.Setenv("HOME", previous)
+		os.RemoveAll(home)
+	})
+
+	journal, err := Open(account, snapID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(home, ".config", "snap", "history", account, filepath.FromSlash(snapID)); journal.Dir() != want {
+		t.Fatalf("journal directory %s, want %s", journal.Dir(), want)
+	}
+	return journal
+}
+
+func TestJournal(t *testing.T) {
+	journal := openTestJournal(t, "acct", "acct/deploy")
+
+	// an empty journal has no revisions, and doesn't exist on disk yet
+	revisions, err := journal.Revisions()
+	if err != nil || len(revisions) != 0 {
+		t.Fatalf("revisions %v, error %v for an empty journal", revisions, err)
+	}
+	if latest, err := journal.Latest(); latest != nil || err != nil {
+		t.Fatalf("latest %v, error %v for an empty journal", latest, err)
+	}
+	if _, err := os.Stat(journal.Dir()); !os.IsNotExist(err) {
+		t.Fatalf("the journal was created before any revision: %v", err)
+	}
+
+	changes := []Revision{
+		{Action: "create", User: "user", Text: "name: deploy\n"},
+		{Action: "edit", User: "user", Text: "name: deploy\ndescription: v2\n"},
+		{Action: "rollback", From: 1, User: "user", Text: "name: deploy\n"},
+	}
+	for i, change := range changes {
+		r, err := journal.Append(change)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Revision != i+1 || r.Action != change.Action || r.Text != change.Text || r.Timestamp == 0 {
+			t.Errorf("appended %+v for %+v", r, change)
+		}
+	}
+
+	// the same text as the latest revision isn't journaled again
+	r, err := journal.Append(Revision{Action: "observed", Text: "name: deploy\n"})
+	if err != nil || r.Revision != 3 || r.Action != "rollback" {
+		t.Errorf("appended %+v, error %v for an unchanged text", r, err)
+	}
+
+	revisions, err = journal.Revisions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 3 {
+		t.Fatalf("%d revisions, want 3: %+v", len(revisions), revisions)
+	}
+	for i, r := range revisions {
+		if r.Revision != i+1 || r.Action != changes[i].Action || r.From != changes[i].From || r.User != "user" {
+			t.Errorf("revision %d is %+v", i+1, r)
+		}
+		// the index doesn't hold the texts
+		if r.Text != "" {
+			t.Errorf("revision %d was listed with its text", r.Revision)
+		}
+	}
+	if description := revisions[2].Describe(); description != "rollback to 1" {
+		t.Errorf("described the rollback as %q", description)
+	}
+
+	r, err = journal.Get(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Revision != 2 || r.Action != "edit" || r.Text != changes[1].Text {
+		t.Errorf("got %+v for revision 2", r)
+	}
+	if r, err = journal.Latest(); err != nil || r.Revision != 3 || r.Text != changes[2].Text {
+		t.Errorf("latest %+v, error %v", r, err)
+	}
+	for _, revision := range []int{0, 4, -1} {
+		if _, err := journal.Get(revision); err != ErrNotFound {
+			t.Errorf("error %v for revision %d, want ErrNotFound", err, revision)
+		}
+	}
+
+	// journals of other snaps are separate
+	other, err := Open("acct", "acct/build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revisions, err := other.Revisions(); err != nil || len(revisions) != 0 {
+		t.Errorf("revisions %v, error %v for another snap", revisions, err)
+	}
+}
+
+func TestJournalActivations(t *testing.T) {
+	journal := openTestJournal(t, "acct", "acct/deploy")
+
+	if revision := journal.Activation("active-1"); revision != 0 {
+		t.Errorf("revision %d for an unknown activation", revision)
+	}
+	if err := journal.SetActivation("active-1", 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.SetActivation("active-2", 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.SetActivation("active-1", 4); err != nil {
+		t.Fatal(err)
+	}
+	if revision := journal.Activation("active-1"); revision != 4 {
+		t.Errorf("revision %d for active-1, want 4", revision)
+	}
+	if revision := journal.Activation("active-2"); revision != 3 {
+		t.Errorf("revision %d for active-2, want 3", revision)
+	}
+}
+
+func TestJournalCorrupt(t *testing.T) {
+	journal := openTestJournal(t, "acct", "acct/deploy")
+
+	if err := os.MkdirAll(journal.Dir(), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(journal.Dir(), "revisions.json"), []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := journal.Revisions(); err == nil {
+		t.Error("no error listing a corrupt index")
+	}
+	if _, err := journal.Get(1); err == nil || err == ErrNotFound {
+		t.Errorf("error %v getting a revision from a corrupt index", err)
+	}
+	if _, err := journal.Append(Revision{Action: "edit", Text: "name: deploy\n"}); err == nil {
+		t.Error("no error appending to a corrupt index")
+	}
+}
+
+func TestOpenInvalidPath(t *testing.T) {
+	tests := []struct {
+		account string
+		snapID  string
+	}{
+		{"", "acct/deploy"},
+		{"acct", "../deploy"},
+		{"acct", "acct/.."},
+		{"acct", "acct//deploy"},
+		{"acct", `acct\deploy`},
+		{"acct", "c:deploy"},
+		{"..", "deploy"},
+	}
+	for _, test := range tests {
+		if _, err := Open(test.account, test.snapID); err == nil {
+			t.Errorf("no error opening the journal of %s/%s", test.account, test.snapID)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+// Package importer reads tool credentials from local sources, such as a kubeconfig
+// context or an AWS shared-credentials profile, and maps them onto the connectionInfo
+// parameters of a tool.
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Value is a credential value read from a local source.  Key is the canonical name of the
+// value (e.g. "token"), which is matched against the tool's parameter names.
+type Value struct {
+	Key    string
+	Value  string
+	Secret bool
+}
+
+// Credentials are the values read from a local source
+type Credentials struct {
+	// Source describes where the values were read from, e.g. "kubeconfig context prod (~/.kube/config)"
+	Source string
+	// Name is a suggested name for the credential set, e.g. the context or profile name
+	Name   string
+	Values []Value
+}
+
+// Options select the entries of the local sources to import
+type Options struct {
+	KubeContext  string
+	AWSProfile   string
+	NetrcMachine string
+}
+
+// Importer reads credentials from one kind of local source
+type Importer struct {
+	Name        string
+	Description string
+	// Tools are the providers the importer applies to; an empty list applies to all tools
+	Tools  []string
+	Import func(tool string, options Options) (*Credentials, error)
+}
+
+// ErrNotFound is returned by an importer when its source doesn't exist or has no entry for the tool
+var ErrNotFound = errors.New("no credentials found")
+
+// Importers are the available importers, in the order they are tried
+var Importers = []Importer{
+	{
+		Name:        "kubeconfig",
+		Description: "a context of the kubeconfig file ($KUBECONFIG or ~/.kube/config)",
+		Tools:       []string{"kubernetes", "k8s", "gke", "eks", "aks"},
+		Import:      importKubeconfig,
+	},
+	{
+		Name:        "gcp",
+		Description: "the service-account key file in $GOOGLE_APPLICATION_CREDENTIALS",
+		Tools:       []string{"gcp", "gke", "google", "gcs", "bigquery"},
+		Import:      importGCP,
+	},
+	{
+		Name:        "aws",
+		Description: "a profile of the AWS shared-credentials file ($AWS_SHARED_CREDENTIALS_FILE or ~/.aws/credentials)",
+		Tools:       []string{"aws", "eks", "s3", "lambda"},
+		Import:      importAWS,
+	},
+	{
+		Name:        "github",
+		Description: "the $GITHUB_TOKEN environment variable",
+		Tools:       []string{"github"},
+		Import:      importGitHub,
+	},
+	{
+		Name:        "netrc",
+		Description: "a machine entry of the netrc file ($NETRC or ~/.netrc)",
+		Import:      importNetrc,
+	},
+}
+
+// Find returns the importer with the name, or nil if there isn't one
+func Find(name string) *Importer {
+	for i := range Importers {
+		if Importers[i].Name == name {
+			return &Importers[i]
+		}
+	}
+
+	return nil
+}
+
+// AppliesTo returns whether the importer can import credentials for the tool
+func (i *Importer) AppliesTo(tool string) bool {
+	if len(i.Tools) == 0 {
+		return true
+	}
+	for _, t := range i.Tools {
+		if strings.EqualFold(t, tool) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Import returns the credentials of the first importer that applies to the tool and finds
+// credentials for it, or of the named importer if source isn't empty
+func Import(tool string, source string, options Options) (*Credentials, error) {
+	if source != "" {
+		i := Find(source)
+		if i == nil {
+			return nil, fmt.Errorf("unknown source %s (sources: %s)", source, strings.Join(Names(), ", "))
+		}
+		return i.Import(tool, options)
+	}
+
+	for _, i := range Importers {
+		if !i.AppliesTo(tool) {
+			continue
+		}
+		credentials, err := i.Import(tool, options)
+		if err == ErrNotFound {
+			continue
+		}
+		return credentials, err
+	}
+
+	return nil, fmt.Errorf("no local credentials found for %s", tool)
+}
+
+// aliases are the parameter names that each canonical key maps onto, compared without
+// case and punctuation
+var aliases = map[string][]string{
+	"token":           {"token", "accesstoken", "apitoken", "authtoken", "pat", "personalaccesstoken", "bearertoken"},
+	"username":        {"username", "user", "login"},
+	"password":        {"password", "pass", "secret"},
+	"kubeconfig":      {"kubeconfig", "config", "kubeconfigfile"},
+	"server":          {"server", "endpoint", "apiserver", "url", "host"},
+	"context":         {"context", "contextname", "cluster"},
+	"certificate":     {"certificate", "cacert", "certificateauthority", "certificateauthoritydata", "cadata"},
+	"key":             {"key", "servicekey", "serviceaccountkey", "serviceaccount", "keyfile", "credentials", "json"},
+	"projectId":       {"projectid", "project"},
+	"clientEmail":     {"clientemail", "email", "serviceaccountemail"},
+	"accessKeyId":     {"accesskeyid", "awsaccesskeyid", "accesskey"},
+	"secretAccessKey": {"secretaccesskey", "awssecretaccesskey", "secretkey"},
+	"sessionToken":    {"sessiontoken", "awssessiontoken"},
+	"region":          {"region", "awsregion", "defaultregion"},
+}
+
+// Map stores the credential values in the "value" key of the matching tool parameters, and
+// the credential set name in the parameter of type "name".  Parameters that already have a
+// value are left unchanged.  It returns the parameters that are still missing a value.
+func Map(credentials *Credentials, params []map[string]string, name string) []map[string]string {
+	var missing []map[string]string
+	for _, param := range params {
+		if param["value"] != "" {
+			continue
+		}
+		if param["type"] == "name" {
+			param["value"] = name
+			continue
+		}
+
+		for _, v := range credentials.Values {
+			if matches(v.Key, param["name"]) {
+				param["value"] = v.Value
+				break
+			}
+		}
+		if param["value"] == "" {
+			missing = append(missing, param)
+		}
+	}
+
+	return missing
+}
+
+// IsSecret returns whether the parameter's value came from a secret credential value, or
+// doesn't match any of them (and so may be a secret)
+func IsSecret(credentials *Credentials, param map[string]string) bool {
+	if param["type"] == "name" {
+		return false
+	}
+	for _, v := range credentials.Values {
+		if matches(v.Key, param["name"]) {
+			return v.Secret
+		}
+	}
+
+	return true
+}
+
+// Redact hides a secret value, showing only its length
+func Redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	return fmt.Sprintf("******** (%d characters)", len(value))
+}
+
+// matches returns whether the parameter name is one of the aliases of the canonical key
+func matches(key string, paramName string) bool {
+	name := normalize(paramName)
+	for _, alias := range aliases[key] {
+		if name == alias {
+			return true
+		}
+	}
+
+	return name == normalize(key)
+}
+
+// normalize lowercases the name and removes everything but letters and digits
+func normalize(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		if r >= 'A' && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+		return -1
+	}, name)
+}
+
+// Names returns the names of the importers
+func Names() []string {
+	result := make([]string, len(Importers))
+	for i, importer := range Importers {
+		result[i] = importer.Name
+	}
+
+	return result
+}
+
+// homePath returns the path of a file in the user's home directory
+func homePath(elem ...string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(append([]string{home}, elem...)...)
+}
+
+// readSource reads a local source file, returning ErrNotFound if it doesn't exist
+func readSource(path string) ([]byte, error) {
+	if path == "" {
+		return nil, ErrNotFound
+	}
+	contents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+
+	return contents, err
+}
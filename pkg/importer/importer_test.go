@@ -0,0 +1,474 @@
+package importer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestRedact(t *testing.T) {
+	tests := map[string]string{
+		"":                           "",
+		"short":                      "******** (5 characters)",
+		"ghp_0123456789abcdefghijkl": "******** (26 characters)",
+	}
+	// no part of a value is shown, however long it is
+	for value, want := range tests {
+		if redacted := Redact(value); redacted != want {
+			t.Errorf("Redact(%q) = %q, want %q", value, redacted, want)
+		}
+	}
+}
+
+// sourceEnv are the environment variables that the importers read
+var sourceEnv = []string{
+	"HOME", "KUBECONFIG", "GOOGLE_APPLICATION_CREDENTIALS", "AWS_PROFILE", "AWS_REGION",
+	"AWS_SHARED_CREDENTIALS_FILE", "AWS_CONFIG_FILE", "GITHUB_TOKEN", "NETRC",
+}
+
+// setSourceEnv clears the importers' environment, pointing HOME at an empty directory so
+// that no real source is read, and then sets env.  The environment is restored when the
+// test ends.
+func setSourceEnv(t *testing.T, env map[string]string) {
+	home, err := ioutil.TempDir("", "importer")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved := make(map[string]*string)
+	for _, name := range sourceEnv {
+		if value, ok := os.LookupEnv(name); ok {
+			saved[name] = &value
+		} else {
+			saved[name] = nil
+		}
+		os.Unsetenv(name)
+	}
+	t.Cleanup(func() {
+		for name, value := range saved {
+			if value == nil {
+				os.Unsetenv(name)
+			} else {
+				os.Setenv(name, *value)
+			}
+		}
+		os.RemoveAll(home)
+	})
+
+	os.Setenv("HOME", home)
+	for name, value := range env {
+		os.Setenv(name, value)
+	}
+}
+
+// fixture returns the path of a file under testdata
+func fixture(name string) string {
+	return filepath.Join("testdata", name)
+}
+
+// valueMap returns the credential values by key
+func valueMap(credentials *Credentials) map[string]string {
+	values := make(map[string]string)
+	for _, v := range credentials.Values {
+		values[v.Key] = v.Value
+	}
+
+	return values
+}
+
+// importTest is a test of one importer: the credentials' name and values, or the error
+type importTest struct {
+	name    string
+	env     map[string]string
+	options Options
+	tool    string
+	want    string
+	values  map[string]string
+	err     string
+}
+
+func runImportTests(t *testing.T, importer func(string, Options) (*Credentials, error), tests []importTest) {
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setSourceEnv(t, test.env)
+
+			credentials, err := importer(test.tool, test.options)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("error = %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if credentials.Name != test.want {
+				t.Errorf("name = %q, want %q", credentials.Name, test.want)
+			}
+			values := valueMap(credentials)
+			for key, want := range test.values {
+				if values[key] != want {
+					t.Errorf("%s = %q, want %q", key, values[key], want)
+				}
+			}
+			for key := range values {
+				if _, ok := test.values[key]; !ok && key != "kubeconfig" && key != "key" {
+					t.Errorf("unexpected value %s = %q", key, values[key])
+				}
+			}
+		})
+	}
+}
+
+func TestImportKubeconfig(t *testing.T) {
+	both := fixture("kubeconfig") + string(filepath.ListSeparator) + fixture("kubeconfig-dev")
+	runImportTests(t, importKubeconfig, []importTest{
+		{
+			name:   "current context",
+			env:    map[string]string{"KUBECONFIG": fixture("kubeconfig")},
+			want:   "prod",
+			values: map[string]string{"context": "prod", "server": "https://prod.example.com", "certificate": "Y2EtZGF0YQ==", "token": "prod-token"},
+		},
+		{
+			name:    "named context",
+			env:     map[string]string{"KUBECONFIG": fixture("kubeconfig-dev")},
+			options: Options{KubeContext: "dev"},
+			want:    "dev",
+			values:  map[string]string{"context": "dev", "server": "https://dev.example.com", "token": "dev-token"},
+		},
+		{
+			name:    "context of a later file",
+			env:     map[string]string{"KUBECONFIG": both},
+			options: Options{KubeContext: "dev"},
+			want:    "dev",
+			values:  map[string]string{"context": "dev", "server": "https://dev.example.com", "token": "dev-token"},
+		},
+		{
+			// the first file sets both the current context and the prod entry
+			name:   "first file wins",
+			env:    map[string]string{"KUBECONFIG": both},
+			want:   "prod",
+			values: map[string]string{"context": "prod", "server": "https://prod.example.com", "certificate": "Y2EtZGF0YQ==", "token": "prod-token"},
+		},
+		{
+			name:    "missing files are skipped",
+			env:     map[string]string{"KUBECONFIG": fixture("missing") + string(filepath.ListSeparator) + fixture("kubeconfig-dev")},
+			options: Options{KubeContext: "dev"},
+			want:    "dev",
+			values:  map[string]string{"context": "dev", "server": "https://dev.example.com", "token": "dev-token"},
+		},
+		{
+			name:    "missing context",
+			env:     map[string]string{"KUBECONFIG": fixture("kubeconfig")},
+			options: Options{KubeContext: "staging"},
+			err:     `has no context "staging"`,
+		},
+		{
+			name:    "missing cluster",
+			env:     map[string]string{"KUBECONFIG": fixture("kubeconfig")},
+			options: Options{KubeContext: "dangling"},
+			err:     "refers to a missing cluster or user",
+		},
+		{
+			name:    "certificate file",
+			env:     map[string]string{"KUBECONFIG": fixture("kubeconfig")},
+			options: Options{KubeContext: "certfile"},
+			err:     "uses certificate-authority",
+		},
+		{
+			name:    "exec auth",
+			env:     map[string]string{"KUBECONFIG": fixture("kubeconfig")},
+			options: Options{KubeContext: "exec"},
+			err:     "uses exec",
+		},
+		{
+			name: "no file",
+			env:  map[string]string{"KUBECONFIG": fixture("missing")},
+			err:  ErrNotFound.Error(),
+		},
+	})
+}
+
+func TestImportKubeconfigMinimal(t *testing.T) {
+	setSourceEnv(t, map[string]string{"KUBECONFIG": fixture("kubeconfig")})
+
+	credentials, err := importKubeconfig("kubernetes", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the imported kubeconfig has only the selected context, cluster and user
+	var config kubeconfig
+	if err := yaml.Unmarshal([]byte(valueMap(credentials)["kubeconfig"]), &config); err != nil {
+		t.Fatal(err)
+	}
+	names := func(entries []namedKubeEntry) []string {
+		var result []string
+		for _, e := range entries {
+			result = append(result, e.Name)
+		}
+		return result
+	}
+	if config.CurrentContext != "prod" ||
+		!reflect.DeepEqual(names(config.Contexts), []string{"prod"}) ||
+		!reflect.DeepEqual(names(config.Clusters), []string{"prod-cluster"}) ||
+		!reflect.DeepEqual(names(config.Users), []string{"prod-user"}) {
+		t.Errorf("kubeconfig = %+v", config)
+	}
+}
+
+func TestImportGCP(t *testing.T) {
+	runImportTests(t, importGCP, []importTest{
+		{
+			name: "service account",
+			env:  map[string]string{"GOOGLE_APPLICATION_CREDENTIALS": fixture("gcp-key.json")},
+			want: "my-project",
+			values: map[string]string{
+				"projectId":   "my-project",
+				"clientEmail": "deployer@my-project.iam.gserviceaccount.com",
+			},
+		},
+		{
+			name: "user credentials",
+			env:  map[string]string{"GOOGLE_APPLICATION_CREDENTIALS": fixture("gcp-user.json")},
+			err:  `not a service-account key file (type "authorized_user")`,
+		},
+		{
+			name: "not json",
+			env:  map[string]string{"GOOGLE_APPLICATION_CREDENTIALS": fixture("netrc")},
+			err:  "could not parse",
+		},
+		{
+			name: "unset",
+			err:  ErrNotFound.Error(),
+		},
+	})
+}
+
+func TestImportAWS(t *testing.T) {
+	files := map[string]string{
+		"AWS_SHARED_CREDENTIALS_FILE": fixture("aws-credentials"),
+		"AWS_CONFIG_FILE":             fixture("aws-config"),
+	}
+	with := func(env map[string]string) map[string]string {
+		result := make(map[string]string)
+		for k, v := range files {
+			result[k] = v
+		}
+		for k, v := range env {
+			result[k] = v
+		}
+		return result
+	}
+
+	runImportTests(t, importAWS, []importTest{
+		{
+			name:   "default profile",
+			env:    files,
+			want:   "default",
+			values: map[string]string{"accessKeyId": "AKIADEFAULT", "secretAccessKey": "default-secret", "region": "us-west-2"},
+		},
+		{
+			name:    "named profile",
+			env:     files,
+			options: Options{AWSProfile: "staging"},
+			want:    "staging",
+			values: map[string]string{
+				"accessKeyId": "AKIASTAGING", "secretAccessKey": "staging-secret",
+				"sessionToken": "staging-session", "region": "eu-west-1",
+			},
+		},
+		{
+			name:   "AWS_PROFILE and AWS_REGION",
+			env:    with(map[string]string{"AWS_PROFILE": "staging", "AWS_REGION": "ap-south-1"}),
+			want:   "staging",
+			values: map[string]string{"accessKeyId": "AKIASTAGING", "secretAccessKey": "staging-secret", "sessionToken": "staging-session", "region": "ap-south-1"},
+		},
+		{
+			name:   "no config file",
+			env:    map[string]string{"AWS_SHARED_CREDENTIALS_FILE": fixture("aws-credentials")},
+			want:   "default",
+			values: map[string]string{"accessKeyId": "AKIADEFAULT", "secretAccessKey": "default-secret"},
+		},
+		{
+			name:    "missing profile",
+			env:     files,
+			options: Options{AWSProfile: "prod"},
+			err:     `has no profile "prod"`,
+		},
+		{
+			name:    "profile without keys",
+			env:     files,
+			options: Options{AWSProfile: "empty"},
+			err:     `profile "empty"`,
+		},
+		{
+			name: "no credentials file",
+			err:  ErrNotFound.Error(),
+		},
+	})
+}
+
+func TestParseINI(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     map[string]map[string]string
+	}{
+		{
+			name:     "sections",
+			contents: "[a]\nx = 1\n\n[ b c ]\ny=2=3\n",
+			want:     map[string]map[string]string{"a": {"x": "1"}, "b c": {"y": "2=3"}},
+		},
+		{
+			name:     "comments and keys before any section",
+			contents: "orphan = 1\n# comment\n[a]\n; x = 2\nx = 3\nnot a key\n",
+			want:     map[string]map[string]string{"a": {"x": "3"}},
+		},
+		{
+			name:     "empty section",
+			contents: "[a]\n",
+			want:     map[string]map[string]string{"a": {}},
+		},
+		{
+			name:     "empty",
+			contents: "",
+			want:     map[string]map[string]string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := parseINI([]byte(test.contents)); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("parseINI = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestImportGitHub(t *testing.T) {
+	runImportTests(t, importGitHub, []importTest{
+		{
+			name:   "token",
+			env:    map[string]string{"GITHUB_TOKEN": "ghp-env-token"},
+			want:   "default",
+			values: map[string]string{"token": "ghp-env-token"},
+		},
+		{
+			name: "unset",
+			err:  ErrNotFound.Error(),
+		},
+	})
+}
+
+func TestImportNetrc(t *testing.T) {
+	env := map[string]string{"NETRC": fixture("netrc")}
+	runImportTests(t, importNetrc, []importTest{
+		{
+			name:   "well-known host",
+			env:    env,
+			tool:   "GitHub",
+			want:   "default",
+			values: map[string]string{"username": "octocat", "password": "ghp-netrc-token", "token": "ghp-netrc-token", "server": "api.github.com"},
+		},
+		{
+			name:   "entry on one line",
+			env:    env,
+			tool:   "gitlab",
+			want:   "default",
+			values: map[string]string{"username": "gitlab-user", "password": "gitlab-pass", "token": "gitlab-pass", "server": "gitlab.com"},
+		},
+		{
+			name:    "named machine",
+			env:     env,
+			tool:    "custom",
+			options: Options{NetrcMachine: "gitlab.com"},
+			want:    "default",
+			values:  map[string]string{"username": "gitlab-user", "password": "gitlab-pass", "token": "gitlab-pass", "server": "gitlab.com"},
+		},
+		{
+			name:    "missing machine",
+			env:     env,
+			tool:    "github",
+			options: Options{NetrcMachine: "example.com"},
+			err:     "has no machine example.com",
+		},
+		{
+			// the default entry isn't used for a tool, since its login is for any machine
+			name: "no entry for the tool",
+			env:  env,
+			tool: "heroku",
+			err:  ErrNotFound.Error(),
+		},
+		{
+			name: "no file",
+			tool: "github",
+			err:  ErrNotFound.Error(),
+		},
+	})
+}
+
+func TestParseNetrc(t *testing.T) {
+	contents, err := ioutil.ReadFile(fixture("netrc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the macro and everything after it are skipped
+	want := map[string]map[string]string{
+		"api.github.com": {"login": "octocat", "password": "ghp-netrc-token"},
+		"gitlab.com":     {"login": "gitlab-user", "password": "gitlab-pass", "account": "ignored"},
+		"default":        {"login": "anonymous", "password": "default-pass"},
+	}
+	if got := parseNetrc(contents); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseNetrc = %v, want %v", got, want)
+	}
+
+	// a trailing keyword without a value is ignored
+	if got := parseNetrc([]byte("machine a login")); !reflect.DeepEqual(got, map[string]map[string]string{"a": {}}) {
+		t.Errorf("parseNetrc = %v", got)
+	}
+}
+
+func TestMap(t *testing.T) {
+	credentials := &Credentials{
+		Values: []Value{
+			{Key: "token", Value: "t0ken", Secret: true},
+			{Key: "server", Value: "https://example.com"},
+			{Key: "accessKeyId", Value: "AKIA"},
+		},
+	}
+	tests := []struct {
+		name    string
+		param   string
+		ptype   string
+		value   string
+		want    string
+		missing bool
+	}{
+		{name: "canonical key", param: "token", want: "t0ken"},
+		{name: "alias", param: "personalAccessToken", want: "t0ken"},
+		{name: "alias with punctuation", param: "API_TOKEN", want: "t0ken"},
+		{name: "alias of another key", param: "endpoint", want: "https://example.com"},
+		{name: "camel case key", param: "access-key-id", want: "AKIA"},
+		{name: "name parameter", param: "credentialName", ptype: "name", want: "ci"},
+		{name: "existing value", param: "token", value: "kept", want: "kept"},
+		{name: "no match", param: "region", missing: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			param := map[string]string{"name": test.param, "type": test.ptype, "value": test.value}
+			missing := Map(credentials, []map[string]string{param}, "ci")
+			if param["value"] != test.want {
+				t.Errorf("value = %q, want %q", param["value"], test.want)
+			}
+			if (len(missing) == 1) != test.missing {
+				t.Errorf("missing = %v, want missing %v", missing, test.missing)
+			}
+		})
+	}
+}
@@ -0,0 +1,365 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// kubeconfig defines the parts of a kubeconfig file that are imported
+type kubeconfig struct {
+	CurrentContext string           `yaml:"current-context"`
+	Contexts       []namedKubeEntry `yaml:"contexts"`
+	Clusters       []namedKubeEntry `yaml:"clusters"`
+	Users          []namedKubeEntry `yaml:"users"`
+}
+
+// namedKubeEntry is a named context, cluster, or user of a kubeconfig file
+type namedKubeEntry struct {
+	Name    string                 `yaml:"name"`
+	Context map[string]interface{} `yaml:"context,omitempty"`
+	Cluster map[string]interface{} `yaml:"cluster,omitempty"`
+	User    map[string]interface{} `yaml:"user,omitempty"`
+}
+
+// importKubeconfig imports a context of the kubeconfig files: a kubeconfig with only that
+// context, its cluster and its user, along with the cluster's server and the user's token.
+// Like kubectl, it merges the files in KUBECONFIG, the first file to set a value winning.
+func importKubeconfig(tool string, options Options) (*Credentials, error) {
+	paths := []string{homePath(".kube", "config")}
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		paths = filepath.SplitList(env)
+	}
+
+	var config kubeconfig
+	var found []string
+	for _, path := range paths {
+		contents, err := readSource(path)
+		if err == ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var file kubeconfig
+		if err := yaml.Unmarshal(contents, &file); err != nil {
+			return nil, fmt.Errorf("could not parse %s: %s", path, err)
+		}
+		if config.CurrentContext == "" {
+			config.CurrentContext = file.CurrentContext
+		}
+		config.Contexts = append(config.Contexts, file.Contexts...)
+		config.Clusters = append(config.Clusters, file.Clusters...)
+		config.Users = append(config.Users, file.Users...)
+		found = append(found, path)
+	}
+	if len(found) == 0 {
+		return nil, ErrNotFound
+	}
+	path := strings.Join(found, string(filepath.ListSeparator))
+
+	contextName := options.KubeContext
+	if contextName == "" {
+		contextName = config.CurrentContext
+	}
+	context := findKubeEntry(config.Contexts, contextName)
+	if context == nil {
+		return nil, fmt.Errorf("%s has no context %q", path, contextName)
+	}
+
+	clusterName := fmt.Sprintf("%v", context.Context["cluster"])
+	userName := fmt.Sprintf("%v", context.Context["user"])
+	cluster := findKubeEntry(config.Clusters, clusterName)
+	user := findKubeEntry(config.Users, userName)
+	if cluster == nil || user == nil {
+		return nil, fmt.Errorf("%s: context %q refers to a missing cluster or user", path, contextName)
+	}
+	if field := localKubeField(cluster.Cluster, user.User); field != "" {
+		return nil, fmt.Errorf("%s: context %q uses %s, which refers to this machine and can't be imported; "+
+			"use inline data (e.g. kubectl config view --minify --flatten) or a token instead", path, contextName, field)
+	}
+
+	// a self-contained kubeconfig with only the selected context
+	minimal := yaml.MapSlice{
+		{Key: "apiVersion", Value: "v1"},
+		{Key: "kind", Value: "Config"},
+		{Key: "current-context", Value: contextName},
+		{Key: "contexts", Value: []namedKubeEntry{*context}},
+		{Key: "clusters", Value: []namedKubeEntry{*cluster}},
+		{Key: "users", Value: []namedKubeEntry{*user}},
+	}
+	text, err := yaml.Marshal(minimal)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := &Credentials{
+		Source: fmt.Sprintf("kubeconfig context %s (%s)", contextName, path),
+		Name:   contextName,
+		Values: []Value{
+			{Key: "kubeconfig", Value: string(text), Secret: true},
+			{Key: "context", Value: contextName},
+		},
+	}
+	if server, ok := cluster.Cluster["server"].(string); ok {
+		credentials.Values = append(credentials.Values, Value{Key: "server", Value: server})
+	}
+	if ca, ok := cluster.Cluster["certificate-authority-data"].(string); ok {
+		credentials.Values = append(credentials.Values, Value{Key: "certificate", Value: ca})
+	}
+	if token, ok := user.User["token"].(string); ok {
+		credentials.Values = append(credentials.Values, Value{Key: "token", Value: token, Secret: true})
+	}
+
+	return credentials, nil
+}
+
+// localKubeFields are the cluster and user fields that refer to files or programs on this
+// machine, which wouldn't exist where the credentials are used
+var localKubeFields = []string{"certificate-authority", "client-certificate", "client-key", "tokenFile", "exec", "auth-provider"}
+
+// localKubeField returns the first of localKubeFields that the cluster or user sets, or ""
+func localKubeField(cluster, user map[string]interface{}) string {
+	for _, field := range localKubeFields {
+		if _, ok := cluster[field]; ok {
+			return field
+		}
+		if _, ok := user[field]; ok {
+			return field
+		}
+	}
+
+	return ""
+}
+
+// findKubeEntry returns the entry with the name, or nil if there isn't one
+func findKubeEntry(entries []namedKubeEntry, name string) *namedKubeEntry {
+	for i := range entries {
+		if entries[i].Name == name {
+			return &entries[i]
+		}
+	}
+
+	return nil
+}
+
+// importGCP imports the service-account key file that GOOGLE_APPLICATION_CREDENTIALS points to
+func importGCP(tool string, options Options) (*Credentials, error) {
+	path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	contents, err := readSource(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var key struct {
+		Type        string `json:"type"`
+		ProjectID   string `json:"project_id"`
+		ClientEmail string `json:"client_email"`
+	}
+	if err := json.Unmarshal(contents, &key); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %s", path, err)
+	}
+	if key.Type != "service_account" {
+		return nil, fmt.Errorf("%s is not a service-account key file (type %q)", path, key.Type)
+	}
+
+	return &Credentials{
+		Source: fmt.Sprintf("service-account key %s", path),
+		Name:   key.ProjectID,
+		Values: []Value{
+			{Key: "key", Value: string(contents), Secret: true},
+			{Key: "projectId", Value: key.ProjectID},
+			{Key: "clientEmail", Value: key.ClientEmail},
+		},
+	}, nil
+}
+
+// importAWS imports a profile of the AWS shared-credentials file, along with the profile's
+// region from the AWS config file
+func importAWS(tool string, options Options) (*Credentials, error) {
+	profile := options.AWSProfile
+	if profile == "" {
+		profile = os.Getenv("AWS_PROFILE")
+	}
+	if profile == "" {
+		profile = "default"
+	}
+
+	path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if path == "" {
+		path = homePath(".aws", "credentials")
+	}
+	contents, err := readSource(path)
+	if err != nil {
+		return nil, err
+	}
+
+	section, found := parseINI(contents)[profile]
+	if !found {
+		return nil, fmt.Errorf("%s has no profile %q", path, profile)
+	}
+	if section["aws_access_key_id"] == "" || section["aws_secret_access_key"] == "" {
+		return nil, fmt.Errorf("profile %q of %s has no access key", profile, path)
+	}
+
+	credentials := &Credentials{
+		Source: fmt.Sprintf("AWS profile %s (%s)", profile, path),
+		Name:   profile,
+		Values: []Value{
+			{Key: "accessKeyId", Value: section["aws_access_key_id"]},
+			{Key: "secretAccessKey", Value: section["aws_secret_access_key"], Secret: true},
+		},
+	}
+	if token := section["aws_session_token"]; token != "" {
+		credentials.Values = append(credentials.Values, Value{Key: "sessionToken", Value: token, Secret: true})
+	}
+
+	// the region is in the config file, where profiles other than the default are prefixed
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		configPath := os.Getenv("AWS_CONFIG_FILE")
+		if configPath == "" {
+			configPath = homePath(".aws", "config")
+		}
+		if config, err := readSource(configPath); err == nil {
+			sections := parseINI(config)
+			region = sections["profile "+profile]["region"]
+			if region == "" {
+				region = sections[profile]["region"]
+			}
+		}
+	}
+	if region != "" {
+		credentials.Values = append(credentials.Values, Value{Key: "region", Value: region})
+	}
+
+	return credentials, nil
+}
+
+// parseINI parses the sections of an INI file into maps of keys and values
+func parseINI(contents []byte) map[string]map[string]string {
+	sections := make(map[string]map[string]string)
+	var current map[string]string
+
+	scanner := bufio.NewScanner(strings.NewReader(string(contents)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = make(map[string]string)
+			sections[strings.TrimSpace(line[1:len(line)-1])] = current
+			continue
+		}
+		if parts := strings.SplitN(line, "=", 2); len(parts) == 2 && current != nil {
+			current[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+
+	return sections
+}
+
+// importGitHub imports the token in the GITHUB_TOKEN environment variable
+func importGitHub(tool string, options Options) (*Credentials, error) {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		return nil, ErrNotFound
+	}
+
+	return &Credentials{
+		Source: "$GITHUB_TOKEN",
+		Name:   "default",
+		Values: []Value{{Key: "token", Value: token, Secret: true}},
+	}, nil
+}
+
+// machines are the netrc machine names looked up for well-known tools, after the tool name itself
+var machines = map[string][]string{
+	"github":    {"api.github.com", "github.com"},
+	"gitlab":    {"gitlab.com"},
+	"bitbucket": {"api.bitbucket.org", "bitbucket.org"},
+	"docker":    {"index.docker.io", "registry-1.docker.io", "docker.io"},
+	"heroku":    {"api.heroku.com"},
+}
+
+// importNetrc imports the login and password of a machine entry of the netrc file.  The
+// machine is the one in the options, or else the first of the tool name and the tool's
+// well-known hosts that has an entry.
+func importNetrc(tool string, options Options) (*Credentials, error) {
+	path := os.Getenv("NETRC")
+	if path == "" {
+		path = homePath(".netrc")
+	}
+	contents, err := readSource(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := parseNetrc(contents)
+	candidates := append([]string{tool}, machines[strings.ToLower(tool)]...)
+	if options.NetrcMachine != "" {
+		candidates = []string{options.NetrcMachine}
+	}
+
+	for _, machine := range candidates {
+		entry, found := entries[machine]
+		if !found {
+			continue
+		}
+
+		credentials := &Credentials{
+			Source: fmt.Sprintf("netrc machine %s (%s)", machine, path),
+			Name:   "default",
+			Values: []Value{
+				{Key: "username", Value: entry["login"]},
+				{Key: "password", Value: entry["password"], Secret: true},
+				{Key: "token", Value: entry["password"], Secret: true},
+				{Key: "server", Value: machine},
+			},
+		}
+		return credentials, nil
+	}
+
+	if options.NetrcMachine != "" {
+		return nil, fmt.Errorf("%s has no machine %s", path, options.NetrcMachine)
+	}
+	return nil, ErrNotFound
+}
+
+// parseNetrc parses the machine entries of a netrc file into maps of their tokens
+func parseNetrc(contents []byte) map[string]map[string]string {
+	entries := make(map[string]map[string]string)
+	var current map[string]string
+
+	fields := strings.Fields(string(contents))
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "machine":
+			if i+1 < len(fields) {
+				i++
+				current = make(map[string]string)
+				entries[fields[i]] = current
+			}
+		case "default":
+			current = make(map[string]string)
+			entries["default"] = current
+		case "macdef":
+			// macros run until a blank line, which Fields can't see; stop at the macro
+			return entries
+		case "login", "password", "account":
+			if i+1 < len(fields) && current != nil {
+				current[fields[i]] = fields[i+1]
+				i++
+			}
+		}
+	}
+
+	return entries
+}
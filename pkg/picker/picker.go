@@ -0,0 +1,333 @@
+package picker
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// Item is a candidate in a selection list
+type Item struct {
+	Value       string
+	Description string
+}
+
+// ErrCancelled is returned when the user cancels the selection with escape or ctrl-c
+var ErrCancelled = errors.New("selection cancelled")
+
+// maximum number of candidates shown at once
+const maxVisible = 10
+
+// IsTerminal returns whether stdin and stderr are both terminals, which is required
+// to read keystrokes and draw the selection list
+func IsTerminal() bool {
+	return terminal.IsTerminal(int(os.Stdin.Fd())) && terminal.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// Pick shows the items in a selection list on stderr, filtering them with a fuzzy match
+// as the user types.  If multi is true, tab toggles the highlighted item and enter returns
+// all the toggled items (or the highlighted one if none were toggled).  Otherwise, enter
+// returns the highlighted item.
+func Pick(title string, items []Item, multi bool) ([]string, error) {
+	if len(items) == 0 {
+		return nil, errors.New("nothing to select from")
+	}
+
+	fd := int(os.Stdin.Fd())
+	state, err := terminal.MakeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+	defer terminal.Restore(fd, state)
+
+	width, _, err := terminal.GetSize(int(os.Stderr.Fd()))
+	if err != nil || width <= 0 {
+		width = 80
+	}
+
+	p := &picker{
+		title:    title,
+		items:    items,
+		multi:    multi,
+		selected: make(map[string]bool),
+		width:    width,
+		out:      os.Stderr,
+	}
+	p.filter()
+	p.render()
+	defer p.clear()
+
+	buf := make([]byte, 32)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		done, err := p.handleKeys(buf[:n])
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			return p.result(), nil
+		}
+		p.render()
+	}
+}
+
+// picker holds the state of the selection list
+type picker struct {
+	title    string
+	items    []Item
+	multi    bool
+	query    []rune
+	matches  []Item
+	cursor   int
+	offset   int
+	selected map[string]bool
+	width    int
+	lines    int
+	out      io.Writer
+}
+
+// handleKeys processes a chunk of input, returning true when the selection is complete
+func (p *picker) handleKeys(keys []byte) (bool, error) {
+	for len(keys) > 0 {
+		switch {
+		case keys[0] == 3: // ctrl-c
+			return false, ErrCancelled
+		case keys[0] == 27 && len(keys) >= 3 && (keys[1] == '[' || keys[1] == 'O'):
+			// arrow keys
+			switch keys[2] {
+			case 'A':
+				p.move(-1)
+			case 'B':
+				p.move(1)
+			}
+			keys = keys[3:]
+			continue
+		case keys[0] == 27: // escape
+			return false, ErrCancelled
+		case keys[0] == '\r' || keys[0] == '\n':
+			return len(p.matches) > 0 || len(p.selected) > 0, nil
+		case keys[0] == '\t':
+			if p.multi && len(p.matches) > 0 {
+				value := p.matches[p.cursor].Value
+				if p.selected[value] {
+					delete(p.selected, value)
+				} else {
+					p.selected[value] = true
+				}
+				p.move(1)
+			}
+		case keys[0] == 16: // ctrl-p
+			p.move(-1)
+		case keys[0] == 14: // ctrl-n
+			p.move(1)
+		case keys[0] == 127 || keys[0] == 8: // backspace
+			if len(p.query) > 0 {
+				p.query = p.query[:len(p.query)-1]
+				p.filter()
+			}
+		case keys[0] == 21: // ctrl-u
+			p.query = nil
+			p.filter()
+		default:
+			r, size := utf8.DecodeRune(keys)
+			if unicode.IsPrint(r) {
+				p.query = append(p.query, r)
+				p.filter()
+			}
+			keys = keys[size:]
+			continue
+		}
+		keys = keys[1:]
+	}
+
+	return false, nil
+}
+
+// move moves the cursor by delta, scrolling the visible window as needed
+func (p *picker) move(delta int) {
+	if len(p.matches) == 0 {
+		return
+	}
+	p.cursor = (p.cursor + delta + len(p.matches)) % len(p.matches)
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+maxVisible {
+		p.offset = p.cursor - maxVisible + 1
+	}
+}
+
+// filter recomputes the matches for the current query, best matches first
+func (p *picker) filter() {
+	query := strings.ToLower(string(p.query))
+
+	type match struct {
+		item  Item
+		score int
+	}
+	var matches []match
+	for _, item := range p.items {
+		score, ok := fuzzyScore(strings.ToLower(item.Value+" "+item.Description), query)
+		if ok {
+			matches = append(matches, match{item, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	p.matches = make([]Item, len(matches))
+	for i, m := range matches {
+		p.matches[i] = m.item
+	}
+	p.cursor = 0
+	p.offset = 0
+}
+
+// fuzzyScore returns whether the characters of the query appear in order in the text,
+// and a score that rewards consecutive characters and matches near the start
+func fuzzyScore(text string, query string) (int, bool) {
+	if query == "" {
+		return 0, true
+	}
+
+	score := 0
+	previous := -2
+	position := 0
+	runes := []rune(text)
+	for _, q := range query {
+		found := false
+		for position < len(runes) {
+			if runes[position] == q {
+				found = true
+				break
+			}
+			position++
+		}
+		if !found {
+			return 0, false
+		}
+
+		score++
+		if position == previous+1 {
+			score += 5
+		}
+		if position == 0 || runes[position-1] == ' ' || runes[position-1] == '/' || runes[position-1] == '-' {
+			score += 3
+		}
+		previous = position
+		position++
+	}
+
+	return score, true
+}
+
+// result returns the selected values, in the order of the original items
+func (p *picker) result() []string {
+	var values []string
+	if len(p.selected) > 0 {
+		for _, item := range p.items {
+			if p.selected[item.Value] {
+				values = append(values, item.Value)
+			}
+		}
+		return values
+	}
+
+	return []string{p.matches[p.cursor].Value}
+}
+
+// render redraws the selection list in place
+func (p *picker) render() {
+	p.clear()
+
+	var lines []string
+	help := "↑/↓ to move, enter to select, esc to cancel"
+	if p.multi {
+		help = "↑/↓ to move, tab to toggle, enter to confirm, esc to cancel"
+	}
+	lines = append(lines, fmt.Sprintf("\x1b[1m? %s\x1b[0m \x1b[2m(%s)\x1b[0m", p.title, help))
+	lines = append(lines, fmt.Sprintf("> %s\x1b[2m  %d/%d\x1b[0m", string(p.query), len(p.matches), len(p.items)))
+
+	end := p.offset + maxVisible
+	if end > len(p.matches) {
+		end = len(p.matches)
+	}
+	for i := p.offset; i < end; i++ {
+		item := p.matches[i]
+		text := item.Value
+		if item.Description != "" {
+			text = fmt.Sprintf("%s  \x1b[2m%s\x1b[0m", text, item.Description)
+		}
+		if p.multi {
+			check := "[ ]"
+			if p.selected[item.Value] {
+				check = "[x]"
+			}
+			text = fmt.Sprintf("%s %s", check, text)
+		}
+		if i == p.cursor {
+			lines = append(lines, fmt.Sprintf("\x1b[36m❯\x1b[0m %s", text))
+		} else {
+			lines = append(lines, fmt.Sprintf("  %s", text))
+		}
+	}
+
+	for i, line := range lines {
+		line = truncate(line, p.width-1)
+		if i < len(lines)-1 {
+			line += "\r\n"
+		}
+		fmt.Fprint(p.out, line)
+	}
+
+	// park the cursor at the end of the query line, which is where clear expects it
+	if len(lines) > 2 {
+		fmt.Fprintf(p.out, "\x1b[%dA", len(lines)-2)
+	}
+	fmt.Fprintf(p.out, "\r\x1b[%dC", 2+len(p.query))
+	p.lines = 2
+}
+
+// clear erases the selection list, leaving the cursor where the list started
+func (p *picker) clear() {
+	if p.lines > 1 {
+		fmt.Fprintf(p.out, "\x1b[%dA", p.lines-1)
+	}
+	fmt.Fprint(p.out, "\r\x1b[J")
+	p.lines = 0
+}
+
+// truncate shortens a line to width visible characters, skipping over escape sequences
+func truncate(line string, width int) string {
+	visible := 0
+	escape := false
+	for i, r := range line {
+		switch {
+		case r == '\x1b':
+			escape = true
+		case escape:
+			if r == 'm' {
+				escape = false
+			}
+		default:
+			visible++
+			if visible > width {
+				return line[:i] + "\x1b[0m"
+			}
+		}
+	}
+
+	return line
+}
@@ -0,0 +1,224 @@
+package picker
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testItems = []Item{
+	{Value: "snaptest/build", Description: "build an image"},
+	{Value: "snaptest/gke-deploy", Description: "deploy to gke"},
+	{Value: "other/deploy-gke", Description: "deploy to gke"},
+	{Value: "snaptest/notify", Description: "post to slack"},
+}
+
+// newTestPicker returns a picker of the items, filtered with the empty query
+func newTestPicker(items []Item, multi bool) *picker {
+	p := &picker{title: "Select a snap", items: items, multi: multi, selected: make(map[string]bool), width: 80, out: ioutil.Discard}
+	p.filter()
+	return p
+}
+
+// values returns the values of the items
+func values(items []Item) []string {
+	var values []string
+	for _, item := range items {
+		values = append(values, item.Value)
+	}
+	return values
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// the empty query keeps all the items in their order
+		{"", []string{"snaptest/build", "snaptest/gke-deploy", "other/deploy-gke", "snaptest/notify"}},
+		// matches with the same score keep the order of the items
+		{"deploy", []string{"snaptest/gke-deploy", "other/deploy-gke"}},
+		// the description is matched too, and matching is case-insensitive
+		{"SLACK", []string{"snaptest/notify"}},
+		// the characters must appear in order, but not consecutively
+		{"sb", []string{"snaptest/build"}},
+		{"zzz", nil},
+	}
+	for _, test := range tests {
+		p := newTestPicker(testItems, false)
+		p.query = []rune(test.query)
+		p.filter()
+		if got := values(p.matches); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("matches for %q are %v, want %v", test.query, got, test.want)
+		}
+	}
+}
+
+func TestFilterRanking(t *testing.T) {
+	items := []Item{{Value: "xbxuxixlxd"}, {Value: "rebuild"}, {Value: "build"}, {Value: "deploy"}}
+	p := newTestPicker(items, false)
+	p.query = []rune("build")
+	p.filter()
+
+	// consecutive characters rank above scattered ones, and a word start above the middle of a word
+	if got, want := values(p.matches), []string{"build", "rebuild", "xbxuxixlxd"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("matches are %v, want %v", got, want)
+	}
+	if p.cursor != 0 || p.offset != 0 {
+		t.Errorf("cursor %d, offset %d after filtering", p.cursor, p.offset)
+	}
+}
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		text  string
+		query string
+		score int
+		ok    bool
+	}{
+		{"deploy", "", 0, true},
+		{"deploy", "dep", 4 + 6 + 6, true},
+		{"gke-deploy", "dep", 4 + 6 + 6, true},
+		{"pushed", "dep", 0, false},
+		{"do it please", "dip", 4 + 4 + 4, true},
+		// the earliest occurrence of each character is taken
+		{"snaptest/notify", "no", 1 + 1, true},
+	}
+	for _, test := range tests {
+		score, ok := fuzzyScore(test.text, test.query)
+		if score != test.score || ok != test.ok {
+			t.Errorf("fuzzyScore(%q, %q) = %d, %v; want %d, %v", test.text, test.query, score, ok, test.score, test.ok)
+		}
+	}
+}
+
+func TestHandleKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		multi  bool
+		keys   []string
+		done   bool
+		err    error
+		query  string
+		result []string
+	}{
+		{name: "enter", keys: []string{"\r"}, done: true, result: []string{"snaptest/build"}},
+		{name: "newline", keys: []string{"\n"}, done: true, result: []string{"snaptest/build"}},
+		{name: "down arrow", keys: []string{"\x1b[B", "\x1b[B", "\r"}, done: true, result: []string{"other/deploy-gke"}},
+		{name: "application mode arrows", keys: []string{"\x1bOB", "\r"}, done: true, result: []string{"snaptest/gke-deploy"}},
+		{name: "up arrow wraps around", keys: []string{"\x1b[A", "\r"}, done: true, result: []string{"snaptest/notify"}},
+		{name: "ctrl-n and ctrl-p", keys: []string{"\x0e\x0e\x10", "\r"}, done: true, result: []string{"snaptest/gke-deploy"}},
+		{name: "typing filters", keys: []string{"not", "\r"}, done: true, query: "not", result: []string{"snaptest/notify"}},
+		{name: "keys in one chunk", keys: []string{"gke\x1b[B\r"}, done: true, query: "gke", result: []string{"other/deploy-gke"}},
+		{name: "backspace", keys: []string{"notx", "\x7f", "\r"}, done: true, query: "not", result: []string{"snaptest/notify"}},
+		{name: "ctrl-h", keys: []string{"notx", "\x08", "\r"}, done: true, query: "not", result: []string{"snaptest/notify"}},
+		{name: "backspace on an empty query", keys: []string{"\x7f"}, query: ""},
+		{name: "ctrl-u clears the query", keys: []string{"zzz", "\x15", "\r"}, done: true, result: []string{"snaptest/build"}},
+		{name: "enter without matches", keys: []string{"zzz", "\r"}, query: "zzz"},
+		{name: "escape", keys: []string{"\x1b"}, err: ErrCancelled},
+		{name: "ctrl-c", keys: []string{"de", "\x03"}, err: ErrCancelled, query: "de"},
+		{name: "unicode", keys: []string{"ключ"}, query: "ключ"},
+		{name: "tab toggles in multi mode", multi: true, keys: []string{"\t\t", "\r"}, done: true, result: []string{"snaptest/build", "snaptest/gke-deploy"}},
+		// tab moves down after toggling, so without toggled items the highlighted one is returned
+		{name: "tab twice untoggles", multi: true, keys: []string{"\t\x1b[A\t", "\r"}, done: true, result: []string{"snaptest/gke-deploy"}},
+		{name: "toggled items survive filtering", multi: true, keys: []string{"\t", "not", "\r"}, done: true, query: "not", result: []string{"snaptest/build"}},
+		{name: "enter with toggled items but no matches", multi: true, keys: []string{"\t", "zzz", "\r"}, done: true, query: "zzz", result: []string{"snaptest/build"}},
+		{name: "tab in single mode", keys: []string{"\t", "\r"}, done: true, result: []string{"snaptest/build"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := newTestPicker(testItems, test.multi)
+			var done bool
+			var err error
+			for _, keys := range test.keys {
+				if done, err = p.handleKeys([]byte(keys)); done || err != nil {
+					break
+				}
+			}
+			if done != test.done || err != test.err {
+				t.Fatalf("done %v, error %v; want %v, %v", done, err, test.done, test.err)
+			}
+			if string(p.query) != test.query {
+				t.Errorf("query %q, want %q", string(p.query), test.query)
+			}
+			if done {
+				if result := p.result(); !reflect.DeepEqual(result, test.result) {
+					t.Errorf("result %v, want %v", result, test.result)
+				}
+			}
+		})
+	}
+}
+
+func TestResult(t *testing.T) {
+	// toggled items are returned in the order of the items, not the order they were toggled
+	p := newTestPicker(testItems, true)
+	p.selected["snaptest/notify"] = true
+	p.selected["snaptest/build"] = true
+	if result := p.result(); !reflect.DeepEqual(result, []string{"snaptest/build", "snaptest/notify"}) {
+		t.Errorf("result %v", result)
+	}
+
+	// without toggled items, the highlighted match is returned
+	p = newTestPicker(testItems, false)
+	p.query = []rune("deploy")
+	p.filter()
+	p.move(1)
+	if result := p.result(); !reflect.DeepEqual(result, []string{"other/deploy-gke"}) {
+		t.Errorf("result %v", result)
+	}
+}
+
+func TestMoveScrolls(t *testing.T) {
+	var items []Item
+	for _, c := range "abcdefghijklmno" {
+		items = append(items, Item{Value: string(c)})
+	}
+	p := newTestPicker(items, false)
+
+	for i := 0; i < maxVisible; i++ {
+		p.move(1)
+	}
+	if p.cursor != maxVisible || p.offset != 1 {
+		t.Errorf("cursor %d, offset %d after moving past the window", p.cursor, p.offset)
+	}
+	p.move(-maxVisible)
+	if p.cursor != 0 || p.offset != 0 {
+		t.Errorf("cursor %d, offset %d after moving back", p.cursor, p.offset)
+	}
+}
+
+func TestRender(t *testing.T) {
+	var out bytes.Buffer
+	p := newTestPicker(testItems, true)
+	p.out = &out
+	p.selected["snaptest/gke-deploy"] = true
+	p.handleKeys([]byte("snap"))
+	p.render()
+
+	for _, want := range []string{"Select a snap", "tab to toggle", "> snap", "3/4", "❯\x1b[0m [ ] snaptest/build", "[x] snaptest/gke-deploy"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("rendered %q, want it to contain %q", out.String(), want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		line  string
+		width int
+		want  string
+	}{
+		{"short", 10, "short"},
+		{"a longer line", 8, "a longer\x1b[0m"},
+		// escape sequences don't count towards the width
+		{"\x1b[36m❯\x1b[0m item", 4, "\x1b[36m❯\x1b[0m it\x1b[0m"},
+	}
+	for _, test := range tests {
+		if got := truncate(test.line, test.width); got != test.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", test.line, test.width, got, test.want)
+		}
+	}
+}
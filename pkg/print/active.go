@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"time"
 
 	"github.com/jedib0t/go-pretty/table"
 	"github.com/jedib0t/go-pretty/text"
@@ -20,6 +19,7 @@ type ActiveSnap struct {
 	Activated        int64  `json:"activated"`
 	ExecutionCounter int    `json:"executionCounter"`
 	ErrorCounter     int    `json:"errorCounter"`
+	SnapRevision     int    `json:"snapRevision,omitempty"`
 }
 
 // ActiveSnapsResponse defines the fields to unmarshal from getting all active snaps
@@ -63,7 +63,7 @@ func ActiveSnapTable(response []byte) {
 	t.AppendHeader(table.Row{"Field", "Value"})
 	for field, value := range entity {
 		if field == "activated" {
-			value = time.Unix(int64(value.(float64))/1000, 0)
+			value = FormatTime(int64(value.(float64)))
 		}
 		t.AppendRow(table.Row{field, value})
 	}
@@ -102,7 +102,7 @@ func ActiveSnapStatusTable(response []byte) {
 	t.AppendHeader(table.Row{"Field", "Value"})
 	for field, value := range entity {
 		if field == "activated" {
-			value = time.Unix(int64(value.(float64))/1000, 0)
+			value = FormatTime(int64(value.(float64)))
 		}
 		t.AppendRow(table.Row{field, value})
 	}
@@ -127,10 +127,10 @@ func ActiveSnapsTable(response []byte) {
 	t := table.NewWriter()
 	t.SetOutputMirror(os.Stdout)
 	t.SetTitle("Active Snaps")
-	t.AppendHeader(table.Row{"Active Snap ID", "Snap ID", "State", "Activated", "Trigger", "Executions", "Errors"})
+	t.AppendHeader(table.Row{"Active Snap ID", "Snap ID", "Revision", "State", "Activated", "Trigger", "Executions", "Errors"})
 	for _, a := range activeSnaps {
-		activated := time.Unix(a.Activated/1000, 0)
-		t.AppendRow(table.Row{a.ActiveSnapID, a.SnapID, a.State, activated, a.Provider, a.ExecutionCounter, a.ErrorCounter})
+		activated := FormatTime(a.Activated)
+		t.AppendRow(table.Row{a.ActiveSnapID, a.SnapID, FormatRevision(a.SnapRevision), a.State, activated, a.Provider, a.ExecutionCounter, a.ErrorCounter})
 	}
 	t.SetStyle(tableStyle)
 	t.Style().Title.Align = text.AlignCenter
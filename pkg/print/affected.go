@@ -0,0 +1,49 @@
+package print
+
+import (
+	"os"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/jedib0t/go-pretty/text"
+)
+
+// AffectedActiveSnapsTable prints out the active snaps affected by an operation as a table,
+// with the number of logs of each active snap if logCounts isn't nil
+func AffectedActiveSnapsTable(title string, activeSnaps []ActiveSnap, logCounts map[string]int) {
+	header := table.Row{"Active Snap ID", "Snap ID", "State", "Trigger", "Executions"}
+	if logCounts != nil {
+		header = append(header, "Logs")
+	}
+
+	// write out the table
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetTitle(title)
+	t.AppendHeader(header)
+	for _, a := range activeSnaps {
+		row := table.Row{a.ActiveSnapID, a.SnapID, a.State, a.Provider, a.ExecutionCounter}
+		if logCounts != nil {
+			row = append(row, logCounts[a.ActiveSnapID])
+		}
+		t.AppendRow(row)
+	}
+	t.SetStyle(tableStyle)
+	t.Style().Title.Align = text.AlignCenter
+	t.Render()
+}
+
+// AffectedSnapsTable prints out the snaps affected by an operation as a table, with the
+// number of activations of each snap
+func AffectedSnapsTable(title string, snaps []Snap, activeCounts map[string]int) {
+	// write out the table
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetTitle(title)
+	t.AppendHeader(table.Row{"Snap ID", "Description", "Trigger", "Active Snaps"})
+	for _, snap := range snaps {
+		t.AppendRow(table.Row{snap.SnapID, snap.Description, snap.Provider, activeCounts[snap.SnapID]})
+	}
+	t.SetStyle(tableStyle)
+	t.Style().Title.Align = text.AlignCenter
+	t.Render()
+}
@@ -0,0 +1,40 @@
+package print
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/jedib0t/go-pretty/text"
+)
+
+// BulkResult defines the outcome of an operation on one of the items of a bulk command,
+// using the same states as StepResult
+type BulkResult struct {
+	ID     string `json:"id"`
+	State  string `json:"state"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// BulkResultsTable prints out the outcome of the operation on each item as a table
+func BulkResultsTable(title string, idHeader string, results []BulkResult) {
+	done := 0
+	for _, result := range results {
+		if result.State == StepDone {
+			done++
+		}
+	}
+
+	// write out the table
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetTitle(title)
+	t.AppendHeader(table.Row{idHeader, "State", "Detail"})
+	for _, result := range results {
+		t.AppendRow(table.Row{result.ID, result.State, result.Detail})
+	}
+	t.AppendFooter(table.Row{"", "", fmt.Sprintf("%d of %d done", done, len(results))})
+	t.SetStyle(tableStyle)
+	t.Style().Title.Align = text.AlignCenter
+	t.Render()
+}
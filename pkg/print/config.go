@@ -14,6 +14,7 @@ func Config() {
 		"API URL":     viper.GetString("APIURL"),
 		"Client ID":   viper.GetString("ClientID"),
 		"Auth Domain": viper.GetString("AuthDomain"),
+		"Time Zone":   TimeZone().String(),
 	}
 
 	// write out the table of properties
@@ -28,3 +29,34 @@ func Config() {
 	t.Style().Title.Align = text.AlignCenter
 	t.Render()
 }
+
+// Context defines a saved context, for listing
+type Context struct {
+	Name     string
+	APIURL   string
+	User     string
+	LoggedIn bool
+	Current  bool
+}
+
+// ContextsTable prints out the saved contexts as a table, marking the current one
+func ContextsTable(contexts []Context) {
+	t := table.NewWriter()
+	t.SetTitle("Contexts")
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Context", "API URL", "User", "Current"})
+	for _, c := range contexts {
+		user := c.User
+		if !c.LoggedIn {
+			user = "(logged out)"
+		}
+		current := ""
+		if c.Current {
+			current = "*"
+		}
+		t.AppendRow(table.Row{c.Name, c.APIURL, user, current})
+	}
+	t.SetStyle(tableStyle)
+	t.Style().Title.Align = text.AlignCenter
+	t.Render()
+}
@@ -68,3 +68,55 @@ func CredentialsTable(response []byte, connection string) {
 	t.Style().Title.Align = text.AlignCenter
 	t.Render()
 }
+
+// ConnectionTest defines the outcome of testing a credential set of a tool, using the same
+// states as StepResult
+type ConnectionTest struct {
+	Tool          string `json:"tool"`
+	CredentialSet string `json:"credentialSet,omitempty"`
+	State         string `json:"state"`
+	LatencyMs     int64  `json:"latencyMs"`
+	Message       string `json:"message,omitempty"`
+}
+
+// ConnectionTestsTable prints out the outcome of connection tests as a table
+func ConnectionTestsTable(title string, results []ConnectionTest) {
+	// write out the table
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetTitle(title)
+	t.AppendHeader(table.Row{"Credential Set", "State", "Latency", "Message"})
+	for _, result := range results {
+		latency := "-"
+		if result.State != StepDryRun {
+			latency = fmt.Sprintf("%dms", result.LatencyMs)
+		}
+		t.AppendRow(table.Row{result.CredentialSet, result.State, latency, result.Message})
+	}
+	t.SetStyle(tableStyle)
+	t.Style().Title.Align = text.AlignCenter
+	t.Render()
+}
+
+// CredentialValue defines a credential value to preview before it is saved, with its value
+// already redacted if it is a secret
+type CredentialValue struct {
+	Name   string
+	Value  string
+	Source string
+}
+
+// CredentialPreviewTable prints out the credential values that will be saved as a table
+func CredentialPreviewTable(title string, values []CredentialValue) {
+	// write out the table
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetTitle(title)
+	t.AppendHeader(table.Row{"Parameter", "Value", "Source"})
+	for _, v := range values {
+		t.AppendRow(table.Row{v.Name, v.Value, v.Source})
+	}
+	t.SetStyle(tableStyle)
+	t.Style().Title.Align = text.AlignCenter
+	t.Render()
+}
@@ -0,0 +1,120 @@
+package print
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/jedib0t/go-pretty/text"
+	"github.com/snapmaster-io/snap/pkg/utils"
+)
+
+// GallerySnap defines the fields to unmarshal for a snap in the gallery
+type GallerySnap struct {
+	SnapID      string `json:"snapId"`
+	Account     string `json:"account"`
+	Description string `json:"description"`
+	Provider    string `json:"provider"`
+	Forks       int    `json:"forks"`
+	Text        string `json:"text"`
+}
+
+// GalleryResponse defines the fields to unmarshal from a gallery list operation
+type GalleryResponse struct {
+	Status  string        `json:"status"`
+	Message string        `json:"message"`
+	Data    []GallerySnap `json:"data"`
+}
+
+// GallerySnapResponse defines the fields to unmarshal from a get snap operation
+type GallerySnapResponse struct {
+	Status  string      `json:"status"`
+	Message string      `json:"message"`
+	Data    GallerySnap `json:"data"`
+}
+
+// Author returns the account that published the snap, which is also the namespace of its snap ID
+func (s GallerySnap) Author() string {
+	if s.Account != "" {
+		return s.Account
+	}
+
+	return strings.SplitN(s.SnapID, "/", 2)[0]
+}
+
+// GallerySearchResult defines a snap that matched a gallery search, and its rank
+type GallerySearchResult struct {
+	Snap  GallerySnap
+	Score int
+}
+
+// RequiredTool defines a tool that a snap uses, how it uses it, and whether the user has connected it
+type RequiredTool struct {
+	Provider  string
+	UsedFor   string
+	Connected bool
+}
+
+// GallerySearchTable prints out the gallery search results as a table, in rank order
+func GallerySearchTable(results []GallerySearchResult, query string) {
+	// write out the table
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	if query != "" {
+		t.SetTitle(fmt.Sprintf("Gallery snaps matching '%s'", query))
+	} else {
+		t.SetTitle("Gallery snaps")
+	}
+	t.AppendHeader(table.Row{"Snap ID", "Description", "Trigger", "Score"})
+	for _, result := range results {
+		t.AppendRow(table.Row{result.Snap.SnapID, result.Snap.Description, result.Snap.Provider, result.Score})
+	}
+	t.SetStyle(tableStyle)
+	t.Style().Title.Align = text.AlignCenter
+	t.Render()
+}
+
+// GallerySnapTable prints out a gallery snap's author, fork count, and required tools as tables
+func GallerySnapTable(response []byte, tools []RequiredTool) {
+	var snapResponse GallerySnapResponse
+	json.Unmarshal(response, &snapResponse)
+
+	if snapResponse.Status == "error" {
+		utils.PrintStatus(snapResponse.Status, snapResponse.Message)
+		return
+	}
+
+	snap := snapResponse.Data
+
+	// write out the general information
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetTitle(fmt.Sprintf("Snap %s", snap.SnapID))
+	t.AppendHeader(table.Row{"Field", "Value"})
+	t.AppendRow(table.Row{"Author", snap.Author()})
+	t.AppendRow(table.Row{"Description", snap.Description})
+	t.AppendRow(table.Row{"Trigger", snap.Provider})
+	t.AppendRow(table.Row{"Forks", snap.Forks})
+	t.SetStyle(tableStyle)
+	t.Style().Title.Align = text.AlignCenter
+	t.Render()
+
+	fmt.Println()
+
+	// write out the required tools and whether they are connected
+	t = table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetTitle("Required tools")
+	t.AppendHeader(table.Row{"Provider", "Used For", "Connected?"})
+	for _, tool := range tools {
+		t.AppendRow(table.Row{tool.Provider, tool.UsedFor, tool.Connected})
+	}
+	t.SetStyle(tableStyle)
+	t.Style().Title.Align = text.AlignCenter
+	t.Render()
+
+	fmt.Println()
+	utils.PrintYAML(snap.Text)
+}
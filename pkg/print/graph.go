@@ -0,0 +1,222 @@
+package print
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/snapmaster-io/snap/pkg/definition"
+)
+
+// GraphStep defines a step of a snap's workflow graph: the trigger or an action, and the
+// snap parameters it consumes
+type GraphStep struct {
+	Name       string   `json:"name"`
+	Provider   string   `json:"provider"`
+	Operation  string   `json:"operation,omitempty"`
+	Parameters []string `json:"parameters"`
+}
+
+// SnapGraph defines the workflow of a snap: its trigger, followed by its actions in order
+type SnapGraph struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Trigger     GraphStep   `json:"trigger"`
+	Actions     []GraphStep `json:"actions"`
+}
+
+// Font Awesome icons of well-known providers, for Mermaid diagrams
+var providerIcons = map[string]string{
+	"aws":        "fa:fa-aws",
+	"azure":      "fa:fa-microsoft",
+	"bitbucket":  "fa:fa-bitbucket",
+	"docker":     "fa:fa-docker",
+	"gcp":        "fa:fa-google",
+	"git":        "fa:fa-git-alt",
+	"github":     "fa:fa-github",
+	"gitlab":     "fa:fa-gitlab",
+	"jenkins":    "fa:fa-jenkins",
+	"kubernetes": "fa:fa-dharmachakra",
+	"pagerduty":  "fa:fa-bell",
+	"slack":      "fa:fa-slack",
+	"twilio":     "fa:fa-sms",
+}
+
+// NewSnapGraph returns the workflow graph of a snap definition
+func NewSnapGraph(snap *definition.Snap) SnapGraph {
+	newStep := func(step definition.Step, operation string) GraphStep {
+		return GraphStep{
+			Name:       step.Name,
+			Provider:   step.Provider,
+			Operation:  operation,
+			Parameters: definition.References(step.Values),
+		}
+	}
+
+	trigger := snap.TriggerStep()
+	graph := SnapGraph{
+		Name:        snap.Name,
+		Description: snap.Description,
+		Trigger:     newStep(trigger, trigger.Event),
+		Actions:     []GraphStep{},
+	}
+	for _, step := range snap.ActionSteps() {
+		graph.Actions = append(graph.Actions, newStep(step, step.Action))
+	}
+
+	return graph
+}
+
+// steps returns the trigger and the actions of the graph, in order
+func (g SnapGraph) steps() []GraphStep {
+	return append([]GraphStep{g.Trigger}, g.Actions...)
+}
+
+// title returns the provider and operation of a step, as "provider: operation"
+func (s GraphStep) title() string {
+	if s.Operation == "" {
+		return s.Provider
+	}
+
+	return fmt.Sprintf("%s: %s", s.Provider, s.Operation)
+}
+
+// consumes describes the snap parameters a step consumes, or returns "" if it consumes none
+func (s GraphStep) consumes() string {
+	if len(s.Parameters) == 0 {
+		return ""
+	}
+
+	references := make([]string, len(s.Parameters))
+	for i, p := range s.Parameters {
+		references[i] = "$" + p
+	}
+	return strings.Join(references, ", ")
+}
+
+// GraphMermaid returns the graph as a Mermaid flowchart
+func GraphMermaid(g SnapGraph) string {
+	escape := func(s string) string {
+		return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(s)
+	}
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, step := range g.steps() {
+		label := step.title()
+		if icon, ok := providerIcons[step.Provider]; ok {
+			label = icon + " " + label
+		}
+		lines := []string{escape(label)}
+		if i > 0 && step.Name != step.Operation {
+			lines = append(lines, fmt.Sprintf("<i>%s</i>", escape(step.Name)))
+		}
+		if consumes := step.consumes(); consumes != "" {
+			lines = append(lines, fmt.Sprintf("<small>%s</small>", escape(consumes)))
+		}
+
+		// the trigger is drawn as a stadium, and the actions as rounded boxes
+		open, close := "(", ")"
+		if i == 0 {
+			open, close = "([", "])"
+		}
+		fmt.Fprintf(&b, "  s%d%s\"%s\"%s\n", i, open, strings.Join(lines, "<br/>"), close)
+	}
+	for i := range g.Actions {
+		fmt.Fprintf(&b, "  s%d --> s%d\n", i, i+1)
+	}
+
+	return b.String()
+}
+
+// GraphDot returns the graph in the DOT language of Graphviz
+func GraphDot(g SnapGraph) string {
+	quote := func(s string) string {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", quote(g.Name))
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=rounded, fontname=Helvetica];\n")
+	for i, step := range g.steps() {
+		lines := []string{step.title()}
+		if i > 0 && step.Name != step.Operation {
+			lines = append(lines, step.Name)
+		}
+		if consumes := step.consumes(); consumes != "" {
+			lines = append(lines, consumes)
+		}
+
+		label := quote(strings.Join(lines, "\n"))
+		label = strings.Replace(label, "\n", `\n`, -1)
+		if i == 0 {
+			fmt.Fprintf(&b, "  s%d [label=%s, shape=oval, style=bold];\n", i, label)
+		} else {
+			fmt.Fprintf(&b, "  s%d [label=%s];\n", i, label)
+		}
+	}
+	for i := range g.Actions {
+		fmt.Fprintf(&b, "  s%d -> s%d;\n", i, i+1)
+	}
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// GraphASCII returns the graph as boxes connected top to bottom, drawn with box-drawing characters
+func GraphASCII(g SnapGraph) string {
+	// the lines of each box, which are all as wide as the widest line
+	var boxes [][]string
+	width := 0
+	for i, step := range g.steps() {
+		lines := []string{step.title()}
+		if i == 0 {
+			lines[0] = "trigger " + lines[0]
+		} else if step.Name != step.Operation {
+			lines = append(lines, "step "+step.Name)
+		}
+		if consumes := step.consumes(); consumes != "" {
+			lines = append(lines, "uses "+consumes)
+		}
+		for _, line := range lines {
+			if w := utf8.RuneCountInString(line); w > width {
+				width = w
+			}
+		}
+		boxes = append(boxes, lines)
+	}
+
+	var b strings.Builder
+	b.WriteString(g.Name)
+	if g.Description != "" {
+		b.WriteString(" - " + g.Description)
+	}
+	b.WriteString("\n\n")
+
+	// the connector leaves from the middle of the bottom edge
+	middle := (width + 2) / 2
+	for i, lines := range boxes {
+		// the trigger box has rounded corners
+		topLeft, topRight, bottomLeft, bottomRight := "┌", "┐", "└", "┘"
+		if i == 0 {
+			topLeft, topRight, bottomLeft, bottomRight = "╭", "╮", "╰", "╯"
+		}
+
+		fmt.Fprintf(&b, "%s%s%s\n", topLeft, strings.Repeat("─", width+2), topRight)
+		for _, line := range lines {
+			fmt.Fprintf(&b, "│ %s%s │\n", line, strings.Repeat(" ", width-utf8.RuneCountInString(line)))
+		}
+		bottom := strings.Repeat("─", width+2)
+		if i < len(boxes)-1 {
+			bottom = bottom[:middle*len("─")] + "┬" + bottom[(middle+1)*len("─"):]
+		}
+		fmt.Fprintf(&b, "%s%s%s\n", bottomLeft, bottom, bottomRight)
+		if i < len(boxes)-1 {
+			fmt.Fprintf(&b, "%s│\n", strings.Repeat(" ", middle+1))
+			fmt.Fprintf(&b, "%s▼\n", strings.Repeat(" ", middle+1))
+		}
+	}
+
+	return b.String()
+}
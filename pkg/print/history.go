@@ -0,0 +1,54 @@
+package print
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/jedib0t/go-pretty/text"
+	"github.com/snapmaster-io/snap/pkg/history"
+)
+
+// SnapHistoryResponse defines the fields to unmarshal from getting the history of a snap
+type SnapHistoryResponse struct {
+	Status  string             `json:"status"`
+	Message string             `json:"message"`
+	Data    []history.Revision `json:"data"`
+}
+
+// SnapRevisionResponse defines the fields to unmarshal from getting a revision of a snap
+type SnapRevisionResponse struct {
+	Status  string           `json:"status"`
+	Message string           `json:"message"`
+	Data    history.Revision `json:"data"`
+}
+
+// SnapHistoryTable prints out the revisions of a snap as a table, along with the active
+// snaps that run each revision
+func SnapHistoryTable(title string, revisions []history.Revision, activeSnaps map[int][]string) {
+	// write out the table
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetTitle(title)
+	t.AppendHeader(table.Row{"Revision", "Change", "Time", "User", "Active Snaps"})
+	for i, r := range revisions {
+		revision := fmt.Sprintf("%d", r.Revision)
+		if i == len(revisions)-1 {
+			revision += " (current)"
+		}
+		t.AppendRow(table.Row{revision, r.Describe(), FormatTime(r.Timestamp), r.User, strings.Join(activeSnaps[r.Revision], ", ")})
+	}
+	t.SetStyle(tableStyle)
+	t.Style().Title.Align = text.AlignCenter
+	t.Render()
+}
+
+// FormatRevision formats the revision of a snap that an active snap runs, or "-" if it isn't known
+func FormatRevision(revision int) string {
+	if revision == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%d", revision)
+}
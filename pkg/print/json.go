@@ -1,15 +1,77 @@
 package print
 
-import "github.com/snapmaster-io/snap/pkg/utils"
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/snapmaster-io/snap/pkg/utils"
+)
+
+// fields that contain millisecond epoch timestamps
+var epochFields = map[string]bool{
+	"activated": true,
+	"timestamp": true,
+}
+
+// whether to add ISO 8601 fields next to epoch timestamps in JSON output
+var enrichJSON = false
+
+// SetEnrich sets whether JSON output is enriched with ISO 8601 timestamps
+func SetEnrich(enrich bool) {
+	enrichJSON = enrich
+}
 
 // JSON pretty-prints a JSON response
 func JSON(response []byte) {
 	// pretty-print the json
-	utils.PrintJSON(response)
+	utils.PrintJSON(enrich(response))
 }
 
 // JSONString pretty-prints a string that contains JSON
 func JSONString(response string) {
 	// pretty-print the json
-	utils.PrintJSON([]byte(response))
+	utils.PrintJSON(enrich([]byte(response)))
+}
+
+// enrich adds an ISO 8601 "<field>ISO" field next to every epoch timestamp field
+func enrich(response []byte) []byte {
+	if !enrichJSON {
+		return response
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(response))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return response
+	}
+
+	enriched, err := json.Marshal(enrichValue(value))
+	if err != nil {
+		return response
+	}
+
+	return enriched
+}
+
+// enrichValue walks a decoded JSON value and adds ISO 8601 fields to every object
+func enrichValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for field, fieldValue := range v {
+			if number, ok := fieldValue.(json.Number); ok && epochFields[field] {
+				if epoch, err := number.Int64(); err == nil {
+					v[field+"ISO"] = FormatTimeISO(epoch)
+				}
+				continue
+			}
+			v[field] = enrichValue(fieldValue)
+		}
+	case []interface{}:
+		for i, element := range v {
+			v[i] = enrichValue(element)
+		}
+	}
+
+	return value
 }
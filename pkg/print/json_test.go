@@ -0,0 +1,89 @@
+package print
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEnrich(t *testing.T) {
+	tests := []struct {
+		name     string
+		enrich   bool
+		response string
+		want     string
+	}{
+		{
+			name:     "disabled",
+			response: `{"timestamp":1600000000123}`,
+			want:     `{"timestamp":1600000000123}`,
+		},
+		{
+			name:     "top-level fields",
+			enrich:   true,
+			response: `{"timestamp":1600000000123,"activated":0,"name":"x"}`,
+			want:     `{"timestamp":1600000000123,"timestampISO":"2020-09-13T21:26:40.123+09:00","activated":0,"activatedISO":"1970-01-01T09:00:00.000+09:00","name":"x"}`,
+		},
+		{
+			name:     "nested objects and arrays",
+			enrich:   true,
+			response: `{"status":"success","data":[{"activated":1600000000123,"actions":[{"timestamp":1600000000000}]}]}`,
+			want:     `{"status":"success","data":[{"activated":1600000000123,"activatedISO":"2020-09-13T21:26:40.123+09:00","actions":[{"timestamp":1600000000000,"timestampISO":"2020-09-13T21:26:40.000+09:00"}]}]}`,
+		},
+		{
+			// only numbers are timestamps, and other fields are left alone
+			name:     "not timestamps",
+			enrich:   true,
+			response: `{"timestamp":"yesterday","activated":1.5,"created":1600000000123}`,
+			want:     `{"timestamp":"yesterday","activated":1.5,"created":1600000000123}`,
+		},
+		{
+			// large numbers keep their precision
+			name:     "other numbers",
+			enrich:   true,
+			response: `{"id":9007199254740993}`,
+			want:     `{"id":9007199254740993}`,
+		},
+		{
+			name:     "invalid json",
+			enrich:   true,
+			response: `not json`,
+			want:     `not json`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pinTime(t, "Asia/Tokyo", TimeFormatLocal, time.Time{})
+			saved := enrichJSON
+			defer SetEnrich(saved)
+			SetEnrich(test.enrich)
+
+			got := enrich([]byte(test.response))
+			if !json.Valid([]byte(test.want)) {
+				if string(got) != test.want {
+					t.Errorf("enrich = %s, want %s", got, test.want)
+				}
+				return
+			}
+			gotValue, err := decodeNumbers(got)
+			if err != nil {
+				t.Fatalf("enrich returned invalid json %s: %s", got, err)
+			}
+			wantValue, _ := decodeNumbers([]byte(test.want))
+			if !reflect.DeepEqual(gotValue, wantValue) {
+				t.Errorf("enrich = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+// decodeNumbers decodes JSON, keeping numbers as they were written
+func decodeNumbers(text []byte) (interface{}, error) {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(text))
+	decoder.UseNumber()
+	err := decoder.Decode(&value)
+	return value, err
+}
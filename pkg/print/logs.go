@@ -5,8 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
-	"time"
 
 	"github.com/jedib0t/go-pretty/table"
 	"github.com/jedib0t/go-pretty/text"
@@ -15,10 +15,13 @@ import (
 
 // ActiveSnapActionsLog defines the fields to unmarshal for action logs
 type ActiveSnapActionsLog struct {
+	Name     string                     `json:"name,omitempty"`
 	Provider string                     `json:"provider"`
 	Action   string                     `json:"action"`
 	State    string                     `json:"state"`
 	Output   ActiveSnapActionsLogOutput `json:"output"`
+	// Parameters are the resolved values of the step, reported by local runs
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
 }
 
 // ActiveSnapActionsLogOutput defines the fields to unmarshal for action logs
@@ -77,7 +80,7 @@ func ActiveSnapLogsTable(response []byte) {
 	t.SetOutputMirror(os.Stdout)
 	t.AppendHeader(table.Row{"Log ID", "Timestamp", "State"})
 	for _, logEntry := range activeSnapLogs {
-		timestamp := time.Unix(logEntry.LogID/1000, 0)
+		timestamp := FormatTime(logEntry.LogID)
 		t.AppendRow(table.Row{logEntry.LogID, timestamp, logEntry.State})
 	}
 	t.SetStyle(tableStyle)
@@ -144,6 +147,11 @@ func ActiveSnapLogDetails(response []byte, logID string, format string) {
 		t.SetStyle(actionTableStyle)
 		t.Render()
 
+		// print the resolved parameters, if the log has them
+		if len(action.Parameters) > 0 {
+			printParameters(action.Parameters)
+		}
+
 		// print the output of the operation
 		printOutput(action.Output)
 	}
@@ -173,6 +181,31 @@ func extractLogs(response []byte) ([]ActiveSnapLog, error) {
 	return activeSnapLogs, nil
 }
 
+// print the resolved parameters of an action, sorted by name
+func printParameters(parameters map[string]interface{}) {
+	var names []string
+	for name := range parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Parameter", "Value"})
+	for _, name := range names {
+		value := parameters[name]
+		if _, ok := value.(string); !ok {
+			// show lists and maps as JSON
+			if b, err := json.Marshal(value); err == nil {
+				value = string(b)
+			}
+		}
+		t.AppendRow(table.Row{name, value})
+	}
+	t.SetStyle(tableStyle)
+	t.Render()
+}
+
 // print the output for the action
 func printOutput(output ActiveSnapActionsLogOutput) {
 	data := output.Data
@@ -15,6 +15,7 @@ type Snap struct {
 	Description string `json:"description"`
 	Provider    string `json:"provider"`
 	Private     bool   `json:"private"`
+	Revision    int    `json:"revision,omitempty"`
 }
 
 // SnapDefinition defines the text field to unmarshal for a snap's YAML definition
@@ -0,0 +1,40 @@
+package print
+
+import (
+	"os"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/jedib0t/go-pretty/text"
+)
+
+// step states
+const (
+	StepDone       = "done"
+	StepFailed     = "failed"
+	StepSkipped    = "skipped"
+	StepDryRun     = "dry run"
+	StepConflict   = "conflict"
+	StepUnverified = "cannot verify"
+)
+
+// StepResult defines the outcome of one step of a multi-step operation
+type StepResult struct {
+	Step   string
+	State  string
+	Detail string
+}
+
+// StepResultsTable prints out the outcome of each step of a multi-step operation as a table
+func StepResultsTable(title string, results []StepResult) {
+	// write out the table
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetTitle(title)
+	t.AppendHeader(table.Row{"Step", "State", "Detail"})
+	for _, result := range results {
+		t.AppendRow(table.Row{result.Step, result.State, result.Detail})
+	}
+	t.SetStyle(tableStyle)
+	t.Style().Title.Align = text.AlignCenter
+	t.Render()
+}
@@ -0,0 +1,100 @@
+package print
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// supported time formats
+const (
+	TimeFormatLocal    = "local"
+	TimeFormatRFC3339  = "rfc3339"
+	TimeFormatRelative = "relative"
+	TimeFormatUnix     = "unix"
+)
+
+// which time format to use for timestamps in tables
+var timeFormat = TimeFormatLocal
+
+// now returns the reference time of relative timestamps
+var now = time.Now
+
+// SetTimeFormat sets the format used for printing timestamps, and returns an error if the format is unknown
+func SetTimeFormat(format string) error {
+	switch format {
+	case TimeFormatLocal, TimeFormatRFC3339, TimeFormatRelative, TimeFormatUnix:
+		timeFormat = format
+		return nil
+	}
+
+	return fmt.Errorf("unknown time format '%s' (must be one of {rfc3339, relative, unix, local})", format)
+}
+
+// TimeZone returns the location configured in the TimeZone setting, defaulting to the local time zone
+func TimeZone() *time.Location {
+	zone := viper.GetString("TimeZone")
+	if zone == "" {
+		return time.Local
+	}
+
+	location, err := time.LoadLocation(zone)
+	if err != nil {
+		return time.Local
+	}
+
+	return location
+}
+
+// FormatTime formats a millisecond epoch timestamp using the configured time format and time zone
+func FormatTime(epoch int64) string {
+	t := time.Unix(epoch/1000, (epoch%1000)*int64(time.Millisecond)).In(TimeZone())
+
+	switch timeFormat {
+	case TimeFormatRFC3339:
+		return t.Format(time.RFC3339)
+	case TimeFormatRelative:
+		return relativeTime(t, now())
+	case TimeFormatUnix:
+		return strconv.FormatInt(epoch/1000, 10)
+	}
+
+	// default local format, truncated to the second
+	return t.Truncate(time.Second).String()
+}
+
+// FormatTimeISO formats a millisecond epoch timestamp as ISO 8601 in the configured time zone
+func FormatTimeISO(epoch int64) string {
+	t := time.Unix(epoch/1000, (epoch%1000)*int64(time.Millisecond)).In(TimeZone())
+	return t.Format("2006-01-02T15:04:05.000Z07:00")
+}
+
+// relativeTime returns a short description of t relative to now, such as "5m ago"
+func relativeTime(t time.Time, now time.Time) string {
+	d := now.Sub(t)
+	suffix := "ago"
+	if d < 0 {
+		d = -d
+		suffix = "from now"
+	}
+
+	var amount string
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		amount = fmt.Sprintf("%dm", int(d/time.Minute))
+	case d < 24*time.Hour:
+		amount = fmt.Sprintf("%dh", int(d/time.Hour))
+	case d < 30*24*time.Hour:
+		amount = fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	case d < 365*24*time.Hour:
+		amount = fmt.Sprintf("%dmo", int(d/(30*24*time.Hour)))
+	default:
+		amount = fmt.Sprintf("%dy", int(d/(365*24*time.Hour)))
+	}
+
+	return fmt.Sprintf("%s %s", amount, suffix)
+}
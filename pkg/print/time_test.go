@@ -0,0 +1,120 @@
+package print
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 2020-09-13T12:26:40.123Z
+const testEpoch = 1600000000123
+
+// pinTime sets the time zone, the time format, and the reference time of relative
+// timestamps, and restores them when the test ends
+func pinTime(t *testing.T, zone string, format string, reference time.Time) {
+	savedZone, savedFormat, savedNow := viper.Get("TimeZone"), timeFormat, now
+	t.Cleanup(func() {
+		viper.Set("TimeZone", savedZone)
+		timeFormat, now = savedFormat, savedNow
+	})
+
+	viper.Set("TimeZone", zone)
+	if err := SetTimeFormat(format); err != nil {
+		t.Fatal(err)
+	}
+	now = func() time.Time { return reference }
+}
+
+func TestFormatTime(t *testing.T) {
+	reference := time.Unix(testEpoch/1000, 0).Add(90 * time.Minute)
+	tests := []struct {
+		zone   string
+		format string
+		want   string
+	}{
+		{"UTC", TimeFormatLocal, "2020-09-13 12:26:40 +0000 UTC"},
+		{"Asia/Tokyo", TimeFormatLocal, "2020-09-13 21:26:40 +0900 JST"},
+		{"America/New_York", TimeFormatLocal, "2020-09-13 08:26:40 -0400 EDT"},
+		{"UTC", TimeFormatRFC3339, "2020-09-13T12:26:40Z"},
+		{"Asia/Tokyo", TimeFormatRFC3339, "2020-09-13T21:26:40+09:00"},
+		{"Asia/Tokyo", TimeFormatUnix, "1600000000"},
+		{"Asia/Tokyo", TimeFormatRelative, "1h ago"},
+		// an unknown zone falls back to the local time zone, which is pinned to UTC below
+		{"Mars/Olympus", TimeFormatRFC3339, "2020-09-13T12:26:40Z"},
+	}
+
+	savedLocal := time.Local
+	time.Local = time.UTC
+	defer func() { time.Local = savedLocal }()
+
+	for _, test := range tests {
+		t.Run(test.zone+" "+test.format, func(t *testing.T) {
+			pinTime(t, test.zone, test.format, reference)
+			if got := FormatTime(testEpoch); got != test.want {
+				t.Errorf("FormatTime(%d) = %q, want %q", int64(testEpoch), got, test.want)
+			}
+		})
+	}
+}
+
+func TestFormatTimeISO(t *testing.T) {
+	tests := []struct {
+		zone  string
+		epoch int64
+		want  string
+	}{
+		{"UTC", testEpoch, "2020-09-13T12:26:40.123Z"},
+		{"Asia/Tokyo", testEpoch, "2020-09-13T21:26:40.123+09:00"},
+		{"Asia/Kolkata", testEpoch, "2020-09-13T17:56:40.123+05:30"},
+		{"UTC", 0, "1970-01-01T00:00:00.000Z"},
+	}
+	for _, test := range tests {
+		t.Run(test.zone, func(t *testing.T) {
+			// the time format only applies to tables
+			pinTime(t, test.zone, TimeFormatUnix, time.Time{})
+			if got := FormatTimeISO(test.epoch); got != test.want {
+				t.Errorf("FormatTimeISO(%d) = %q, want %q", test.epoch, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	reference := time.Date(2020, 9, 13, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		offset time.Duration
+		want   string
+	}{
+		{0, "just now"},
+		{-59 * time.Second, "just now"},
+		{59 * time.Second, "just now"},
+		{-time.Minute, "1m ago"},
+		{-59 * time.Minute, "59m ago"},
+		{-time.Hour, "1h ago"},
+		{-23 * time.Hour, "23h ago"},
+		{-24 * time.Hour, "1d ago"},
+		{-29 * 24 * time.Hour, "29d ago"},
+		{-30 * 24 * time.Hour, "1mo ago"},
+		{-364 * 24 * time.Hour, "12mo ago"},
+		{-365 * 24 * time.Hour, "1y ago"},
+		{-3 * 365 * 24 * time.Hour, "3y ago"},
+		{5 * time.Minute, "5m from now"},
+		{2 * 24 * time.Hour, "2d from now"},
+	}
+	for _, test := range tests {
+		if got := relativeTime(reference.Add(test.offset), reference); got != test.want {
+			t.Errorf("relativeTime(%v) = %q, want %q", test.offset, got, test.want)
+		}
+	}
+}
+
+func TestSetTimeFormat(t *testing.T) {
+	pinTime(t, "UTC", TimeFormatLocal, time.Time{})
+	if err := SetTimeFormat("iso"); err == nil {
+		t.Error("SetTimeFormat accepted an unknown format")
+	}
+	if timeFormat != TimeFormatLocal {
+		t.Errorf("an unknown format changed the format to %q", timeFormat)
+	}
+}
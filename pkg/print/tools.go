@@ -2,10 +2,13 @@ package print
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"strings"
 
 	"github.com/jedib0t/go-pretty/table"
 	"github.com/jedib0t/go-pretty/text"
+	"github.com/snapmaster-io/snap/pkg/definition"
 	"github.com/snapmaster-io/snap/pkg/utils"
 )
 
@@ -48,3 +51,42 @@ func ToolsTable(response []byte) {
 	t.Style().Title.Align = text.AlignCenter
 	t.Render()
 }
+
+// ToolOperationsTable prints out a tool's triggers or actions and their parameters as a table
+func ToolOperationsTable(title string, operations []definition.Operation) {
+	// write out the table
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetTitle(title)
+	t.AppendHeader(table.Row{"Name", "Description", "Parameters"})
+	for _, operation := range operations {
+		t.AppendRow(table.Row{operation.Name, operation.Description, strings.Join(operation.ParameterNames(), ", ")})
+	}
+	t.SetStyle(tableStyle)
+	t.Style().Title.Align = text.AlignCenter
+	t.Render()
+}
+
+// ToolOperationDetails prints out the parameters of a tool's trigger or action as a table,
+// followed by an example config entry for a snap step that uses it
+func ToolOperationDetails(provider string, operation *definition.Operation, trigger bool) {
+	kind := "action"
+	if trigger {
+		kind = "trigger"
+	}
+
+	// write out the table of parameters
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetTitle(fmt.Sprintf("Parameters of %s %s:%s", kind, provider, operation.Name))
+	t.AppendHeader(table.Row{"Name", "Type", "Required", "Description"})
+	for _, p := range operation.Parameters {
+		t.AppendRow(table.Row{p.Name, p.Type, p.Required, p.Description})
+	}
+	t.SetStyle(tableStyle)
+	t.Style().Title.Align = text.AlignCenter
+	t.Render()
+
+	fmt.Printf("\nExample snap step:\n\n")
+	utils.PrintYAML("config:\n" + definition.ExampleStep(provider, operation, trigger))
+}
@@ -0,0 +1,64 @@
+package print
+
+import (
+	"os"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/jedib0t/go-pretty/text"
+)
+
+// ToolUsage defines a step of a snap or active snap that uses a tool.  CredentialSet is
+// empty when the step doesn't name a credential set, and so may use any of them.
+type ToolUsage struct {
+	SnapID        string `json:"snapId"`
+	ActiveSnapID  string `json:"activeSnapId,omitempty"`
+	State         string `json:"state,omitempty"`
+	Step          string `json:"step"`
+	CredentialSet string `json:"credentialSet"`
+}
+
+// ToolUsageResponse defines the fields to marshal for the snaps and active snaps that use a tool
+type ToolUsageResponse struct {
+	Tool          string      `json:"tool"`
+	CredentialSet string      `json:"credentialSet,omitempty"`
+	Snaps         []ToolUsage `json:"snaps"`
+	ActiveSnaps   []ToolUsage `json:"activeSnaps"`
+}
+
+// SnapUsageTable prints out the steps of snaps that use a tool as a table
+func SnapUsageTable(title string, usage []ToolUsage) {
+	// write out the table
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetTitle(title)
+	t.AppendHeader(table.Row{"Snap ID", "Step", "Credential Set"})
+	for _, u := range usage {
+		t.AppendRow(table.Row{u.SnapID, u.Step, credentialSetName(u.CredentialSet)})
+	}
+	t.SetStyle(tableStyle)
+	t.Style().Title.Align = text.AlignCenter
+	t.Render()
+}
+
+// ActiveSnapUsageTable prints out the steps of active snaps that use a tool as a table
+func ActiveSnapUsageTable(title string, usage []ToolUsage) {
+	// write out the table
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetTitle(title)
+	t.AppendHeader(table.Row{"Active Snap ID", "Snap ID", "State", "Step", "Credential Set"})
+	for _, u := range usage {
+		t.AppendRow(table.Row{u.ActiveSnapID, u.SnapID, u.State, u.Step, credentialSetName(u.CredentialSet)})
+	}
+	t.SetStyle(tableStyle)
+	t.Style().Title.Align = text.AlignCenter
+	t.Render()
+}
+
+// credentialSetName returns the name to display for a credential set a step uses
+func credentialSetName(name string) string {
+	if name == "" {
+		return "(default)"
+	}
+	return name
+}
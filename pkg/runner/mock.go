@@ -0,0 +1,104 @@
+package runner
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/snapmaster-io/snap/pkg/print"
+	"gopkg.in/yaml.v2"
+)
+
+// Fixture is a canned output of the mock provider.  It matches the calls to the step, and
+// to the provider and action, that it names; an empty field matches any call.
+type Fixture struct {
+	Step     string        `yaml:"step,omitempty"`
+	Provider string        `yaml:"provider,omitempty"`
+	Action   string        `yaml:"action,omitempty"`
+	Output   FixtureOutput `yaml:"output"`
+}
+
+// FixtureOutput is the output of an action, as it appears in a fixtures file
+type FixtureOutput struct {
+	Status  string      `yaml:"status,omitempty"`
+	Message string      `yaml:"message,omitempty"`
+	Data    interface{} `yaml:"data,omitempty"`
+}
+
+// fixturesFile defines the fields of a fixtures file
+type fixturesFile struct {
+	Outputs []Fixture `yaml:"outputs"`
+}
+
+// ReadFixtures reads the fixtures in a yaml file of the form
+//
+//	outputs:
+//	  - step: build
+//	    output:
+//	      data:
+//	        stdout: built
+//	  - provider: slack
+//	    action: send
+//	    output:
+//	      status: error
+//	      message: channel not found
+func ReadFixtures(path string) ([]Fixture, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file fixturesFile
+	if err := yaml.UnmarshalStrict(contents, &file); err != nil {
+		return nil, fmt.Errorf("could not parse fixtures file %s: %s", path, err)
+	}
+	return file.Outputs, nil
+}
+
+// Mock is a provider that records its calls, and returns the output of the first fixture
+// that matches each call, or a successful output if none does
+type Mock struct {
+	Fixtures []Fixture
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+// Invoke records the call, and returns the output of the first fixture that matches it
+func (m *Mock) Invoke(call Call) (print.ActiveSnapActionsLogOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, call)
+
+	for _, f := range m.Fixtures {
+		if f.matches(call) {
+			output := print.ActiveSnapActionsLogOutput{Status: f.Output.Status, Message: f.Output.Message}
+			if data, ok := expand(f.Output.Data, nil).(map[string]interface{}); ok {
+				output.Data = data
+			}
+			return output, nil
+		}
+	}
+
+	return print.ActiveSnapActionsLogOutput{
+		Status:  "success",
+		Message: fmt.Sprintf("mocked %s:%s", call.Provider, call.Action),
+		Data:    map[string]interface{}{},
+	}, nil
+}
+
+// Calls returns the calls the mock has received, in order
+func (m *Mock) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Call(nil), m.calls...)
+}
+
+// matches returns whether the fixture applies to the call
+func (f Fixture) matches(call Call) bool {
+	return (f.Step == "" || f.Step == call.Step) &&
+		(f.Provider == "" || f.Provider == call.Provider) &&
+		(f.Action == "" || f.Action == call.Action)
+}
@@ -0,0 +1,66 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFixtureMatches(t *testing.T) {
+	call := Call{Step: "notify", Provider: "slack", Action: "send"}
+	tests := []struct {
+		fixture Fixture
+		matches bool
+	}{
+		{Fixture{}, true},
+		{Fixture{Step: "notify"}, true},
+		{Fixture{Provider: "slack", Action: "send"}, true},
+		{Fixture{Step: "notify", Provider: "slack", Action: "send"}, true},
+		{Fixture{Step: "build"}, false},
+		{Fixture{Provider: "slack", Action: "upload"}, false},
+		{Fixture{Step: "notify", Provider: "docker"}, false},
+	}
+
+	for _, test := range tests {
+		if matches := test.fixture.matches(call); matches != test.matches {
+			t.Errorf("%+v matches %+v: %v, want %v", test.fixture, call, matches, test.matches)
+		}
+	}
+}
+
+func TestMockInvoke(t *testing.T) {
+	m := &Mock{Fixtures: []Fixture{
+		{Step: "build", Output: FixtureOutput{Data: map[interface{}]interface{}{"stdout": "built"}}},
+		{Provider: "slack", Output: FixtureOutput{Status: "error", Message: "channel not found"}},
+		// never used: the fixture above matches first
+		{Provider: "slack", Action: "send", Output: FixtureOutput{Status: "success"}},
+	}}
+
+	calls := []Call{
+		{Step: "build", Provider: "docker", Action: "build"},
+		{Step: "notify", Provider: "slack", Action: "send"},
+		{Step: "deploy", Provider: "kubernetes", Action: "apply"},
+	}
+	var statuses, messages []string
+	for _, call := range calls {
+		output, err := m.Invoke(call)
+		if err != nil {
+			t.Fatal(err)
+		}
+		statuses = append(statuses, output.Status)
+		messages = append(messages, output.Message)
+		if call.Step == "build" && output.Data["stdout"] != "built" {
+			t.Errorf("build output data %v, want the fixture's", output.Data)
+		}
+	}
+
+	// a call without a fixture succeeds
+	if want := []string{"", "error", "success"}; !reflect.DeepEqual(statuses, want) {
+		t.Errorf("statuses %q, want %q", statuses, want)
+	}
+	if want := []string{"", "channel not found", "mocked kubernetes:apply"}; !reflect.DeepEqual(messages, want) {
+		t.Errorf("messages %q, want %q", messages, want)
+	}
+	if !reflect.DeepEqual(m.Calls(), calls) {
+		t.Errorf("recorded calls %+v, want %+v", m.Calls(), calls)
+	}
+}
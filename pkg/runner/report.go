@@ -0,0 +1,110 @@
+package runner
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// WriteTAP writes the results in the Test Anything Protocol, version 13, with the failed
+// assertions of each test in a yaml block
+func WriteTAP(w io.Writer, results []Result) error {
+	var b strings.Builder
+	b.WriteString("TAP version 13\n")
+	fmt.Fprintf(&b, "1..%d\n", len(results))
+	for i, result := range results {
+		status := "ok"
+		if !result.Passed() {
+			status = "not ok"
+		}
+		// "#" starts a directive in a TAP description
+		description := strings.Replace(fmt.Sprintf("%s: %s", result.Suite, result.Name), "#", `\#`, -1)
+		fmt.Fprintf(&b, "%s %d - %s\n", status, i+1, description)
+
+		if !result.Passed() {
+			b.WriteString("  ---\n")
+			b.WriteString("  failures:\n")
+			for _, failure := range result.Failures {
+				fmt.Fprintf(&b, "    - %q\n", failure)
+			}
+			b.WriteString("  ...\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// junitTestSuites defines the root element of a JUnit XML report
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite defines a test suite of a JUnit XML report
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase defines a test case of a JUnit XML report
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+// junitFailure defines the failure of a test case of a JUnit XML report
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the results as a JUnit XML report, with a test suite for each suite file
+func WriteJUnit(w io.Writer, results []Result) error {
+	seconds := func(d time.Duration) string {
+		return fmt.Sprintf("%.3f", d.Seconds())
+	}
+
+	report := junitTestSuites{}
+	var total time.Duration
+	for i := 0; i < len(results); {
+		// results are grouped by suite, in order
+		suite := junitTestSuite{Name: results[i].Suite}
+		var elapsed time.Duration
+		for ; i < len(results) && results[i].Suite == suite.Name; i++ {
+			result := results[i]
+			testCase := junitTestCase{ClassName: result.Suite, Name: result.Name, Time: seconds(result.Duration)}
+			if !result.Passed() {
+				testCase.Failure = &junitFailure{Message: result.Failures[0], Text: strings.Join(result.Failures, "\n")}
+				suite.Failures++
+			}
+			suite.Cases = append(suite.Cases, testCase)
+			suite.Tests++
+			elapsed += result.Duration
+		}
+		suite.Time = seconds(elapsed)
+
+		report.Suites = append(report.Suites, suite)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		total += elapsed
+	}
+	report.Time = seconds(total)
+
+	contents, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, contents)
+	return err
+}
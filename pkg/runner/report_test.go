@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+)
+
+var testResults = []Result{
+	{Suite: "a.snaptest.yaml", Name: "passes", Duration: 1500 * time.Millisecond},
+	{Suite: "a.snaptest.yaml", Name: "fails #1", Failures: []string{"state: expected complete, got error", `actions: expected [build], got ["build", "notify"]`}, Duration: 500 * time.Millisecond},
+	{Suite: "b.snaptest.yaml", Name: "passes too", Duration: time.Second},
+}
+
+func TestWriteTAP(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteTAP(&b, testResults); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `TAP version 13
+1..3
+ok 1 - a.snaptest.yaml: passes
+not ok 2 - a.snaptest.yaml: fails \#1
+  ---
+  failures:
+    - "state: expected complete, got error"
+    - "actions: expected [build], got [\"build\", \"notify\"]"
+  ...
+ok 3 - b.snaptest.yaml: passes too
+`
+	if b.String() != want {
+		t.Errorf("WriteTAP wrote:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteJUnit(&b, testResults); err != nil {
+		t.Fatal(err)
+	}
+
+	var report junitTestSuites
+	if err := xml.Unmarshal(b.Bytes(), &report); err != nil {
+		t.Fatalf("the report isn't valid XML: %s\n%s", err, b.String())
+	}
+	if report.Tests != 3 || report.Failures != 1 || report.Time != "3.000" {
+		t.Errorf("report totals: %d tests, %d failures, %ss; want 3, 1, 3.000s", report.Tests, report.Failures, report.Time)
+	}
+	if len(report.Suites) != 2 {
+		t.Fatalf("report has %d suites, want 2", len(report.Suites))
+	}
+
+	a := report.Suites[0]
+	if a.Name != "a.snaptest.yaml" || a.Tests != 2 || a.Failures != 1 || a.Time != "2.000" {
+		t.Errorf("first suite %+v", a)
+	}
+	if a.Cases[0].Failure != nil {
+		t.Errorf("passing test has a failure: %+v", a.Cases[0].Failure)
+	}
+	failure := a.Cases[1].Failure
+	if failure == nil || failure.Message != testResults[1].Failures[0] || failure.Text != testResults[1].Failures[0]+"\n"+testResults[1].Failures[1] {
+		t.Errorf("failure %+v", failure)
+	}
+	if b := report.Suites[1]; b.Name != "b.snaptest.yaml" || b.Tests != 1 || b.Failures != 0 {
+		t.Errorf("second suite %+v", b)
+	}
+}
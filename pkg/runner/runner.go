@@ -0,0 +1,203 @@
+// Package runner runs snap definitions locally, without a server: it resolves the snap's
+// parameters, and walks its actions in order, invoking a Provider for each of them.  The
+// built-in Mock provider records its calls and returns canned outputs from fixtures, for
+// testing snap definitions offline.
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/snapmaster-io/snap/pkg/definition"
+	"github.com/snapmaster-io/snap/pkg/print"
+)
+
+// the active snap ID of local runs
+const LocalActiveSnapID = "local"
+
+// log and action states, as the server reports them
+const (
+	StateComplete = "complete"
+	StateError    = "error"
+)
+
+// Call is an invocation of an action, with the step's values resolved
+type Call struct {
+	Step       string                 `json:"step"`
+	Provider   string                 `json:"provider"`
+	Action     string                 `json:"action"`
+	Parameters map[string]interface{} `json:"parameters"`
+	Event      map[string]interface{} `json:"event,omitempty"`
+}
+
+// Provider runs the actions of a tool.  An error is reported as a failed action.
+type Provider interface {
+	Invoke(call Call) (print.ActiveSnapActionsLogOutput, error)
+}
+
+// ProviderFunc adapts a function to the Provider interface
+type ProviderFunc func(call Call) (print.ActiveSnapActionsLogOutput, error)
+
+// Invoke calls the function
+func (f ProviderFunc) Invoke(call Call) (print.ActiveSnapActionsLogOutput, error) {
+	return f(call)
+}
+
+// Runner runs snaps locally
+type Runner struct {
+	// Providers run the actions of the tools they are keyed by
+	Providers map[string]Provider
+	// Default runs the actions of the tools that don't have a provider
+	Default Provider
+}
+
+// Run runs the actions of the snap in order, with the parameter values and the trigger's
+// event payload, and returns the log of the run.  The run stops at the first action that
+// fails, like it does on the server.  An error is returned if the definition isn't valid,
+// or the parameter values don't satisfy it.
+func (r *Runner) Run(snap *definition.Snap, values map[string]string, event map[string]interface{}) (*print.ActiveSnapLog, error) {
+	if err := snap.Validate(); err != nil {
+		return nil, err
+	}
+	params, err := ResolveParameters(snap, values)
+	if err != nil {
+		return nil, err
+	}
+
+	trigger := snap.TriggerStep()
+	log := &print.ActiveSnapLog{
+		LogID:        time.Now().UnixNano() / int64(time.Millisecond),
+		ActiveSnapID: LocalActiveSnapID,
+		SnapID:       snap.Name,
+		State:        StateComplete,
+		Trigger:      trigger.Provider,
+		Event:        trigger.Event,
+		Actions:      []print.ActiveSnapActionsLog{},
+	}
+
+	for _, step := range snap.ActionSteps() {
+		call := Call{
+			Step:       step.Name,
+			Provider:   step.Provider,
+			Action:     step.Action,
+			Parameters: StepParameters(step, params),
+			Event:      event,
+		}
+
+		provider, ok := r.Providers[step.Provider]
+		if !ok {
+			provider = r.Default
+		}
+		var output print.ActiveSnapActionsLogOutput
+		if provider == nil {
+			err = fmt.Errorf("no provider for tool %s", step.Provider)
+		} else {
+			output, err = provider.Invoke(call)
+		}
+		if err != nil {
+			output = print.ActiveSnapActionsLogOutput{Status: "error", Message: err.Error()}
+		}
+		if output.Status == "" {
+			output.Status = "success"
+		}
+
+		action := print.ActiveSnapActionsLog{
+			Name:       step.Name,
+			Provider:   step.Provider,
+			Action:     step.Action,
+			State:      StateComplete,
+			Parameters: call.Parameters,
+			Output:     output,
+		}
+		if output.Status != "success" {
+			action.State = StateError
+		}
+		log.Actions = append(log.Actions, action)
+
+		if action.State == StateError {
+			log.State = StateError
+			break
+		}
+	}
+
+	return log, nil
+}
+
+// ResolveParameters returns the value of each of the snap's parameters: the value passed
+// in, or else the parameter's default, or else "" for a parameter that isn't required
+func ResolveParameters(snap *definition.Snap, values map[string]string) (map[string]string, error) {
+	var problems []string
+
+	var names []string
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if snap.FindParameter(name) == nil {
+			problems = append(problems, fmt.Sprintf("'%s' is not a parameter of snap %s", name, snap.Name))
+		}
+	}
+
+	params := make(map[string]string)
+	for _, p := range snap.Parameters {
+		value, ok := values[p.Name]
+		switch {
+		case ok:
+		case p.Default != "":
+			value = p.Default
+		case p.Required:
+			problems = append(problems, fmt.Sprintf("required parameter '%s' has no value", p.Name))
+		}
+		params[p.Name] = value
+	}
+
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "\n"))
+	}
+
+	return params, nil
+}
+
+// StepParameters returns the values of a step, with the references to snap parameters
+// replaced by their values
+func StepParameters(step definition.Step, params map[string]string) map[string]interface{} {
+	values := make(map[string]interface{})
+	for _, v := range step.Values {
+		values[v.Name] = expand(v.Value, params)
+	}
+
+	return values
+}
+
+// expand replaces the references to snap parameters in the strings of a yaml value, and
+// converts its maps to map[string]interface{} so that they can be marshaled into JSON
+func expand(value interface{}, params map[string]string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return definition.Expand(v, params)
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = expand(item, params)
+		}
+		return list
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{})
+		for key, item := range v {
+			m[fmt.Sprintf("%v", key)] = expand(item, params)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{})
+		for key, item := range v {
+			m[key] = expand(item, params)
+		}
+		return m
+	default:
+		return value
+	}
+}
@@ -0,0 +1,306 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/snapmaster-io/snap/pkg/definition"
+	"github.com/snapmaster-io/snap/pkg/print"
+	"gopkg.in/yaml.v2"
+)
+
+// SuiteSuffix is the suffix of the names of test suite files
+const SuiteSuffix = ".snaptest.yaml"
+
+// Suite is a file of tests of a snap definition
+type Suite struct {
+	// Path is the path of the suite file
+	Path string `yaml:"-"`
+	// Snap is the path of the snap definition, relative to the suite file
+	Snap string `yaml:"snap"`
+	// Vars and VarFiles (relative to the suite file) are substituted into the definition
+	Vars     map[string]interface{} `yaml:"vars,omitempty"`
+	VarFiles []string               `yaml:"varFiles,omitempty"`
+	Tests    []Test                 `yaml:"tests"`
+}
+
+// Test runs the snap with parameter values, an event payload, and mocked action outputs,
+// and checks the run against its expectations
+type Test struct {
+	Name   string                 `yaml:"name"`
+	Params map[string]interface{} `yaml:"params,omitempty"`
+	Event  map[string]interface{} `yaml:"event,omitempty"`
+	// EventFile is a JSON or yaml file with the event payload, relative to the suite file
+	EventFile string      `yaml:"eventFile,omitempty"`
+	Outputs   []Fixture   `yaml:"outputs,omitempty"`
+	Expect    Expectation `yaml:"expect"`
+}
+
+// Expectation defines the assertions on a run; the fields that aren't set aren't checked
+type Expectation struct {
+	// State is the state of the run: complete, or error if an action failed
+	State string `yaml:"state,omitempty"`
+	// Actions are the names of the steps that ran, in order
+	Actions []string `yaml:"actions,omitempty"`
+	// Parameters are the resolved values of steps, by step name; only the values listed
+	// are checked
+	Parameters map[string]map[string]interface{} `yaml:"parameters,omitempty"`
+}
+
+// Result is the outcome of a test
+type Result struct {
+	Suite    string
+	Name     string
+	Failures []string
+	Duration time.Duration
+	Log      *print.ActiveSnapLog
+}
+
+// Passed returns whether the test passed
+func (r Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// Discover returns the sorted paths of the suite files among the paths, searching
+// directories recursively (except for hidden directories)
+func Discover(paths []string) ([]string, error) {
+	var suites []string
+	for _, path := range paths {
+		err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				if p != path && strings.HasPrefix(info.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if p == path || strings.HasSuffix(p, SuiteSuffix) {
+				suites = append(suites, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(suites)
+
+	return suites, nil
+}
+
+// ReadSuite reads a suite file
+func ReadSuite(path string) (*Suite, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var suite Suite
+	if err := yaml.UnmarshalStrict(contents, &suite); err != nil {
+		return nil, fmt.Errorf("could not parse test suite %s: %s", path, err)
+	}
+	if suite.Snap == "" {
+		return nil, fmt.Errorf("test suite %s does not name a snap definition", path)
+	}
+	suite.Path = path
+
+	return &suite, nil
+}
+
+// Run runs the tests of the suite.  If the snap definition can't be loaded, every test fails.
+func (s *Suite) Run() []Result {
+	snap, err := s.loadSnap()
+
+	var results []Result
+	for i, test := range s.Tests {
+		name := test.Name
+		if name == "" {
+			name = fmt.Sprintf("test %d", i+1)
+		}
+
+		result := Result{Suite: s.Path, Name: name}
+		start := time.Now()
+		if err != nil {
+			result.Failures = []string{err.Error()}
+		} else {
+			result.Log, result.Failures = s.runTest(snap, test)
+		}
+		result.Duration = time.Since(start)
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// loadSnap reads the suite's snap definition, with its variables substituted
+func (s *Suite) loadSnap() (*definition.Snap, error) {
+	vars := make(map[string]interface{})
+	for _, file := range s.VarFiles {
+		fileVars, err := definition.ReadVars(s.relative(file))
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range fileVars {
+			vars[name] = value
+		}
+	}
+	for name, value := range s.Vars {
+		vars[name] = value
+	}
+
+	path := s.relative(s.Snap)
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	text, err := definition.Render(string(contents), vars)
+	if err != nil {
+		return nil, fmt.Errorf("could not render snap definition %s: %s", path, err)
+	}
+	snap, err := definition.Parse([]byte(text))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse snap definition %s: %s", path, err)
+	}
+
+	return snap, nil
+}
+
+// relative returns a path relative to the suite file
+func (s *Suite) relative(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(filepath.Dir(s.Path), path)
+}
+
+// runTest runs a test, and returns the log of the run and the failed assertions
+func (s *Suite) runTest(snap *definition.Snap, test Test) (*print.ActiveSnapLog, []string) {
+	event := test.Event
+	if test.EventFile != "" {
+		contents, err := ioutil.ReadFile(s.relative(test.EventFile))
+		if err != nil {
+			return nil, []string{err.Error()}
+		}
+		if err := yaml.Unmarshal(contents, &event); err != nil {
+			return nil, []string{fmt.Sprintf("could not parse event file %s: %s", test.EventFile, err)}
+		}
+	}
+	if event != nil {
+		event = expand(event, nil).(map[string]interface{})
+	}
+
+	values := make(map[string]string)
+	for name, value := range test.Params {
+		values[name] = fmt.Sprintf("%v", value)
+	}
+
+	r := Runner{Default: &Mock{Fixtures: test.Outputs}}
+	log, err := r.Run(snap, values, event)
+	if err != nil {
+		return nil, []string{err.Error()}
+	}
+
+	return log, test.Expect.check(log)
+}
+
+// check returns the assertions of the expectation that the log of a run fails
+func (e Expectation) check(log *print.ActiveSnapLog) []string {
+	var failures []string
+
+	if e.State != "" && e.State != log.State {
+		failure := fmt.Sprintf("state: expected %s, got %s", e.State, log.State)
+		if n := len(log.Actions); n > 0 && log.Actions[n-1].State == StateError {
+			failed := log.Actions[n-1]
+			failure += fmt.Sprintf(" (%s failed: %s)", failed.Name, failed.Output.Message)
+		}
+		failures = append(failures, failure)
+	}
+
+	ran := []string{}
+	steps := make(map[string]print.ActiveSnapActionsLog)
+	for _, action := range log.Actions {
+		ran = append(ran, action.Name)
+		steps[action.Name] = action
+	}
+	if e.Actions != nil && !reflect.DeepEqual(e.Actions, ran) {
+		failures = append(failures, fmt.Sprintf("actions: expected [%s], got [%s]", strings.Join(e.Actions, ", "), strings.Join(ran, ", ")))
+	}
+
+	var names []string
+	for name := range e.Parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		action, ok := steps[name]
+		if !ok {
+			failures = append(failures, fmt.Sprintf("parameters of %s: the step did not run", name))
+			continue
+		}
+
+		expected := e.Parameters[name]
+		var params []string
+		for param := range expected {
+			params = append(params, param)
+		}
+		sort.Strings(params)
+		for _, param := range params {
+			want := expand(expected[param], nil)
+			got, ok := action.Parameters[param]
+			switch {
+			case !ok:
+				failures = append(failures, fmt.Sprintf("parameters of %s: %s: expected %s, not set", name, param, describeValue(want)))
+			case !reflect.DeepEqual(canonical(want), canonical(got)):
+				failures = append(failures, fmt.Sprintf("parameters of %s: %s: expected %s, got %s", name, param, describeValue(want), describeValue(got)))
+			}
+		}
+	}
+
+	return failures
+}
+
+// canonical converts the scalars in a value to strings, so that values that are written
+// the same way compare equal, whether or not they come from a parameter reference
+func canonical(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = canonical(item)
+		}
+		return list
+	case map[string]interface{}:
+		m := make(map[string]interface{})
+		for key, item := range v {
+			m[key] = canonical(item)
+		}
+		return m
+	case nil:
+		return ""
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// describeValue formats a value for a failure message
+func describeValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(b)
+}
@@ -0,0 +1,169 @@
+package runner
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/snapmaster-io/snap/pkg/print"
+)
+
+// testLog is the log of a run whose second action failed
+var testLog = &print.ActiveSnapLog{
+	State: StateError,
+	Actions: []print.ActiveSnapActionsLog{
+		{
+			Name:       "build",
+			State:      StateComplete,
+			Parameters: map[string]interface{}{"image": "app:1", "retries": 3, "tags": []interface{}{"a", "b"}},
+		},
+		{
+			Name:   "notify",
+			State:  StateError,
+			Output: print.ActiveSnapActionsLogOutput{Status: "error", Message: "channel not found"},
+		},
+	},
+}
+
+func TestExpectationCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		expect   Expectation
+		failures []string
+	}{
+		{
+			name:   "nothing checked",
+			expect: Expectation{},
+		},
+		{
+			name: "all met",
+			expect: Expectation{
+				State:   StateError,
+				Actions: []string{"build", "notify"},
+				Parameters: map[string]map[string]interface{}{
+					// scalars compare by how they are written
+					"build": {"image": "app:1", "retries": "3", "tags": []interface{}{"a", "b"}},
+				},
+			},
+		},
+		{
+			name:     "state, with the failed action",
+			expect:   Expectation{State: StateComplete},
+			failures: []string{"state: expected complete, got error (notify failed: channel not found)"},
+		},
+		{
+			name:     "actions",
+			expect:   Expectation{Actions: []string{"build"}},
+			failures: []string{"actions: expected [build], got [build, notify]"},
+		},
+		{
+			name: "parameters",
+			expect: Expectation{Parameters: map[string]map[string]interface{}{
+				"build":  {"image": "app:2", "platform": "linux"},
+				"deploy": {"cluster": "prod"},
+			}},
+			failures: []string{
+				`parameters of build: image: expected "app:2", got "app:1"`,
+				`parameters of build: platform: expected "linux", not set`,
+				"parameters of deploy: the step did not run",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		if failures := test.expect.check(testLog); !reflect.DeepEqual(failures, test.failures) {
+			t.Errorf("%s: check returned %q, want %q", test.name, failures, test.failures)
+		}
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := []string{
+		"b.snaptest.yaml",
+		"a/c.snaptest.yaml",
+		"a/snap.yaml",
+		".hidden/d.snaptest.yaml",
+		"explicit.yaml",
+	}
+	for _, file := range files {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// directories are searched for suite files, and files passed explicitly are kept
+	suites, err := Discover([]string{dir, filepath.Join(dir, "explicit.yaml")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(dir, "a/c.snaptest.yaml"),
+		filepath.Join(dir, "b.snaptest.yaml"),
+		filepath.Join(dir, "explicit.yaml"),
+	}
+	if !reflect.DeepEqual(suites, want) {
+		t.Errorf("Discover returned %q, want %q", suites, want)
+	}
+
+	if _, err := Discover([]string{filepath.Join(dir, "missing")}); err == nil {
+		t.Error("Discover of a missing path succeeded")
+	}
+}
+
+func TestReadSuite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	suites := map[string]string{
+		"no-snap.snaptest.yaml": "tests: []\n",
+		"unknown.snaptest.yaml": "snap: a.yaml\ntests:\n  - name: x\n    expects: {}\n",
+	}
+	for name, contents := range suites {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadSuite(path); err == nil {
+			t.Errorf("ReadSuite(%s) succeeded", name)
+		}
+	}
+}
+
+// TestExamples runs the example suites, which should pass
+func TestExamples(t *testing.T) {
+	paths, err := Discover([]string{filepath.Join("..", "..", "examples")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no example suites")
+	}
+
+	for _, path := range paths {
+		suite, err := ReadSuite(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, result := range suite.Run() {
+			if !result.Passed() {
+				t.Errorf("%s: %s: %s", path, result.Name, strings.Join(result.Failures, "; "))
+			}
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// Package secrets resolves references to secrets, such as vault://secret/data/ci#token,
+// env://NAME, file://path and cmd://pass show x, into their values.  Resolvers for other
+// schemes can be registered.
+package secrets
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Resolver resolves the references of one scheme.  The reference passed to Resolve is the
+// part of the value after "scheme://".
+type Resolver interface {
+	Resolve(reference string) (string, error)
+}
+
+// Checker is implemented by resolvers that can check the syntax of a reference without
+// resolving it
+type Checker interface {
+	Check(reference string) error
+}
+
+// ResolverFunc adapts a function to the Resolver interface
+type ResolverFunc func(reference string) (string, error)
+
+// Resolve calls the function
+func (f ResolverFunc) Resolve(reference string) (string, error) {
+	return f(reference)
+}
+
+var (
+	mu        sync.RWMutex
+	resolvers = map[string]Resolver{
+		"env":   ResolverFunc(resolveEnv),
+		"file":  ResolverFunc(resolveFile),
+		"cmd":   ResolverFunc(resolveCommand),
+		"vault": &Vault{},
+	}
+)
+
+// Register adds or replaces the resolver for a scheme
+func Register(scheme string, resolver Resolver) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	resolvers[scheme] = resolver
+}
+
+// Schemes returns the sorted schemes that have a resolver
+func Schemes() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	var schemes []string
+	for scheme := range resolvers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+
+	return schemes
+}
+
+// IsReference returns whether the value is a reference for one of the registered schemes
+func IsReference(value string) bool {
+	_, _, ok := resolverFor(value)
+	return ok
+}
+
+// Scheme returns the scheme of the value if it is a reference, or else ""
+func Scheme(value string) string {
+	if !IsReference(value) {
+		return ""
+	}
+
+	return value[:strings.Index(value, "://")]
+}
+
+// Resolve returns the secret the value refers to, or the value itself if it isn't a reference
+func Resolve(value string) (string, error) {
+	resolver, reference, ok := resolverFor(value)
+	if !ok {
+		return value, nil
+	}
+
+	secret, err := resolver.Resolve(reference)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve %s: %s", value, err)
+	}
+
+	return secret, nil
+}
+
+// Check returns an error if the value is a reference that is malformed, without resolving
+// it: no command is run, and no file, environment variable, or secret store is read
+func Check(value string) error {
+	resolver, reference, ok := resolverFor(value)
+	if !ok {
+		return nil
+	}
+
+	if reference == "" {
+		return fmt.Errorf("could not resolve %s: the reference is empty", value)
+	}
+	if checker, ok := resolver.(Checker); ok {
+		if err := checker.Check(reference); err != nil {
+			return fmt.Errorf("could not resolve %s: %s", value, err)
+		}
+	}
+
+	return nil
+}
+
+// resolverFor returns the resolver for the scheme of the value and the rest of the reference,
+// and whether the value is a reference
+func resolverFor(value string) (Resolver, string, bool) {
+	parts := strings.SplitN(value, "://", 2)
+	if len(parts) != 2 {
+		return nil, "", false
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	resolver, ok := resolvers[parts[0]]
+	return resolver, parts[1], ok
+}
+
+// resolveEnv resolves env://NAME to the value of an environment variable
+func resolveEnv(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+
+	return value, nil
+}
+
+// resolveFile resolves file://path to the contents of a file, without a trailing newline.
+// A path starting with ~/ is relative to the home directory.
+func resolveFile(path string) (string, error) {
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, path[2:])
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(contents), "\r\n"), nil
+}
+
+// resolveCommand resolves cmd://command to the output of a shell command, without a
+// trailing newline
+func resolveCommand(command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stdin = os.Stdin
+
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("%s: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+
+	return strings.TrimRight(string(output), "\r\n"), nil
+}
@@ -0,0 +1,120 @@
+package secrets_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/snapmaster-io/snap/pkg/secrets"
+)
+
+func TestResolveLiteral(t *testing.T) {
+	for _, value := range []string{"", "plain", "https://example.com", "unknown://x"} {
+		resolved, err := secrets.Resolve(value)
+		if err != nil || resolved != value {
+			t.Errorf("Resolve(%q) = %q, %v; want the value itself", value, resolved, err)
+		}
+		if secrets.IsReference(value) {
+			t.Errorf("IsReference(%q) = true", value)
+		}
+	}
+}
+
+func TestResolveEnv(t *testing.T) {
+	os.Setenv("SNAP_SECRETS_TEST", "s3cret")
+	defer os.Unsetenv("SNAP_SECRETS_TEST")
+
+	resolved, err := secrets.Resolve("env://SNAP_SECRETS_TEST")
+	if err != nil || resolved != "s3cret" {
+		t.Errorf("Resolve(env://SNAP_SECRETS_TEST) = %q, %v; want s3cret", resolved, err)
+	}
+
+	if _, err := secrets.Resolve("env://SNAP_SECRETS_TEST_UNSET"); err == nil {
+		t.Error("Resolve of an unset variable succeeded")
+	}
+}
+
+func TestResolveFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(path, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	resolved, err := secrets.Resolve("file://" + path)
+	if err != nil || resolved != "s3cret" {
+		t.Errorf("Resolve(file://%s) = %q, %v; want s3cret without the newline", path, resolved, err)
+	}
+
+	if _, err := secrets.Resolve("file://" + filepath.Join(dir, "missing")); err == nil {
+		t.Error("Resolve of a missing file succeeded")
+	}
+}
+
+func TestResolveCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	resolved, err := secrets.Resolve("cmd://echo s3cret")
+	if err != nil || resolved != "s3cret" {
+		t.Errorf("Resolve(cmd://echo s3cret) = %q, %v; want s3cret", resolved, err)
+	}
+
+	_, err = secrets.Resolve("cmd://echo denied >&2; exit 3")
+	if err == nil || !strings.Contains(err.Error(), "denied") {
+		t.Errorf("Resolve of a failing command returned %v; want an error with its stderr", err)
+	}
+}
+
+func TestCheckDoesNotResolve(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	marker := filepath.Join(dir, "ran")
+
+	valid := []string{
+		"plain",
+		"cmd://touch " + marker,
+		"env://SNAP_SECRETS_TEST_UNSET",
+		"file://" + filepath.Join(dir, "missing"),
+		"vault://secret/data/ci#token",
+	}
+	for _, value := range valid {
+		if err := secrets.Check(value); err != nil {
+			t.Errorf("Check(%q) = %v; want nil", value, err)
+		}
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("Check ran the command")
+	}
+
+	for _, value := range []string{"env://", "cmd://", "vault://#token"} {
+		if err := secrets.Check(value); err == nil {
+			t.Errorf("Check(%q) = nil; want an error", value)
+		}
+	}
+}
+
+func TestScheme(t *testing.T) {
+	tests := map[string]string{
+		"vault://secret/data/ci#token": "vault",
+		"cmd://pass show ci":           "cmd",
+		"https://example.com":          "",
+		"plain":                        "",
+	}
+	for value, want := range tests {
+		if got := secrets.Scheme(value); got != want {
+			t.Errorf("Scheme(%q) = %q; want %q", value, got, want)
+		}
+	}
+}
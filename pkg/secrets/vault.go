@@ -0,0 +1,167 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Vault resolves vault://path#field references against the HTTP API of a Vault KV version 2
+// secrets engine.  The path includes the mount and the "data" segment, as in the API
+// (e.g. secret/data/ci), and may end with ?version=N.  The field can be omitted when the
+// secret has a single field.
+type Vault struct {
+	// Address is the URL of the Vault server; it defaults to $VAULT_ADDR, or http://127.0.0.1:8200
+	Address string
+	// Token is the Vault token; it defaults to $VAULT_TOKEN, or the contents of ~/.vault-token
+	Token string
+	// Namespace is the Vault Enterprise namespace; it defaults to $VAULT_NAMESPACE
+	Namespace string
+	// Client is the HTTP client used for requests; it defaults to http.DefaultClient
+	Client *http.Client
+}
+
+// Resolve reads the secret at the path, and returns the value of the field
+func (v *Vault) Resolve(reference string) (string, error) {
+	if err := v.Check(reference); err != nil {
+		return "", err
+	}
+	path, field := splitReference(reference)
+
+	token, err := v.token()
+	if err != nil {
+		return "", err
+	}
+
+	url := fmt.Sprintf("%s/v1/%s", strings.TrimRight(v.address(), "/"), strings.TrimLeft(path, "/"))
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := v.namespace(); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	var body struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+		Errors []string `json:"errors"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil && res.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("could not parse the response from %s: %s", url, err)
+	}
+	if res.StatusCode != http.StatusOK {
+		if len(body.Errors) > 0 {
+			return "", fmt.Errorf("vault returned %s: %s", res.Status, strings.Join(body.Errors, "; "))
+		}
+		return "", fmt.Errorf("vault returned %s", res.Status)
+	}
+
+	data := body.Data.Data
+	if data == nil {
+		return "", fmt.Errorf("%s is not a KV version 2 secret, or has been deleted", path)
+	}
+	if field == "" {
+		if len(data) != 1 {
+			return "", fmt.Errorf("%s has several fields; add #field (fields: %s)", path, strings.Join(fieldNames(data), ", "))
+		}
+		for _, value := range data {
+			return fmt.Sprintf("%v", value), nil
+		}
+	}
+
+	value, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("%s has no field %s (fields: %s)", path, field, strings.Join(fieldNames(data), ", "))
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+
+	// other JSON values are returned in their JSON form
+	encoded, err := json.Marshal(value)
+	return string(encoded), err
+}
+
+// Check checks that the reference has a secret path
+func (v *Vault) Check(reference string) error {
+	if path, _ := splitReference(reference); path == "" {
+		return fmt.Errorf("missing secret path")
+	}
+
+	return nil
+}
+
+// splitReference splits a reference into the secret path and the field, which may be empty
+func splitReference(reference string) (string, string) {
+	if i := strings.LastIndex(reference, "#"); i >= 0 {
+		return reference[:i], reference[i+1:]
+	}
+
+	return reference, ""
+}
+
+func (v *Vault) address() string {
+	if v.Address != "" {
+		return v.Address
+	}
+	if address := os.Getenv("VAULT_ADDR"); address != "" {
+		return address
+	}
+
+	return "http://127.0.0.1:8200"
+}
+
+func (v *Vault) token() (string, error) {
+	if v.Token != "" {
+		return v.Token, nil
+	}
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err == nil {
+		if contents, err := ioutil.ReadFile(filepath.Join(home, ".vault-token")); err == nil {
+			return strings.TrimSpace(string(contents)), nil
+		}
+	}
+
+	return "", fmt.Errorf("no vault token: set VAULT_TOKEN or log in with the vault CLI")
+}
+
+func (v *Vault) namespace() string {
+	if v.Namespace != "" {
+		return v.Namespace
+	}
+
+	return os.Getenv("VAULT_NAMESPACE")
+}
+
+// fieldNames returns the sorted names of the fields of a secret
+func fieldNames(data map[string]interface{}) []string {
+	var names []string
+	for name := range data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
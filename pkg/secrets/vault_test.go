@@ -0,0 +1,71 @@
+package secrets_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/snapmaster-io/snap/pkg/secrets"
+	"github.com/snapmaster-io/snap/pkg/snaptest"
+)
+
+func TestVaultResolve(t *testing.T) {
+	vault := snaptest.NewVault()
+	defer vault.Close()
+	vault.Put("secret/data/ci", map[string]interface{}{"token": "v1", "user": "ci"})
+	vault.Put("secret/data/ci", map[string]interface{}{"token": "v2", "user": "ci"})
+	vault.Put("secret/data/single", map[string]interface{}{"password": "only"})
+	vault.Put("secret/data/json", map[string]interface{}{"ports": []int{80, 443}})
+
+	resolver := &secrets.Vault{Address: vault.URL, Token: snaptest.VaultToken}
+	tests := map[string]string{
+		"secret/data/ci#token":           "v2",
+		"secret/data/ci?version=1#token": "v1",
+		"/secret/data/ci#user":           "ci",
+		"secret/data/single":             "only",
+		"secret/data/json#ports":         "[80,443]",
+	}
+	for reference, want := range tests {
+		got, err := resolver.Resolve(reference)
+		if err != nil || got != want {
+			t.Errorf("Resolve(%q) = %q, %v; want %q", reference, got, err, want)
+		}
+	}
+
+	errors := map[string]string{
+		"secret/data/ci":         "several fields",
+		"secret/data/ci#missing": "no field missing",
+		"secret/data/unknown#x":  "404",
+		"#token":                 "missing secret path",
+	}
+	for reference, want := range errors {
+		_, err := resolver.Resolve(reference)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Resolve(%q) returned %v; want an error containing %q", reference, err, want)
+		}
+	}
+}
+
+func TestVaultToken(t *testing.T) {
+	vault := snaptest.NewVault()
+	defer vault.Close()
+	vault.Put("secret/data/ci", map[string]interface{}{"token": "s3cret"})
+
+	resolver := &secrets.Vault{Address: vault.URL, Token: "wrong"}
+	_, err := resolver.Resolve("secret/data/ci#token")
+	if err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("Resolve with a wrong token returned %v; want permission denied", err)
+	}
+}
+
+func TestVaultConfigure(t *testing.T) {
+	vault := snaptest.NewVault()
+	defer vault.Close()
+	vault.Put("secret/data/ci", map[string]interface{}{"token": "s3cret"})
+	vault.Configure()
+	defer secrets.Register("vault", &secrets.Vault{})
+
+	resolved, err := secrets.Resolve("vault://secret/data/ci#token")
+	if err != nil || resolved != "s3cret" {
+		t.Errorf("Resolve(vault://secret/data/ci#token) = %q, %v; want s3cret", resolved, err)
+	}
+}
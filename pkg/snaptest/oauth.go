@@ -0,0 +1,70 @@
+package snaptest
+
+import (
+	"fmt"
+	"net/http"
+
+	jose "gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// AuthorizationCode is the code the fake authorization endpoint hands out
+const AuthorizationCode = "snaptest-authorization-code"
+
+// key used to sign the fake id_token (the CLI does not verify the signature)
+var signingKey = []byte("snaptest-signing-key-0123456789ab")
+
+// handleAuthorize implements the first leg of the PKCE flow by redirecting straight
+// back to the redirect URI with an authorization code, as if the user had logged in
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	redirectURI := r.URL.Query().Get("redirect_uri")
+	if redirectURI == "" {
+		http.Error(w, "redirect_uri is required", http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("%s/?code=%s", redirectURI, AuthorizationCode), http.StatusFound)
+}
+
+// handleToken implements the token endpoint, trading the authorization code for an
+// access token and an id_token carrying the user's name and email
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Method != http.MethodPost {
+		http.Error(w, "invalid token request", http.StatusBadRequest)
+		return
+	}
+	if r.Form.Get("code") != AuthorizationCode || r.Form.Get("code_verifier") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		writeJSON(w, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	idToken, err := s.idToken()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"access_token": AccessToken,
+		"id_token":     idToken,
+		"token_type":   "Bearer",
+		"expires_in":   86400,
+	})
+}
+
+// idToken returns a signed JWT with the user's identity claims
+func (s *Server) idToken() (string, error) {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: signingKey}, nil)
+	if err != nil {
+		return "", err
+	}
+
+	claims := map[string]interface{}{
+		"name":  s.name,
+		"email": s.email,
+		"sub":   "snaptest|" + s.email,
+	}
+
+	return jwt.Signed(signer).Claims(claims).CompactSerialize()
+}
@@ -0,0 +1,53 @@
+package snaptest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// DockerHubPath and GitHubPath are the paths under which the fake server stands in for the
+// tools' own APIs, which the CLI calls directly to check credentials
+const (
+	DockerHubPath = "/dockerhub"
+	GitHubPath    = "/github"
+)
+
+// handleDockerHubLogin implements Docker Hub's login endpoint, which accepts the username and
+// password that pass the credential check
+func (s *Server) handleDockerHubLogin(w http.ResponseWriter, r *http.Request) {
+	var login struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&login) != nil {
+		http.Error(w, "invalid login request", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	err := s.check("docker", map[string]string{"username": login.Username, "password": login.Password})
+	s.mu.Unlock()
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		writeJSON(w, map[string]string{"detail": err.Error()})
+		return
+	}
+	writeJSON(w, map[string]string{"token": "snaptest-docker-hub-token"})
+}
+
+// handleGitHubUser implements GitHub's authenticated user endpoint, which accepts the token
+// that passes the credential check
+func (s *Server) handleGitHubUser(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "token ")
+
+	s.mu.Lock()
+	err := s.check("github", map[string]string{"token": token})
+	s.mu.Unlock()
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		writeJSON(w, map[string]string{"message": err.Error()})
+		return
+	}
+	writeJSON(w, map[string]string{"login": "snaptest"})
+}
@@ -0,0 +1,1012 @@
+// Package snaptest provides an in-process fake of the SnapMaster API and its OAuth2
+// endpoints, so that the snap CLI (and automation built on top of it) can be exercised
+// offline.  The server's behaviour is fixed by the options it is started with.
+//
+// A typical test starts a server, seeds it with tools and snaps, and points the CLI
+// configuration at it:
+//
+//	server := snaptest.NewServer()
+//	defer server.Close()
+//	server.AddTool("slack", "simple", slackDefinition)
+//	server.AddSnap("snapmaster/hello", helloDefinition, false)
+//	server.Configure()
+package snaptest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/snapmaster-io/snap/pkg/auth"
+	"github.com/snapmaster-io/snap/pkg/definition"
+	"github.com/snapmaster-io/snap/pkg/history"
+	"github.com/spf13/viper"
+)
+
+// AccessToken is the bearer token the fake server accepts
+const AccessToken = "snaptest-access-token"
+
+// Snap is a snap stored in the fake server
+type Snap struct {
+	SnapID      string                 `json:"snapId"`
+	Account     string                 `json:"account"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Provider    string                 `json:"provider"`
+	Private     bool                   `json:"private"`
+	Forks       int                    `json:"forks"`
+	Text        string                 `json:"text"`
+	Parameters  []definition.Parameter `json:"parameters"`
+	Revision    int                    `json:"revision,omitempty"`
+}
+
+// ActiveSnap is an activated snap stored in the fake server
+type ActiveSnap struct {
+	ActiveSnapID     string              `json:"activeSnapId"`
+	SnapID           string              `json:"snapId"`
+	State            string              `json:"state"`
+	Provider         string              `json:"provider"`
+	Activated        int64               `json:"activated"`
+	ExecutionCounter int                 `json:"executionCounter"`
+	ErrorCounter     int                 `json:"errorCounter"`
+	Params           []map[string]string `json:"params"`
+	SnapRevision     int                 `json:"snapRevision,omitempty"`
+}
+
+// Log is a log entry of an active snap stored in the fake server
+type Log struct {
+	Timestamp    int64                    `json:"timestamp"`
+	ActiveSnapID string                   `json:"activeSnapId"`
+	SnapID       string                   `json:"snapId"`
+	State        string                   `json:"state"`
+	Trigger      string                   `json:"trigger"`
+	Event        string                   `json:"event"`
+	Actions      []map[string]interface{} `json:"actions"`
+}
+
+// Tool is a tool in the fake server's tools library
+type Tool struct {
+	Provider   string
+	Type       string
+	Definition string
+	Connected  bool
+}
+
+// Request is a request received by the fake server
+type Request struct {
+	Method string
+	Path   string
+	Body   string
+}
+
+// Server is a fake SnapMaster API server backed by in-memory state
+type Server struct {
+	*httptest.Server
+
+	// settings, fixed by the options before the server starts
+	name                  string
+	email                 string
+	checkCredentials      func(provider string, values map[string]string) error
+	history               bool
+	omitTestResults       bool
+	ignoreDefinitionEdits bool
+	rejectDefinitionEdits bool
+	omitForkIDs           bool
+	appendCredentialSets  bool
+	ignoreCredentialSets  bool
+
+	mu          sync.Mutex
+	account     string
+	clock       int64
+	profile     map[string]interface{}
+	snaps       map[string]*Snap
+	revisions   map[string][]history.Revision
+	activeSnaps map[string]*ActiveSnap
+	logs        []Log
+	tools       map[string]*Tool
+	credentials map[string][]map[string]string
+	requests    []Request
+}
+
+// Option configures a fake server before it starts serving
+type Option func(s *Server)
+
+// WithAccount sets the account name of the logged in user; an empty name makes the server
+// start without an account, as for a first login
+func WithAccount(account string) Option {
+	return func(s *Server) {
+		s.account = account
+	}
+}
+
+// WithUser sets the identity claims returned in the id_token
+func WithUser(name string, email string) Option {
+	return func(s *Server) {
+		s.name = name
+		s.email = email
+	}
+}
+
+// WithCredentialCheck sets the check that stands in for the harmless read call a connection
+// test makes with a tool's credential values, returning the provider's error.  It is called
+// with the server's lock held.  Without it, a test fails only when a value is empty.
+func WithCredentialCheck(check func(provider string, values map[string]string) error) Option {
+	return func(s *Server) {
+		s.checkCredentials = check
+	}
+}
+
+// WithHistory makes the server keep the revisions of snap definitions and report the
+// revision each active snap runs, as servers with a version history do.  Without it, the
+// history paths don't exist, and the CLI keeps a local journal.
+func WithHistory() Option {
+	return func(s *Server) {
+		s.history = true
+	}
+}
+
+// WithoutTestResults makes connection tests reply a plain success, without the latency and
+// output of the test, as services that don't implement connection tests may
+func WithoutTestResults() Option {
+	return func(s *Server) {
+		s.omitTestResults = true
+	}
+}
+
+// WithoutDefinitionEdits makes the server reply success to edits of a snap's definition
+// without storing them, as services that only edit a snap's visibility do
+func WithoutDefinitionEdits() Option {
+	return func(s *Server) {
+		s.ignoreDefinitionEdits = true
+	}
+}
+
+// WithoutEditAction makes the server reject edits of a snap's definition as an unknown
+// action, as services that don't implement in-place edits may
+func WithoutEditAction() Option {
+	return func(s *Server) {
+		s.rejectDefinitionEdits = true
+	}
+}
+
+// WithoutForkIDs makes the server reply success to forks without the forked snap
+func WithoutForkIDs() Option {
+	return func(s *Server) {
+		s.omitForkIDs = true
+	}
+}
+
+// WithoutCredentialSetReplacement makes the server add a credential set under a name that
+// is taken as a second set, instead of replacing the set of that name
+func WithoutCredentialSetReplacement() Option {
+	return func(s *Server) {
+		s.appendCredentialSets = true
+	}
+}
+
+// WithoutCredentialSetUpdates makes the server reply success to adding a credential set
+// under a name that is taken without storing it
+func WithoutCredentialSetUpdates() Option {
+	return func(s *Server) {
+		s.ignoreCredentialSets = true
+	}
+}
+
+// NewServer starts a fake server with an account named "snaptest" and an empty tools
+// library, configured by the options
+func NewServer(options ...Option) *Server {
+	s := &Server{
+		account:     "snaptest",
+		name:        "Snap Test",
+		email:       "snaptest@example.com",
+		clock:       1600000000000,
+		profile:     make(map[string]interface{}),
+		snaps:       make(map[string]*Snap),
+		revisions:   make(map[string][]history.Revision),
+		activeSnaps: make(map[string]*ActiveSnap),
+		tools:       make(map[string]*Tool),
+		credentials: make(map[string][]map[string]string),
+	}
+	for _, option := range options {
+		option(s)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authorize", s.handleAuthorize)
+	mux.HandleFunc("/oauth/token", s.handleToken)
+	mux.HandleFunc(DockerHubPath+"/v2/users/login/", s.handleDockerHubLogin)
+	mux.HandleFunc(GitHubPath+"/user", s.handleGitHubUser)
+	mux.HandleFunc("/", s.authenticated(s.handleAPI))
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// Configure points the snap CLI configuration and login endpoints at the fake server, logged
+// in with AccessToken
+func (s *Server) Configure() {
+	auth.AuthorizeURL = s.URL + "/authorize"
+	auth.TokenURL = s.URL + "/oauth/token"
+	viper.Set("APIURL", s.URL)
+	viper.Set("AccessToken", AccessToken)
+	viper.Set("Name", s.name)
+	viper.Set("Email", s.email)
+}
+
+// AddTool adds a tool to the tools library, with its YAML definition
+func (s *Server) AddTool(provider string, toolType string, text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tools[provider] = &Tool{Provider: provider, Type: toolType, Definition: text}
+}
+
+// Connect marks a tool as connected, with a default credential set
+func (s *Server) Connect(provider string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if tool, ok := s.tools[provider]; ok {
+		tool.Connected = true
+	}
+}
+
+// AddSnap stores a snap from its YAML definition.  The snap ID is the account
+// prefix of the given ID followed by the name in the definition.
+func (s *Server) AddSnap(snapID string, text string, private bool) (*Snap, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account := strings.SplitN(snapID, "/", 2)[0]
+	return s.storeSnap(account, text, private)
+}
+
+// AddActiveSnap activates a stored snap with the given parameter values
+func (s *Server) AddActiveSnap(snapID string, params []map[string]string) (*ActiveSnap, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.activate(snapID, params)
+}
+
+// AddLog appends a log entry for an active snap, and returns its log ID
+func (s *Server) AddLog(activeSnapID string, state string, actions []map[string]interface{}) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	active := s.activeSnaps[activeSnapID]
+	entry := Log{
+		Timestamp:    s.tick(),
+		ActiveSnapID: activeSnapID,
+		State:        state,
+		Actions:      actions,
+	}
+	if active != nil {
+		entry.SnapID = active.SnapID
+		entry.Trigger = active.Provider
+		active.ExecutionCounter++
+		if state != "complete" {
+			active.ErrorCounter++
+		}
+	}
+	s.logs = append(s.logs, entry)
+
+	return entry.Timestamp
+}
+
+// Snap returns a copy of a stored snap, and whether it exists
+func (s *Server) Snap(snapID string) (Snap, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap, ok := s.snaps[snapID]
+	if !ok {
+		return Snap{}, false
+	}
+	return *snap, true
+}
+
+// ActiveSnap returns a copy of a stored active snap, and whether it exists
+func (s *Server) ActiveSnap(activeSnapID string) (ActiveSnap, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	active, ok := s.activeSnaps[activeSnapID]
+	if !ok {
+		return ActiveSnap{}, false
+	}
+	return *active, true
+}
+
+// Credentials returns the credential sets stored for a tool
+func (s *Server) Credentials(provider string) []map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]map[string]string(nil), s.credentials[provider]...)
+}
+
+// Account returns the account name of the logged in user; it is empty until an account is created
+func (s *Server) Account() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.account
+}
+
+// Requests returns the requests the server has received, in order
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+// authenticated rejects requests that don't carry the fake access token, and records the rest
+func (s *Server) authenticated(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("authorization") != "Bearer "+AccessToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		s.mu.Lock()
+		s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.RequestURI(), Body: string(body)})
+		s.mu.Unlock()
+
+		r.Body = ioutil.NopCloser(strings.NewReader(string(body)))
+		handler(w, r)
+	}
+}
+
+// handleAPI dispatches an API request on its first path segment
+func (s *Server) handleAPI(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := strings.Trim(r.URL.Path, "/")
+	segments := strings.SplitN(path, "/", 2)
+	rest := ""
+	if len(segments) > 1 {
+		rest = segments[1]
+	}
+
+	var data map[string]interface{}
+	if r.Method == http.MethodPost {
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &data)
+	}
+
+	switch segments[0] {
+	case "snaps":
+		s.handleSnaps(w, r.Method, rest, data)
+	case "gallery":
+		s.handleGallery(w)
+	case "activesnaps":
+		s.handleActiveSnaps(w, r.Method, rest, data)
+	case "logs":
+		s.handleLogs(w, rest)
+	case "connections":
+		s.handleConnections(w, r.Method, data)
+	case "entities":
+		s.handleEntities(w, r.Method, rest, data)
+	case "profile":
+		s.handleProfile(w, r.Method, data)
+	case "validateaccount":
+		s.handleValidateAccount(w, r, rest)
+	default:
+		writeError(w, fmt.Sprintf("unknown path %s", r.URL.Path))
+	}
+}
+
+func (s *Server) handleSnaps(w http.ResponseWriter, method string, snapID string, data map[string]interface{}) {
+	if method == http.MethodGet {
+		if snapID == "" {
+			writeSuccess(w, s.listSnaps(func(snap *Snap) bool { return snap.Account == s.account }))
+			return
+		}
+
+		// snap IDs are account/name, so a history path is account/name/history[/revision]
+		if segments := strings.Split(snapID, "/"); s.history && len(segments) > 2 && segments[2] == "history" {
+			s.handleSnapHistory(w, strings.Join(segments[:2], "/"), strings.Join(segments[3:], "/"))
+			return
+		}
+
+		snap, ok := s.snaps[snapID]
+		if !ok || (snap.Private && snap.Account != s.account) {
+			writeError(w, fmt.Sprintf("snap %s not found", snapID))
+			return
+		}
+		writeSuccess(w, snap)
+		return
+	}
+
+	action, _ := data["action"].(string)
+	id, _ := data["snapId"].(string)
+	switch action {
+	case "create":
+		text, _ := data["definition"].(string)
+		snap, err := s.storeSnap(s.account, text, true)
+		if err != nil {
+			writeError(w, err.Error())
+			return
+		}
+		writeSuccess(w, snap)
+	case "delete":
+		if _, ok := s.snaps[id]; !ok {
+			writeError(w, fmt.Sprintf("snap %s not found", id))
+			return
+		}
+		delete(s.snaps, id)
+		delete(s.revisions, id)
+		writeJSON(w, map[string]string{"status": "success"})
+	case "fork":
+		source, ok := s.snaps[id]
+		if !ok {
+			writeError(w, fmt.Sprintf("snap %s not found", id))
+			return
+		}
+		fork := *source
+		if name, _ := data["name"].(string); name != "" {
+			fork.Name = name
+		}
+		fork.Account = s.account
+		fork.SnapID = fmt.Sprintf("%s/%s", s.account, fork.Name)
+		fork.Private = true
+		fork.Forks = 0
+		fork.Revision = 0
+		if _, exists := s.snaps[fork.SnapID]; exists {
+			writeError(w, fmt.Sprintf("snap %s already exists", fork.SnapID))
+			return
+		}
+		source.Forks++
+		s.snaps[fork.SnapID] = &fork
+		s.addRevision(&fork, "fork", 0)
+		if s.omitForkIDs {
+			writeJSON(w, map[string]string{"status": "success"})
+			return
+		}
+		writeSuccess(w, &fork)
+	case "edit":
+		snap, ok := s.snaps[id]
+		if !ok {
+			writeError(w, fmt.Sprintf("snap %s not found", id))
+			return
+		}
+		if _, ok := data["definition"]; ok && s.rejectDefinitionEdits {
+			writeError(w, "unknown action edit")
+			return
+		}
+		if private, ok := data["private"].(bool); ok {
+			snap.Private = private
+		}
+		if text, ok := data["definition"].(string); ok && !s.ignoreDefinitionEdits {
+			if err := updateDefinition(snap, text); err != nil {
+				writeError(w, err.Error())
+				return
+			}
+			if from, ok := data["rollback"].(float64); ok {
+				s.addRevision(snap, "rollback", int(from))
+			} else {
+				s.addRevision(snap, "edit", 0)
+			}
+		}
+		writeSuccess(w, snap)
+	default:
+		writeError(w, fmt.Sprintf("unknown action %s", action))
+	}
+}
+
+// handleSnapHistory returns the revisions of a snap, or the requested revision with its text
+func (s *Server) handleSnapHistory(w http.ResponseWriter, snapID string, requested string) {
+	if _, ok := s.snaps[snapID]; !ok {
+		writeError(w, fmt.Sprintf("snap %s not found", snapID))
+		return
+	}
+
+	revisions := s.revisions[snapID]
+	if requested == "" {
+		list := []history.Revision{}
+		for _, r := range revisions {
+			r.Text = ""
+			list = append(list, r)
+		}
+		writeSuccess(w, list)
+		return
+	}
+
+	for _, r := range revisions {
+		if fmt.Sprintf("%d", r.Revision) == requested {
+			writeSuccess(w, r)
+			return
+		}
+	}
+	writeError(w, fmt.Sprintf("snap %s has no revision %s", snapID, requested))
+}
+
+func (s *Server) handleGallery(w http.ResponseWriter) {
+	writeSuccess(w, s.listSnaps(func(snap *Snap) bool { return !snap.Private }))
+}
+
+func (s *Server) handleActiveSnaps(w http.ResponseWriter, method string, activeSnapID string, data map[string]interface{}) {
+	if method == http.MethodGet {
+		if activeSnapID == "" {
+			var actives []*ActiveSnap
+			for _, id := range s.sortedActiveSnapIDs() {
+				actives = append(actives, s.activeSnaps[id])
+			}
+			writeSuccess(w, actives)
+			return
+		}
+
+		active, ok := s.activeSnaps[activeSnapID]
+		if !ok {
+			writeError(w, fmt.Sprintf("active snap %s not found", activeSnapID))
+			return
+		}
+
+		// the snap definition is returned alongside the active snap, so that callers can
+		// find the snap parameters under "snap.parameters"
+		writeJSON(w, map[string]interface{}{"status": "success", "data": active, "snap": s.snaps[active.SnapID]})
+		return
+	}
+
+	action, _ := data["action"].(string)
+	id, _ := data["snapId"].(string)
+	params := toParams(data["params"])
+
+	if action == "activate" {
+		active, err := s.activate(id, params)
+		if err != nil {
+			writeError(w, err.Error())
+			return
+		}
+		writeSuccess(w, active)
+		return
+	}
+
+	active, ok := s.activeSnaps[id]
+	if !ok {
+		writeError(w, fmt.Sprintf("active snap %s not found", id))
+		return
+	}
+
+	switch action {
+	case "pause":
+		active.State = "paused"
+	case "resume":
+		active.State = "active"
+	case "edit":
+		active.Params = params
+	case "deactivate":
+		delete(s.activeSnaps, id)
+		var logs []Log
+		for _, entry := range s.logs {
+			if entry.ActiveSnapID != id {
+				logs = append(logs, entry)
+			}
+		}
+		s.logs = logs
+		writeJSON(w, map[string]string{"status": "success"})
+		return
+	default:
+		writeError(w, fmt.Sprintf("unknown action %s", action))
+		return
+	}
+
+	writeSuccess(w, active)
+}
+
+func (s *Server) handleLogs(w http.ResponseWriter, activeSnapID string) {
+	logs := []Log{}
+	for _, entry := range s.logs {
+		if activeSnapID == "" || entry.ActiveSnapID == activeSnapID {
+			logs = append(logs, entry)
+		}
+	}
+	writeSuccess(w, logs)
+}
+
+func (s *Server) handleConnections(w http.ResponseWriter, method string, data map[string]interface{}) {
+	if method == http.MethodGet {
+		writeSuccess(w, s.listTools())
+		return
+	}
+
+	action, _ := data["action"].(string)
+	provider, _ := data["provider"].(string)
+	tool, ok := s.tools[provider]
+	if !ok {
+		writeError(w, fmt.Sprintf("tool %s not found", provider))
+		return
+	}
+
+	switch action {
+	case "add":
+		tool.Connected = true
+		s.addCredentials(provider, toParams(data["connectionInfo"]))
+		writeSuccess(w, s.credentials[provider])
+	case "remove":
+		tool.Connected = false
+		delete(s.credentials, provider)
+		writeJSON(w, map[string]string{"status": "success"})
+	case "test":
+		values := make(map[string]string)
+		for _, param := range toParams(data["connectionInfo"]) {
+			if param["type"] != "name" {
+				values[param["name"]] = param["value"]
+			}
+		}
+		s.writeTestResult(w, provider, values)
+	default:
+		writeError(w, fmt.Sprintf("unknown action %s", action))
+	}
+}
+
+func (s *Server) handleEntities(w http.ResponseWriter, method string, provider string, data map[string]interface{}) {
+	if _, ok := s.tools[provider]; !ok {
+		writeError(w, fmt.Sprintf("tool %s not found", provider))
+		return
+	}
+
+	if method == http.MethodGet {
+		writeSuccess(w, s.credentialSets(provider))
+		return
+	}
+
+	action, _ := data["action"].(string)
+	switch action {
+	case "add":
+		params := toParams(data["connectionInfo"])
+		switch {
+		case s.ignoreCredentialSets && s.hasCredentialSet(provider, params):
+		case s.appendCredentialSets:
+			sets := s.credentials[provider]
+			s.credentials[provider] = nil
+			s.addCredentials(provider, params)
+			s.credentials[provider] = append(sets, s.credentials[provider]...)
+		default:
+			s.addCredentials(provider, params)
+		}
+	case "remove":
+		id, _ := data["id"].(string)
+		var sets []map[string]string
+		for _, set := range s.credentials[provider] {
+			if set["__id"] != id {
+				sets = append(sets, set)
+			}
+		}
+		s.credentials[provider] = sets
+	case "test":
+		id, _ := data["id"].(string)
+		for _, set := range s.credentials[provider] {
+			if set["__id"] == id {
+				values := make(map[string]string)
+				for name, value := range set {
+					if name != "__id" {
+						values[name] = value
+					}
+				}
+				s.writeTestResult(w, provider, values)
+				return
+			}
+		}
+		writeError(w, fmt.Sprintf("credential set %s not found", id))
+		return
+	default:
+		writeError(w, fmt.Sprintf("unknown action %s", action))
+		return
+	}
+
+	writeSuccess(w, s.credentialSets(provider))
+}
+
+func (s *Server) handleProfile(w http.ResponseWriter, method string, data map[string]interface{}) {
+	if method == http.MethodPost {
+		for k, v := range data {
+			s.profile[k] = v
+		}
+		writeJSON(w, map[string]string{"status": "success"})
+		return
+	}
+
+	profile := map[string]interface{}{"account": s.account}
+	for k, v := range s.profile {
+		profile[k] = v
+	}
+	writeJSON(w, profile)
+}
+
+func (s *Server) handleValidateAccount(w http.ResponseWriter, r *http.Request, account string) {
+	if r.Method == http.MethodPost {
+		s.account = account
+		writeJSON(w, map[string]string{"status": "success"})
+		return
+	}
+
+	account = r.URL.Query().Get("account")
+	taken := false
+	for _, snap := range s.snaps {
+		if snap.Account == account {
+			taken = true
+		}
+	}
+	writeJSON(w, map[string]bool{"valid": account != "" && !taken})
+}
+
+// storeSnap parses a definition and stores it as a snap in the account; the caller holds the lock
+func (s *Server) storeSnap(account string, text string, private bool) (*Snap, error) {
+	def, err := definition.Parse([]byte(text))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse definition: %s", err)
+	}
+	if err := def.Validate(); err != nil {
+		return nil, err
+	}
+
+	snap := &Snap{
+		SnapID:      fmt.Sprintf("%s/%s", account, def.Name),
+		Account:     account,
+		Name:        def.Name,
+		Description: def.Description,
+		Provider:    def.TriggerProvider(),
+		Private:     private,
+		Text:        text,
+		Parameters:  def.Parameters,
+	}
+	s.snaps[snap.SnapID] = snap
+	s.addRevision(snap, "create", 0)
+
+	return snap, nil
+}
+
+// addRevision records the definition of a snap as a new revision, if the server keeps a
+// version history; the caller holds the lock
+func (s *Server) addRevision(snap *Snap, action string, from int) {
+	if !s.history {
+		return
+	}
+
+	revision := history.Revision{
+		Revision:  len(s.revisions[snap.SnapID]) + 1,
+		Action:    action,
+		From:      from,
+		Timestamp: s.tick(),
+		User:      s.email,
+		Text:      snap.Text,
+	}
+	s.revisions[snap.SnapID] = append(s.revisions[snap.SnapID], revision)
+	snap.Revision = revision.Revision
+}
+
+// updateDefinition replaces the definition of a stored snap, keeping its ID, visibility,
+// and activations; the definition can't rename the snap
+func updateDefinition(snap *Snap, text string) error {
+	def, err := definition.Parse([]byte(text))
+	if err != nil {
+		return fmt.Errorf("could not parse definition: %s", err)
+	}
+	if err := def.Validate(); err != nil {
+		return err
+	}
+	if def.Name != snap.Name {
+		return fmt.Errorf("the definition's name %s doesn't match snap %s", def.Name, snap.SnapID)
+	}
+
+	snap.Description = def.Description
+	snap.Provider = def.TriggerProvider()
+	snap.Text = text
+	snap.Parameters = def.Parameters
+	return nil
+}
+
+// activate creates an active snap; the caller holds the lock
+func (s *Server) activate(snapID string, params []map[string]string) (*ActiveSnap, error) {
+	snap, ok := s.snaps[snapID]
+	if !ok {
+		return nil, fmt.Errorf("snap %s not found", snapID)
+	}
+
+	activated := s.tick()
+	active := &ActiveSnap{
+		ActiveSnapID: fmt.Sprintf("%d", activated),
+		SnapID:       snapID,
+		State:        "active",
+		Provider:     snap.Provider,
+		Activated:    activated,
+		Params:       params,
+		SnapRevision: snap.Revision,
+	}
+	s.activeSnaps[active.ActiveSnapID] = active
+
+	return active, nil
+}
+
+// addCredentials stores a credential set, named by its "name" typed parameter; the caller holds the lock
+func (s *Server) addCredentials(provider string, params []map[string]string) {
+	set := map[string]string{"__id": "default"}
+	for _, param := range params {
+		if param["type"] == "name" {
+			set["__id"] = param["value"]
+		} else {
+			set[param["name"]] = param["value"]
+		}
+	}
+
+	var sets []map[string]string
+	for _, existing := range s.credentials[provider] {
+		if existing["__id"] != set["__id"] {
+			sets = append(sets, existing)
+		}
+	}
+	s.credentials[provider] = append(sets, set)
+}
+
+// hasCredentialSet returns whether the tool has a credential set with the name in the
+// params; the caller holds the lock
+func (s *Server) hasCredentialSet(provider string, params []map[string]string) bool {
+	id := "default"
+	for _, param := range params {
+		if param["type"] == "name" {
+			id = param["value"]
+		}
+	}
+	for _, set := range s.credentials[provider] {
+		if set["__id"] == id {
+			return true
+		}
+	}
+	return false
+}
+
+// writeTestResult checks the credential values of a tool and writes the outcome of the
+// connection test; the caller holds the lock
+func (s *Server) writeTestResult(w http.ResponseWriter, provider string, values map[string]string) {
+	if err := s.check(provider, values); err != nil {
+		writeError(w, err.Error())
+		return
+	}
+	if s.omitTestResults {
+		writeJSON(w, map[string]string{"status": "success"})
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": fmt.Sprintf("connected to %s", provider),
+		"data":    map[string]interface{}{"latencyMs": 1, "output": fmt.Sprintf("connected to %s", provider)},
+	})
+}
+
+// check runs the credential check on a tool's credential values; the caller holds the lock
+func (s *Server) check(provider string, values map[string]string) error {
+	if s.checkCredentials != nil {
+		return s.checkCredentials(provider, values)
+	}
+
+	var empty []string
+	for name, value := range values {
+		if value == "" {
+			empty = append(empty, name)
+		}
+	}
+	if len(empty) > 0 {
+		sort.Strings(empty)
+		return fmt.Errorf("%s: %s is empty", provider, strings.Join(empty, ", "))
+	}
+	return nil
+}
+
+// credentialSets returns copies of the credential sets for a tool; like the service's, they
+// hold the credential values along with the __id name
+func (s *Server) credentialSets(provider string) []map[string]string {
+	sets := []map[string]string{}
+	for _, set := range s.credentials[provider] {
+		copied := make(map[string]string, len(set))
+		for k, v := range set {
+			copied[k] = v
+		}
+		sets = append(sets, copied)
+	}
+
+	return sets
+}
+
+// listSnaps returns the snaps that match the filter, sorted by snap ID
+func (s *Server) listSnaps(filter func(*Snap) bool) []*Snap {
+	snaps := []*Snap{}
+	for _, snap := range s.snaps {
+		if filter(snap) {
+			snaps = append(snaps, snap)
+		}
+	}
+	sort.Slice(snaps, func(i, j int) bool { return snaps[i].SnapID < snaps[j].SnapID })
+
+	return snaps
+}
+
+// listTools returns the tools library in the shape of the /connections response
+func (s *Server) listTools() []map[string]interface{} {
+	var providers []string
+	for provider := range s.tools {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+
+	tools := []map[string]interface{}{}
+	for _, provider := range providers {
+		tool := s.tools[provider]
+		connected := ""
+		if tool.Connected {
+			connected = "base"
+		}
+
+		// the definition is returned both as text and as parsed fields
+		toolDefinition := map[string]interface{}{"text": tool.Definition}
+		if parsed, err := definition.ParseTool([]byte(tool.Definition)); err == nil {
+			toolDefinition["name"] = parsed.Name
+			toolDefinition["connection"] = parsed.Connection
+			toolDefinition["triggers"] = parsed.Triggers
+			toolDefinition["actions"] = parsed.Actions
+		}
+
+		tools = append(tools, map[string]interface{}{
+			"provider":   provider,
+			"type":       tool.Type,
+			"connected":  connected,
+			"definition": toolDefinition,
+		})
+	}
+
+	return tools
+}
+
+func (s *Server) sortedActiveSnapIDs() []string {
+	var ids []string
+	for id := range s.activeSnaps {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+// tick returns a new, increasing millisecond timestamp; the caller holds the lock
+func (s *Server) tick() int64 {
+	s.clock += 1000
+	return s.clock
+}
+
+// toParams converts a decoded JSON array of objects into a slice of string maps
+func toParams(value interface{}) []map[string]string {
+	var params []map[string]string
+	items, _ := value.([]interface{})
+	for _, item := range items {
+		fields, _ := item.(map[string]interface{})
+		param := make(map[string]string)
+		for k, v := range fields {
+			param[k] = fmt.Sprintf("%v", v)
+		}
+		params = append(params, param)
+	}
+
+	return params
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("content-type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeSuccess(w http.ResponseWriter, data interface{}) {
+	writeJSON(w, map[string]interface{}{"status": "success", "data": data})
+}
+
+func writeError(w http.ResponseWriter, message string) {
+	writeJSON(w, map[string]string{"status": "error", "message": message})
+}
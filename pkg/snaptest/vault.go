@@ -0,0 +1,91 @@
+package snaptest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/snapmaster-io/snap/pkg/secrets"
+)
+
+// VaultToken is the token the fake Vault server accepts
+const VaultToken = "snaptest-vault-token"
+
+// Vault is an in-process fake of the HTTP API of a Vault KV version 2 secrets engine,
+// for exercising vault:// secret references offline:
+//
+//	vault := snaptest.NewVault()
+//	defer vault.Close()
+//	vault.Put("secret/data/ci", map[string]interface{}{"token": "s3cret"})
+//	vault.Configure()
+type Vault struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	versions map[string][]map[string]interface{}
+}
+
+// NewVault starts a fake Vault server with no secrets
+func NewVault() *Vault {
+	v := &Vault{versions: make(map[string][]map[string]interface{})}
+	v.Server = httptest.NewServer(http.HandlerFunc(v.handle))
+
+	return v
+}
+
+// Put stores a new version of the secret at the API path (e.g. secret/data/ci), and returns
+// the version number
+func (v *Vault) Put(path string, data map[string]interface{}) int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	path = strings.Trim(path, "/")
+	v.versions[path] = append(v.versions[path], data)
+	return len(v.versions[path])
+}
+
+// Configure registers a vault:// resolver that uses the fake server with VaultToken
+func (v *Vault) Configure() {
+	secrets.Register("vault", &secrets.Vault{Address: v.URL, Token: VaultToken})
+}
+
+func (v *Vault) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != VaultToken {
+		writeVaultError(w, http.StatusForbidden, "permission denied")
+		return
+	}
+	if r.Method != http.MethodGet || !strings.HasPrefix(r.URL.Path, "/v1/") {
+		writeVaultError(w, http.StatusMethodNotAllowed, "unsupported operation")
+		return
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	versions := v.versions[strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/"), "/")]
+	version := len(versions)
+	if requested := r.URL.Query().Get("version"); requested != "" {
+		version, _ = strconv.Atoi(requested)
+	}
+	if version < 1 || version > len(versions) {
+		writeVaultError(w, http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"data": map[string]interface{}{
+			"data":     versions[version-1],
+			"metadata": map[string]interface{}{"version": version, "destroyed": false},
+		},
+	})
+}
+
+// writeVaultError writes an error response in the form of the Vault API
+func writeVaultError(w http.ResponseWriter, status int, errors ...string) {
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string][]string{"errors": append([]string{}, errors...)})
+}
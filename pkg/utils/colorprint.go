@@ -64,6 +64,14 @@ func PrintMessage(message string) {
 	color.Unset()
 }
 
+// PrintWarning prints out a warning message in yellow
+func PrintWarning(message string) {
+	fmt.Printf("snap: ")
+	color.Set(color.FgYellow)
+	fmt.Println(message)
+	color.Unset()
+}
+
 // PrintStatus prints out a status code and optional message
 func PrintStatus(status string, message string) {
 	fmt.Printf("snap: operation status: ")
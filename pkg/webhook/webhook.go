@@ -0,0 +1,191 @@
+// Package webhook captures incoming webhook requests into JSON Lines files, and replays
+// captured (or hand-written) payloads, for building snaps that use webhook triggers.
+package webhook
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Event is a captured webhook request
+type Event struct {
+	Timestamp  int64             `json:"timestamp"`
+	Method     string            `json:"method"`
+	Path       string            `json:"path"`
+	RemoteAddr string            `json:"remoteAddr,omitempty"`
+	Headers    map[string]string `json:"headers"`
+	// Body is the exact body of the request, base64-encoded if it isn't text
+	Body     string `json:"body"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// headers that describe a connection rather than a request, and aren't replayed
+var hopHeaders = map[string]bool{
+	"Accept-Encoding":   true,
+	"Connection":        true,
+	"Content-Length":    true,
+	"Host":              true,
+	"Keep-Alive":        true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+}
+
+// MaxBodySize is the default limit on the size of a captured request body
+const MaxBodySize = 10 << 20
+
+// ErrBodyTooLarge is returned by Capture for a request body over the limit
+var ErrBodyTooLarge = errors.New("request body too large")
+
+// Capture reads a request into an event, refusing bodies of more than maxBytes.  Over the
+// limit, it returns ErrBodyTooLarge, and the server closes the connection after the reply.
+func Capture(w http.ResponseWriter, r *http.Request, maxBytes int64) (Event, error) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBytes))
+	if err != nil {
+		// the reader stops at the limit
+		if int64(len(body)) >= maxBytes {
+			return Event{}, ErrBodyTooLarge
+		}
+		return Event{}, err
+	}
+
+	event := Event{
+		Timestamp:  time.Now().UnixNano() / int64(time.Millisecond),
+		Method:     r.Method,
+		Path:       r.URL.RequestURI(),
+		RemoteAddr: r.RemoteAddr,
+		Headers:    make(map[string]string),
+	}
+	for name, values := range r.Header {
+		event.Headers[name] = strings.Join(values, ", ")
+	}
+	if utf8.Valid(body) {
+		event.Body = string(body)
+	} else {
+		event.Body = base64.StdEncoding.EncodeToString(body)
+		event.Encoding = "base64"
+	}
+
+	return event, nil
+}
+
+// Payload returns the body of the event
+func (e Event) Payload() ([]byte, error) {
+	if e.Encoding == "base64" {
+		return base64.StdEncoding.DecodeString(e.Body)
+	}
+
+	return []byte(e.Body), nil
+}
+
+// Display returns the event as JSON for display, with a JSON body parsed rather than as a string
+func (e Event) Display() []byte {
+	display := make(map[string]interface{})
+	contents, _ := json.Marshal(e)
+	json.Unmarshal(contents, &display)
+
+	var body interface{}
+	if e.Encoding == "" && json.Unmarshal([]byte(e.Body), &body) == nil {
+		display["body"] = body
+	}
+
+	contents, _ = json.Marshal(display)
+	return contents
+}
+
+// Request returns a request that replays the event to a URL, with the event's method, headers,
+// and body (but not the path it was captured on)
+func (e Event) Request(url string) (*http.Request, error) {
+	payload, err := e.Payload()
+	if err != nil {
+		return nil, err
+	}
+
+	method := e.Method
+	if method == "" {
+		method = "POST"
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for name := range e.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !hopHeaders[http.CanonicalHeaderKey(name)] {
+			req.Header.Set(name, e.Headers[name])
+		}
+	}
+
+	return req, nil
+}
+
+// Append appends the event to a JSON Lines file
+func Append(path string, event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadEvents reads the events in a file.  A JSON Lines file of captured events (named *.jsonl)
+// has an event on each line; any other file is the body of a single POST event, sent as JSON
+// if it is valid JSON.
+func ReadEvents(path string) ([]Event, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasSuffix(path, ".jsonl") {
+		event := Event{Method: "POST", Headers: make(map[string]string), Body: string(contents)}
+		if json.Valid(contents) {
+			event.Headers["Content-Type"] = "application/json"
+		}
+		if !utf8.Valid(contents) {
+			event.Body = base64.StdEncoding.EncodeToString(contents)
+			event.Encoding = "base64"
+		}
+		return []Event{event}, nil
+	}
+
+	var events []Event
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	scanner.Buffer(nil, len(contents)+1)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("could not parse line %d of %s: %s", line, path, err)
+		}
+		events = append(events, event)
+	}
+
+	return events, scanner.Err()
+}
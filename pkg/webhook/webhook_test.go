@@ -0,0 +1,38 @@
+package webhook
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCapture(t *testing.T) {
+	r := httptest.NewRequest("POST", "/hooks/github?x=1", strings.NewReader(`{"ref":"main"}`))
+	r.Header.Set("X-GitHub-Event", "push")
+	event, err := Capture(httptest.NewRecorder(), r, 14)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Method != "POST" || event.Path != "/hooks/github?x=1" || event.Headers["X-Github-Event"] != "push" {
+		t.Errorf("captured %+v", event)
+	}
+	if event.Body != `{"ref":"main"}` || event.Encoding != "" {
+		t.Errorf("body %q, encoding %q", event.Body, event.Encoding)
+	}
+
+	binary := httptest.NewRequest("POST", "/", strings.NewReader("\xff\xfe"))
+	event, err = Capture(httptest.NewRecorder(), binary, MaxBodySize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload, _ := event.Payload(); event.Encoding != "base64" || string(payload) != "\xff\xfe" {
+		t.Errorf("binary body %q, encoding %q", event.Body, event.Encoding)
+	}
+}
+
+func TestCaptureTooLarge(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"ref":"main"}`))
+	if _, err := Capture(httptest.NewRecorder(), r, 13); err != ErrBodyTooLarge {
+		t.Errorf("Capture returned %v, want ErrBodyTooLarge", err)
+	}
+}